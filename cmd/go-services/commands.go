@@ -0,0 +1,375 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+	"github.com/hellgate75/go-services/database/migrate"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Records selection flags, shared by the query and export commands
+type selection struct {
+	table  *string
+	fields *string
+	where  listFlag
+	or     *bool
+	order  *string
+	limit  *int64
+	offset *int64
+	format *string
+}
+
+// Defines the records selection flags
+func selectionFlags(flags *flag.FlagSet, format string) *selection {
+	s := &selection{
+		table:  flags.String("table", "", "Table or collection name"),
+		fields: flags.String("fields", "", "Comma separated field names, all fields when empty"),
+		or:     flags.Bool("or", false, "Matches any of the conditions instead of all of them"),
+		order:  flags.String("order", "", "Comma separated sort fields, descending when prefixed by -"),
+		limit:  flags.Int64("limit", 0, "Maximum number of records, no limit when zero"),
+		offset: flags.Int64("offset", 0, "Number of skipped records"),
+		format: flags.String("format", format, "Output format: table, json or csv"),
+	}
+	flags.Var(&s.where, "where", "Condition, e.g. \"age >= 18\", \"name like 'J%'\", \"id in 1,2\" or \"email is null\", repeatable")
+	return s
+}
+
+// Returns the selection filter and query options
+func (s *selection) query() (database.Filter, database.QueryOptions, error) {
+	var conditions = make([]database.Condition, 0)
+	for _, expression := range s.where {
+		condition, err := parseCondition(expression)
+		if err != nil {
+			return database.Filter{}, database.QueryOptions{}, err
+		}
+		conditions = append(conditions, condition)
+	}
+	options := database.QueryOptions{
+		OrderBy: parseOrder(*s.order),
+		Limit:   *s.limit,
+		Offset:  *s.offset,
+	}
+	return database.FromConditions(conditions, !*s.or), options, nil
+}
+
+func pingCommand(ctx context.Context, env *environment, args []string) error {
+	flags := commandFlags("ping")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	check := database.CheckHealth(ctx, env.conn)
+	if !check.Healthy() {
+		return check.Error
+	}
+	_, err := fmt.Fprintf(env.out, "%s is reachable, version %s, latency %v\n", env.config.Driver, check.Version, check.Latency)
+	return err
+}
+
+func queryCommand(ctx context.Context, env *environment, args []string) error {
+	flags := commandFlags("query")
+	sel := selectionFlags(flags, "table")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireTable(*sel.table); err != nil {
+		return err
+	}
+	filter, options, err := sel.query()
+	if err != nil {
+		return err
+	}
+	rs, err := env.conn.QueryPage(ctx, env.ref(*sel.table), parseFields(*sel.fields), filter, options)
+	if err != nil {
+		return err
+	}
+	writer, err := newRecordWriter(*sel.format, env.out, columnNames(rs.MetaData))
+	if err != nil {
+		return err
+	}
+	for _, result := range rs.Records {
+		rec, err := recordOf(rs.MetaData, result)
+		if err != nil {
+			return err
+		}
+		if err = writer.Write(rec); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+func exportCommand(ctx context.Context, env *environment, args []string) (err error) {
+	flags := commandFlags("export")
+	sel := selectionFlags(flags, "json")
+	output := flags.String("output", "", "Output file, the standard output when empty")
+	if err = flags.Parse(args); err != nil {
+		return err
+	}
+	if err = requireTable(*sel.table); err != nil {
+		return err
+	}
+	filter, options, err := sel.query()
+	if err != nil {
+		return err
+	}
+	var out = env.out
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		out = file
+	}
+	rows, err := env.conn.Stream(ctx, env.ref(*sel.table), parseFields(*sel.fields), filter, options)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	writer, err := newRecordWriter(*sel.format, out, columnNames(rows.MetaData()))
+	if err != nil {
+		return err
+	}
+	var count = 0
+	for rows.Next() {
+		rec, err := recordOf(rows.MetaData(), rows.Current())
+		if err != nil {
+			return err
+		}
+		if err = writer.Write(rec); err != nil {
+			return err
+		}
+		count++
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	if *output != "" {
+		_, err = fmt.Fprintf(env.out, "%v records exported to %s\n", count, *output)
+	}
+	return err
+}
+
+func insertCommand(ctx context.Context, env *environment, args []string) error {
+	flags := commandFlags("insert")
+	table := flags.String("table", "", "Table or collection name")
+	var assignments listFlag
+	flags.Var(&assignments, "set", "Field value, e.g. name='John' or age=18, repeatable")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireTable(*table); err != nil {
+		return err
+	}
+	if len(assignments) == 0 {
+		return errors.New(fmt.Sprint("Please provide the record fields with the -set flag"))
+	}
+	var fields = make([]database.Field, 0)
+	var values = make([]database.Value, 0)
+	for _, assignment := range assignments {
+		field, value, err := parseAssignment(assignment)
+		if err != nil {
+			return err
+		}
+		fields = append(fields, field)
+		values = append(values, value)
+	}
+	if err := env.conn.InsertContext(ctx, env.ref(*table), fields, values); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(env.out, "1 record inserted in %s\n", *table)
+	return err
+}
+
+func purgeCommand(ctx context.Context, env *environment, args []string) error {
+	flags := commandFlags("purge")
+	table := flags.String("table", "", "Table or collection name")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireTable(*table); err != nil {
+		return err
+	}
+	if _, err := env.conn.PurgeContext(ctx, env.ref(*table)); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(env.out, "All records removed from %s\n", *table)
+	return err
+}
+
+func createCommand(ctx context.Context, env *environment, args []string) error {
+	flags := commandFlags("create")
+	table := flags.String("table", "", "Table or collection name, the configured database is created when empty")
+	ifNotExists := flags.Bool("if-not-exists", false, "Skips the creation when the table already exists")
+	var definitions listFlag
+	flags.Var(&definitions, "field", "Field definition, name:type[:pk][:notnull][:unique][:auto][:default=expression], repeatable")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *table == "" {
+		ref := env.ref("")
+		if ref.Database == "" {
+			return errors.New(fmt.Sprint("Please provide the table name with the -table flag or the database name"))
+		}
+		if err := env.conn.CreateDbContext(ctx, ref); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(env.out, "Database %s created\n", ref.Database)
+		return err
+	}
+	var fields = make([]database.Field, 0)
+	for _, definition := range definitions {
+		field, err := parseField(definition)
+		if err != nil {
+			return err
+		}
+		fields = append(fields, field)
+	}
+	ref := env.ref(*table)
+	ref.IfNotExists = *ifNotExists
+	if err := env.conn.CreateContext(ctx, ref, fields); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(env.out, "%s created\n", *table)
+	return err
+}
+
+func dropCommand(ctx context.Context, env *environment, args []string) error {
+	flags := commandFlags("drop")
+	table := flags.String("table", "", "Table or collection name, the configured database is dropped when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *table == "" {
+		ref := env.ref("")
+		if ref.Database == "" {
+			return errors.New(fmt.Sprint("Please provide the table name with the -table flag or the database name"))
+		}
+		if err := env.conn.DropDbContext(ctx, ref); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(env.out, "Database %s dropped\n", ref.Database)
+		return err
+	}
+	if err := env.conn.DropContext(ctx, env.ref(*table)); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(env.out, "%s dropped\n", *table)
+	return err
+}
+
+func migrateCommand(ctx context.Context, env *environment, args []string) error {
+	flags := commandFlags("migrate")
+	dir := flags.String("dir", "", "Migrations directory")
+	table := flags.String("table", "", "Bookkeeping table or collection name, "+migrate.DefaultTable+" when empty")
+	dryRun := flags.Bool("dry-run", false, "Prints the steps without running them")
+	lockWait := flags.Duration("lock-wait", time.Minute, "Maximum time waiting for the lock held by another migrator")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintf(flags.Output(), "Usage: go-services [flags] migrate [command flags] up | down [count] | goto <version> | status | version\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dir == "" {
+		return errors.New(fmt.Sprint("Please provide the migrations directory with the -dir flag"))
+	}
+	action, actionArgs := "up", []string{}
+	if flags.NArg() > 0 {
+		action, actionArgs = flags.Arg(0), flags.Args()[1:]
+	}
+	// The status and version actions read the bookkeeping table, without creating it
+	readOnly := action == "status" || action == "version"
+	migrator, err := migrate.New(env.conn, env.ref(""), os.DirFS(*dir), ".", migrate.Options{
+		Table:    *table,
+		DryRun:   *dryRun || readOnly,
+		LockWait: *lockWait,
+	})
+	if err != nil {
+		return err
+	}
+	var steps []migrate.Step
+	switch action {
+	case "up":
+		steps, err = migrator.Up(ctx)
+	case "down":
+		count := 1
+		if len(actionArgs) > 0 {
+			if count, err = strconv.Atoi(actionArgs[0]); err != nil || count < 1 {
+				return errors.New(fmt.Sprintf("Invalid migrations count: %s", actionArgs[0]))
+			}
+		}
+		steps, err = migrator.Down(ctx, count)
+	case "goto":
+		if len(actionArgs) == 0 {
+			return errors.New(fmt.Sprint("Please provide the target version"))
+		}
+		version, parseErr := strconv.ParseInt(actionArgs[0], 10, 64)
+		if parseErr != nil || version < 0 {
+			return errors.New(fmt.Sprintf("Invalid migration version: %s", actionArgs[0]))
+		}
+		steps, err = migrator.Goto(ctx, version)
+	case "status":
+		return printStatus(ctx, env.out, migrator)
+	case "version":
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(env.out, version)
+		return err
+	default:
+		return errors.New(fmt.Sprintf("Unknown migrate action: %s, expected up, down, goto, status or version", action))
+	}
+	for _, step := range steps {
+		prefix := ""
+		if *dryRun {
+			prefix = "(dry run) "
+		}
+		_, _ = fmt.Fprintf(env.out, "%s%s %v %s\n", prefix, step.Direction, step.Migration.Version, step.Migration.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		_, err = fmt.Fprintln(env.out, "No migrations to run")
+	}
+	return err
+}
+
+// Prints the migrations status table
+func printStatus(ctx context.Context, out io.Writer, migrator *migrate.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(table, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, status := range statuses {
+		state := "pending"
+		if status.Missing {
+			state = "missing file"
+		} else if status.Applied {
+			state = "applied"
+		}
+		_, _ = fmt.Fprintln(table, strings.Join([]string{strconv.FormatInt(status.Version, 10), status.Name, state, status.AppliedAt}, "\t"))
+	}
+	return table.Flush()
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Repeatable string flag
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// Comparison conditions: field, symbolic operator and value, e.g. age>=18 or name = 'John'
+var symbolCondition = regexp.MustCompile(`^\s*([^\s=!<>]+)\s*(==|!=|<>|<=|>=|=|<|>)\s*(.*)$`)
+
+// Word operator conditions, e.g. name like 'J%', id in 1,2,3 or email is not null
+var wordCondition = regexp.MustCompile(`(?i)^\s*(\S+)\s+(not\s+like|like|not\s+in|in|is\s+not\s+null|is\s+null)\b\s*(.*)$`)
+
+// Parses a condition expression: a field name, an operator among =, !=, <>, <, <=, >, >=, like, not like, in,
+// not in, is null and is not null, and a value, a comma separated list of values for in and not in
+func parseCondition(expression string) (database.Condition, error) {
+	var field, operator, value string
+	if match := symbolCondition.FindStringSubmatch(expression); match != nil {
+		field, operator, value = match[1], match[2], match[3]
+	} else if match = wordCondition.FindStringSubmatch(expression); match != nil {
+		field, operator, value = match[1], strings.Join(strings.Fields(strings.ToLower(match[2])), " "), match[3]
+	} else {
+		return database.Condition{}, errors.New(fmt.Sprintf("Invalid condition: %s", expression))
+	}
+	var condition = database.Condition{Field: field}
+	switch operator {
+	case "=", "==":
+		condition.Operation = database.Equals
+	case "!=", "<>":
+		condition.Operation = database.Not + database.Equals
+	case "<":
+		condition.Operation = database.LessThan
+	case "<=":
+		condition.Operation = database.LessThanEquals
+	case ">":
+		condition.Operation = database.GraterThan
+	case ">=":
+		condition.Operation = database.GraterThanEquals
+	case "like":
+		condition.Operation = database.Like
+	case "not like":
+		condition.Operation = database.Not + database.Like
+	case "in":
+		condition.Operation = database.In
+	case "not in":
+		condition.Operation = database.Not + database.In
+	case "is null":
+		condition.Operation = database.Null
+	case "is not null":
+		condition.Operation = database.Not + database.Null
+	}
+	switch condition.Operation {
+	case database.Null, database.Not + database.Null:
+		if strings.TrimSpace(value) != "" {
+			return condition, errors.New(fmt.Sprintf("Unexpected value in null condition: %s", expression))
+		}
+	case database.In, database.Not + database.In:
+		var values = make([]interface{}, 0)
+		for _, item := range strings.Split(value, ",") {
+			values = append(values, parseValue(item))
+		}
+		condition.Value = database.Value{Value: values}
+	default:
+		condition.Value = database.Value{Value: parseValue(value)}
+	}
+	return condition, nil
+}
+
+// Converts the text to a nil, integer, float or boolean value, when possible, or to a string. Quoted text is
+// always a string.
+func parseValue(text string) interface{} {
+	text = strings.TrimSpace(text)
+	if len(text) >= 2 && (text[0] == '\'' || text[0] == '"') && text[len(text)-1] == text[0] {
+		return text[1 : len(text)-1]
+	}
+	if text == "null" {
+		return nil
+	}
+	if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return f
+	}
+	if text == "true" || text == "false" {
+		return text == "true"
+	}
+	return text
+}
+
+// Parses a field=value assignment
+func parseAssignment(assignment string) (database.Field, database.Value, error) {
+	index := strings.IndexByte(assignment, '=')
+	if index <= 0 {
+		return database.Field{}, database.Value{}, errors.New(fmt.Sprintf("Invalid assignment, expected field=value: %s", assignment))
+	}
+	return database.Field{Name: strings.TrimSpace(assignment[:index])}, database.Value{Value: parseValue(assignment[index+1:])}, nil
+}
+
+// Field types with size and optional precision, e.g. VARCHAR(64) or DECIMAL(10,2)
+var sizedType = regexp.MustCompile(`^(\w[\w ]*)\((\d+)(?:,\s*(\d+))?\)$`)
+
+// Parses a field definition: name:type[:pk][:notnull][:unique][:auto][:default=expression], where the type may have
+// size and precision, e.g. id:BIGINT:pk, name:VARCHAR(64):notnull or price:DECIMAL(10,2):default=0
+func parseField(definition string) (database.Field, error) {
+	parts := strings.Split(definition, ":")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return database.Field{}, errors.New(fmt.Sprintf("Invalid field, expected name:type[:options]: %s", definition))
+	}
+	var field = database.Field{Name: parts[0], Type: parts[1]}
+	if match := sizedType.FindStringSubmatch(parts[1]); match != nil {
+		field.Type = match[1]
+		field.Size, _ = strconv.ParseInt(match[2], 10, 64)
+		if match[3] != "" {
+			field.Precision, _ = strconv.Atoi(match[3])
+		}
+	}
+	for i := 2; i < len(parts); i++ {
+		switch option := strings.ToLower(parts[i]); {
+		case option == "pk":
+			field.PrimaryKey = true
+		case option == "notnull":
+			field.NotNull = true
+		case option == "unique":
+			field.Unique = true
+		case option == "auto":
+			field.AutoIncrement = true
+		case strings.HasPrefix(option, "default="):
+			// The default expression may hold colons
+			field.Default = strings.Join(parts[i:], ":")[len("default="):]
+			i = len(parts)
+		default:
+			return field, errors.New(fmt.Sprintf("Unknown option %s in field: %s", parts[i], definition))
+		}
+	}
+	return field, nil
+}
+
+// Parses the comma separated sort fields, descending when prefixed by a minus sign
+func parseOrder(order string) []database.OrderBy {
+	var orderBy = make([]database.OrderBy, 0)
+	for _, item := range strings.Split(order, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if strings.HasPrefix(item, "-") {
+			orderBy = append(orderBy, database.OrderBy{Field: item[1:], Direction: database.Descending})
+		} else {
+			orderBy = append(orderBy, database.OrderBy{Field: strings.TrimPrefix(item, "+"), Direction: database.Ascending})
+		}
+	}
+	return orderBy
+}
+
+// Splits the comma separated field names
+func parseFields(fields string) []string {
+	var names = make([]string, 0)
+	for _, name := range strings.Split(fields, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"github.com/hellgate75/go-services/database"
+	"github.com/hellgate75/go-services/database/migrate"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func execute(t *testing.T, config string, args ...string) string {
+	var out, errOut bytes.Buffer
+	if err := run(append([]string{"-config", config}, args...), &out, &errOut); err != nil {
+		t.Fatalf("Command %v error occured: %v %s", args, err, errOut.String())
+	}
+	return out.String()
+}
+
+func TestParseCondition(t *testing.T) {
+	var tests = []struct {
+		expression string
+		condition  database.Condition
+	}{
+		{"age>=18", database.Condition{Field: "age", Operation: database.GraterThanEquals, Value: database.Value{Value: int64(18)}}},
+		{"name = 'John'", database.Condition{Field: "name", Operation: database.Equals, Value: database.Value{Value: "John"}}},
+		{"name != 12", database.Condition{Field: "name", Operation: database.Not + database.Equals, Value: database.Value{Value: int64(12)}}},
+		{"name NOT LIKE J%", database.Condition{Field: "name", Operation: database.Not + database.Like, Value: database.Value{Value: "J%"}}},
+		{"id in 1, 2.5,x", database.Condition{Field: "id", Operation: database.In, Value: database.Value{Value: []interface{}{int64(1), 2.5, "x"}}}},
+		{"email is not null", database.Condition{Field: "email", Operation: database.Not + database.Null}},
+	}
+	for _, test := range tests {
+		condition, err := parseCondition(test.expression)
+		if err != nil {
+			t.Fatalf("Condition %s error occured: %v", test.expression, err)
+		}
+		if !reflect.DeepEqual(condition, test.condition) {
+			t.Fatalf("Wrong condition %s: %+v", test.expression, condition)
+		}
+	}
+	if _, err := parseCondition("age"); err == nil {
+		t.Fatal("Expected invalid condition error")
+	}
+}
+
+func TestParseField(t *testing.T) {
+	field, err := parseField("price:DECIMAL(10,2):notnull:default=0")
+	if err != nil {
+		t.Fatalf("Field error occured: %v", err)
+	}
+	if field.Name != "price" || field.Type != "DECIMAL" || field.Size != 10 || field.Precision != 2 || !field.NotNull || field.Default != "0" {
+		t.Fatalf("Wrong field: %+v", field)
+	}
+	if field, _ = parseField("created:TEXT:default='a:b'"); field.Default != "'a:b'" {
+		t.Fatalf("Wrong default: %v", field.Default)
+	}
+	if _, err = parseField("id:BIGINT:primary"); err == nil {
+		t.Fatal("Expected unknown option error")
+	}
+}
+
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "db.yaml")
+	data := "driver: sqlite\nconnectUrl: " + filepath.Join(dir, "app.db") + "\n"
+	if err := os.WriteFile(config, []byte(data), 0600); err != nil {
+		t.Fatalf("Configuration error occured: %v", err)
+	}
+	if out := execute(t, config, "ping"); !strings.Contains(out, "sqlite is reachable") {
+		t.Fatalf("Wrong ping output: %s", out)
+	}
+	execute(t, config, "create", "-table", "users", "-field", "id:INTEGER:pk", "-field", "name:VARCHAR(64)")
+	execute(t, config, "insert", "-table", "users", "-set", "id=1", "-set", "name=John")
+	execute(t, config, "insert", "-table", "users", "-set", "id=2", "-set", "name='Mary, Jane'")
+	out := execute(t, config, "query", "-table", "users", "-where", "id >= 1", "-order", "-id")
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 4 || !strings.HasPrefix(lines[1], "2 ") || lines[3] != "(2 records)" {
+		t.Fatalf("Wrong table output: %s", out)
+	}
+	out = execute(t, config, "query", "-table", "users", "-where", "name like J%", "-format", "json")
+	if out != "[\n  {\"id\": 1, \"name\": \"John\"}\n]\n" {
+		t.Fatalf("Wrong json output: %s", out)
+	}
+	export := filepath.Join(dir, "users.csv")
+	execute(t, config, "export", "-table", "users", "-format", "csv", "-order", "id", "-output", export)
+	content, _ := os.ReadFile(export)
+	if string(content) != "id,name\n1,John\n2,\"Mary, Jane\"\n" {
+		t.Fatalf("Wrong csv export: %s", content)
+	}
+	if out = execute(t, config, "purge", "-table", "users"); out != "All records removed from users\n" {
+		t.Fatalf("Wrong purge output: %s", out)
+	}
+	execute(t, config, "drop", "-table", "users")
+	migrations := filepath.Join("..", "..", "database", "migrate", "testdata", "migrations")
+	if out = execute(t, config, "migrate", "-dir", migrations, "-dry-run"); !strings.HasPrefix(out, "(dry run) up 1 create_users") {
+		t.Fatalf("Wrong dry run output: %s", out)
+	}
+	if out = execute(t, config, "migrate", "-dir", migrations, "version"); out != "0\n" {
+		t.Fatalf("Wrong initial version output: %s", out)
+	}
+	var errOut bytes.Buffer
+	if err := run([]string{"-config", config, "query", "-table", migrate.DefaultTable}, &errOut, &errOut); err == nil {
+		t.Fatal("The version command should not create the bookkeeping table")
+	}
+	execute(t, config, "migrate", "-dir", migrations, "goto", "2")
+	if out = execute(t, config, "migrate", "-dir", migrations, "version"); out != "2\n" {
+		t.Fatalf("Wrong version output: %s", out)
+	}
+	out = execute(t, config, "migrate", "-dir", migrations, "status")
+	if !strings.Contains(out, "add_user_name  applied") || !strings.Contains(out, "create_events  pending") {
+		t.Fatalf("Wrong status output: %s", out)
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Output record structure, holding the field names and values in order
+type record struct {
+	names  []string
+	values []interface{}
+}
+
+// Returns the value of the named field
+func (r record) value(name string) (interface{}, bool) {
+	for i, n := range r.names {
+		if n == name {
+			return r.values[i], true
+		}
+	}
+	return nil, false
+}
+
+// Returns the column names of the metadata
+func columnNames(metaData database.MetaData) []string {
+	var names = make([]string, 0)
+	for _, column := range metaData.Columns {
+		names = append(names, column.Name)
+	}
+	return names
+}
+
+// Converts the result in an output record, reading the field names from the metadata columns or, when the
+// columns are not available, from the record document: MongoDB documents or in memory map records
+func recordOf(metaData database.MetaData, result database.Result) (record, error) {
+	if len(metaData.Columns) > 0 {
+		return record{names: columnNames(metaData), values: result.Values}, nil
+	}
+	switch doc := result.Document.(type) {
+	case bson.Raw:
+		var ordered bson.D
+		if err := bson.Unmarshal(doc, &ordered); err != nil {
+			return record{}, err
+		}
+		var rec record
+		for _, element := range ordered {
+			rec.names = append(rec.names, element.Key)
+			rec.values = append(rec.values, element.Value)
+		}
+		return rec, nil
+	case map[string]interface{}:
+		var rec record
+		for name := range doc {
+			rec.names = append(rec.names, name)
+		}
+		sort.Strings(rec.names)
+		for _, name := range rec.names {
+			rec.values = append(rec.values, doc[name])
+		}
+		return rec, nil
+	}
+	return record{names: make([]string, 0), values: result.Values}, nil
+}
+
+// Converts the value to text, empty for null values
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case interface{ Hex() string }:
+		// MongoDB object ids
+		return v.Hex()
+	case fmt.Stringer:
+		return v.String()
+	case map[string]interface{}, []interface{}, bson.D, bson.M, bson.A:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+	return fmt.Sprint(value)
+}
+
+// Converts the value to a JSON friendly value, turning the byte slices in text
+func jsonValue(value interface{}) interface{} {
+	if b, ok := value.([]byte); ok {
+		return string(b)
+	}
+	return value
+}
+
+// Records output writer interface
+type recordWriter interface {
+	// Writes the record
+	Write(rec record) error
+	// Completes the output
+	Close() error
+}
+
+// Creates the records writer of the given format: table, json or csv. The columns are printed when no records are
+// written and, for the csv format, fix the header, otherwise taken from the first record.
+func newRecordWriter(format string, out io.Writer, columns []string) (recordWriter, error) {
+	switch strings.ToLower(format) {
+	case "table":
+		return &tableWriter{out: out, columns: columns}, nil
+	case "json":
+		return &jsonWriter{out: out}, nil
+	case "csv":
+		return &csvWriter{out: csv.NewWriter(out), columns: columns}, nil
+	}
+	return nil, errors.New(fmt.Sprintf("Unknown output format: %s, expected table, json or csv", format))
+}
+
+// Aligned text table writer, buffering the records to collect the columns of all of them
+type tableWriter struct {
+	out     io.Writer
+	columns []string
+	records []record
+}
+
+func (w *tableWriter) Write(rec record) error {
+	w.records = append(w.records, rec)
+	return nil
+}
+
+func (w *tableWriter) Close() error {
+	var columns = append(make([]string, 0), w.columns...)
+	var known = make(map[string]bool)
+	for _, column := range columns {
+		known[column] = true
+	}
+	for _, rec := range w.records {
+		for _, name := range rec.names {
+			if !known[name] {
+				known[name] = true
+				columns = append(columns, name)
+			}
+		}
+	}
+	table := tabwriter.NewWriter(w.out, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(table, strings.Join(columns, "\t"))
+	for _, rec := range w.records {
+		var cells = make([]string, 0)
+		for _, column := range columns {
+			value, _ := rec.value(column)
+			cells = append(cells, formatValue(value))
+		}
+		_, _ = fmt.Fprintln(table, strings.Join(cells, "\t"))
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w.out, "(%v records)\n", len(w.records))
+	return err
+}
+
+// JSON array writer, streaming one object per line
+type jsonWriter struct {
+	out   io.Writer
+	count int
+}
+
+func (w *jsonWriter) Write(rec record) error {
+	var b strings.Builder
+	if w.count == 0 {
+		b.WriteString("[\n  {")
+	} else {
+		b.WriteString(",\n  {")
+	}
+	for i, name := range rec.names {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		key, _ := json.Marshal(name)
+		value, err := json.Marshal(jsonValue(rec.values[i]))
+		if err != nil {
+			return errors.New(fmt.Sprintf("Unable to encode field %s: %v", name, err))
+		}
+		b.Write(key)
+		b.WriteString(": ")
+		b.Write(value)
+	}
+	b.WriteString("}")
+	w.count++
+	_, err := io.WriteString(w.out, b.String())
+	return err
+}
+
+func (w *jsonWriter) Close() error {
+	var err error
+	if w.count == 0 {
+		_, err = io.WriteString(w.out, "[]\n")
+	} else {
+		_, err = io.WriteString(w.out, "\n]\n")
+	}
+	return err
+}
+
+// CSV writer, the header lists the given columns or the fields of the first record
+type csvWriter struct {
+	out     *csv.Writer
+	columns []string
+	header  bool
+}
+
+func (w *csvWriter) Write(rec record) error {
+	if !w.header {
+		if len(w.columns) == 0 {
+			w.columns = rec.names
+		}
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+	var cells = make([]string, 0)
+	for _, column := range w.columns {
+		value, _ := rec.value(column)
+		cells = append(cells, formatValue(value))
+	}
+	return w.out.Write(cells)
+}
+
+func (w *csvWriter) writeHeader() error {
+	w.header = true
+	return w.out.Write(w.columns)
+}
+
+func (w *csvWriter) Close() error {
+	if !w.header && len(w.columns) > 0 {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+	w.out.Flush()
+	return w.out.Error()
+}
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Default number of rows written by a single batch statement
+const DefaultBatchSize = 500
+
+// Batch insert options descriptor structure
+type BatchOptions struct {
+	// Maximum number of rows written by a single statement, DefaultBatchSize when not positive
+	BatchSize int
+	// Replace the records having the same key fields instead of failing
+	Upsert bool
+	// Fields identifying the existing records on upsert, required by the PostgreSQL, SQLite, MongoDB and Memory
+	// drivers, while MySQL relies on the table primary and unique keys and doesn't update the given key fields
+	KeyFields []string
+}
+
+// Returns the number of rows written by a single statement
+func (o BatchOptions) Size() int {
+	if o.BatchSize <= 0 {
+		return DefaultBatchSize
+	}
+	return o.BatchSize
+}
+
+// Verifies the key field is listed in the options KeyFields
+func (o BatchOptions) IsKey(field string) bool {
+	for _, k := range o.KeyFields {
+		if k == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Single batch statement result descriptor structure
+type BatchResult struct {
+	// Number of inserted records
+	Inserted int64
+	// Number of updated records
+	Updated int64
+	// Number of affected rows reported by the database when the inserted and updated records can't be told apart,
+	// MySQL upserts only: 1 for each inserted record, 2 for each updated one and 0 for each unchanged one
+	Affected int64
+}
+
+// Batch insert Connection interface
+type BatchConnection interface {
+	// Insert the rows on the database instance in batches, returning the counts of each batch
+	InsertBatch(dbRef DataRef, fields []Field, rows [][]Value, options BatchOptions) ([]BatchResult, error)
+	// Insert the rows on the database instance in batches within the given context, returning the counts of
+	// each batch
+	InsertBatchContext(ctx context.Context, dbRef DataRef, fields []Field, rows [][]Value, options BatchOptions) ([]BatchResult, error)
+}
+
+// Verifies the batch rows match the fields and splits them in chunks of the options batch size
+func Batches(fields []Field, rows [][]Value, options BatchOptions) ([][][]Value, error) {
+	if len(fields) == 0 {
+		return nil, errors.New(fmt.Sprint("Batch insert needs list of Columns"))
+	}
+	for i, row := range rows {
+		if len(row) != len(fields) {
+			return nil, errors.New(fmt.Sprintf("Row %v columns and values must have same length: %v <> %v", i, len(fields), len(row)))
+		}
+	}
+	if options.Upsert {
+		for _, k := range options.KeyFields {
+			found := false
+			for _, f := range fields {
+				if f.Name == k {
+					found = true
+				}
+			}
+			if !found {
+				return nil, errors.New(fmt.Sprintf("Upsert key field %s is not in the batch columns", k))
+			}
+		}
+	}
+	size := options.Size()
+	var batches = make([][][]Value, 0)
+	for start := 0; start < len(rows); start += size {
+		end := start + size
+		if end > len(rows) {
+			end = len(rows)
+		}
+		batches = append(batches, rows[start:end])
+	}
+	return batches, nil
+}
+
+// Returns the Filter matching the record having the row key fields values
+func (o BatchOptions) KeyFilter(fields []Field, row []Value) Filter {
+	var filters = make([]Filter, 0)
+	for i, f := range fields {
+		if o.IsKey(f.Name) && i < len(row) {
+			filters = append(filters, Leaf(Condition{Field: f.Name, Operation: Equals, Value: row[i]}))
+		}
+	}
+	return And(filters...)
+}
@@ -0,0 +1,33 @@
+package database
+
+import (
+	"testing"
+)
+
+func TestBatches(t *testing.T) {
+	fields := []Field{{Name: "code"}, {Name: "name"}}
+	var rows = make([][]Value, 0)
+	for i := 0; i < 5; i++ {
+		rows = append(rows, []Value{{Value: i}, {Value: "name"}})
+	}
+	batches, err := Batches(fields, rows, BatchOptions{BatchSize: 2})
+	if err != nil {
+		t.Fatalf("Batches error occured: %v", err)
+	}
+	if len(batches) != 3 || len(batches[0]) != 2 || len(batches[2]) != 1 {
+		t.Fatalf("Wrong batches: %v", batches)
+	}
+	if batches, _ = Batches(fields, rows, BatchOptions{}); len(batches) != 1 {
+		t.Fatalf("Rows should fit the default batch size: %v", len(batches))
+	}
+	if _, err = Batches(fields, [][]Value{{{Value: 1}}}, BatchOptions{}); err == nil {
+		t.Fatal("Expected row length error")
+	}
+	if _, err = Batches(fields, rows, BatchOptions{Upsert: true, KeyFields: []string{"id"}}); err == nil {
+		t.Fatal("Expected unknown key field error")
+	}
+	key := BatchOptions{KeyFields: []string{"code"}}.KeyFilter(fields, rows[3])
+	if key.Type != AndFilter || len(key.Filters) != 1 || key.Filters[0].Condition.Field != "code" || key.Filters[0].Condition.Value.Value != 3 {
+		t.Fatalf("Wrong key filter: %v", key)
+	}
+}
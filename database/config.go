@@ -0,0 +1,526 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables prefix, followed by the upper case connection name and the field name
+const EnvPrefix = "GO_SERVICES_"
+
+// Configuration source interface
+type ConfigSource interface {
+	// Reads the configuration fields provided by the source, leaving the others empty
+	Load() (DbConfig, error)
+}
+
+// Configuration source function type
+type ConfigSourceFunc func() (DbConfig, error)
+
+func (f ConfigSourceFunc) Load() (DbConfig, error) {
+	return f()
+}
+
+// Configuration source reporting the fields it sets: LoadConfig merges these fields even when zero, e.g. a false
+// tls.enabled, while the fields of the other sources are merged only when not zero
+type FieldsSource interface {
+	ConfigSource
+	// Reads the configuration and the paths of the fields set by the source, e.g. TLS.Enabled
+	LoadFields() (DbConfig, []string, error)
+}
+
+// Configuration source function reporting the set fields
+type fieldsSourceFunc func() (DbConfig, []string, error)
+
+func (f fieldsSourceFunc) Load() (DbConfig, error) {
+	config, _, err := f()
+	return config, err
+}
+
+func (f fieldsSourceFunc) LoadFields() (DbConfig, []string, error) {
+	return f()
+}
+
+// Configuration field validation error structure
+type FieldError struct {
+	// Field path, e.g. pool.maxIdle
+	Field string
+	// Validation failure description
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// Configuration error, listing the invalid fields
+type ConfigError struct {
+	// Invalid fields
+	Fields []FieldError
+}
+
+func (e *ConfigError) Error() string {
+	var messages = make([]string, 0)
+	for _, field := range e.Fields {
+		messages = append(messages, field.Error())
+	}
+	return fmt.Sprintf("Invalid database configuration: %s", strings.Join(messages, "; "))
+}
+
+// Loads the configuration merging the sources in order, the fields set by a source override the ones set by the
+// previous sources, and validates the result. FileSource and EnvSource report the fields they set, so their zero
+// values override the previous sources too.
+func LoadConfig(sources ...ConfigSource) (DbConfig, error) {
+	var config DbConfig
+	for _, source := range sources {
+		var loaded DbConfig
+		var fields []string
+		var err error
+		if fieldsSource, ok := source.(FieldsSource); ok {
+			loaded, fields, err = fieldsSource.LoadFields()
+		} else {
+			loaded, err = source.Load()
+		}
+		if err != nil {
+			return config, err
+		}
+		mergeFields(reflect.ValueOf(&config).Elem(), reflect.ValueOf(loaded), fieldSet(fields), "")
+	}
+	return config, config.Validate()
+}
+
+// Returns the set of field paths, nil without paths
+func fieldSet(fields []string) map[string]bool {
+	if fields == nil {
+		return nil
+	}
+	var set = make(map[string]bool)
+	for _, field := range fields {
+		set[field] = true
+	}
+	return set
+}
+
+// Copies the fields of the source structure to the target one: the fields in the set, by path, or the non zero
+// fields when the set is nil
+func mergeFields(target reflect.Value, source reflect.Value, fields map[string]bool, prefix string) {
+	for i := 0; i < source.NumField(); i++ {
+		field := source.Field(i)
+		path := prefix + source.Type().Field(i).Name
+		if field.Kind() == reflect.Struct {
+			mergeFields(target.Field(i), field, fields, path+".")
+		} else if (fields == nil && !field.IsZero()) || fields[path] {
+			target.Field(i).Set(field)
+		}
+	}
+}
+
+// Returns the paths of the non zero fields of the structure
+func nonZeroFields(source reflect.Value, prefix string) []string {
+	var fields = make([]string, 0)
+	for i := 0; i < source.NumField(); i++ {
+		field := source.Field(i)
+		path := prefix + source.Type().Field(i).Name
+		if field.Kind() == reflect.Struct {
+			fields = append(fields, nonZeroFields(field, path+".")...)
+		} else if !field.IsZero() {
+			fields = append(fields, path)
+		}
+	}
+	return fields
+}
+
+// Returns the field name used by the encoding tag, the Go field name without tag
+func tagName(field reflect.StructField, tag string) string {
+	name := strings.Split(field.Tag.Get(tag), ",")[0]
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// Returns the paths of the structure fields set by the decoded document keys, matching the encoding tag names
+// ignoring the case
+func documentFields(t reflect.Type, document interface{}, tag string, prefix string) []string {
+	var keys = make(map[string]interface{})
+	switch doc := document.(type) {
+	case map[string]interface{}:
+		for key, value := range doc {
+			keys[strings.ToLower(key)] = value
+		}
+	case map[interface{}]interface{}:
+		for key, value := range doc {
+			keys[strings.ToLower(fmt.Sprint(key))] = value
+		}
+	}
+	var fields = make([]string, 0)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value, ok := keys[strings.ToLower(tagName(field, tag))]
+		if !ok {
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct {
+			fields = append(fields, documentFields(field.Type, value, tag, prefix+field.Name+".")...)
+		} else {
+			fields = append(fields, prefix+field.Name)
+		}
+	}
+	return fields
+}
+
+// Decodes the XML elements in nested maps by element name, the root element excluded
+func xmlDocument(data []byte) (map[string]interface{}, error) {
+	var root = make(map[string]interface{})
+	var stack = []map[string]interface{}{}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return root, nil
+		}
+		if err != nil {
+			return root, err
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			if len(stack) == 0 {
+				stack = append(stack, root)
+				continue
+			}
+			parent := stack[len(stack)-1]
+			child, ok := parent[element.Name.Local].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				parent[element.Name.Local] = child
+			}
+			stack = append(stack, child)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
+
+// Validates the configuration, returning a *ConfigError listing the invalid fields
+func (c DbConfig) Validate() error {
+	var fields = make([]FieldError, 0)
+	invalid := func(field string, format string, args ...interface{}) {
+		fields = append(fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+	driverType := DriverToType(c.Driver)
+	if c.Driver == "" {
+		invalid("driver", "is required")
+	} else if _, ok := DriverName(c.Driver); !ok && driverType == DriverType(0) {
+		invalid("driver", "unknown driver %s", c.Driver)
+	}
+	switch driverType {
+	case MongoDbDriver:
+		if c.Url == "" && c.Host == "" {
+			invalid("hostname", "is required without connectUrl")
+		}
+	case SQLiteDriver:
+		if c.Url == "" {
+			invalid("connectUrl", "is required, the database file path or :memory:")
+		}
+	}
+	if c.Port < 0 || c.Port > 65535 {
+		invalid("port", "must be between 0 and 65535, was %v", c.Port)
+	}
+	if c.Password != "" && c.Name == "" {
+		invalid("userName", "is required with userPassword")
+	}
+	if c.PrivateKey != "" && c.Certificate == "" {
+		invalid("certificateFile", "is required with privateKey")
+	}
+	if c.TLS.KeyFile != "" && c.TLS.CertFile == "" {
+		invalid("tls.certFile", "is required with tls.keyFile")
+	}
+	if c.TLS.MinVersion != "" {
+		if _, ok := tlsVersions[c.TLS.MinVersion]; !ok {
+			invalid("tls.minVersion", "must be 1.0, 1.1, 1.2 or 1.3, was %s", c.TLS.MinVersion)
+		}
+	}
+	if c.TLS.X509Auth && c.TLSSettings().CertFile == "" {
+		invalid("tls.certFile", "is required with tls.x509Auth")
+	}
+	c.MongoDB.validate(c, invalid)
+	pool := c.Pool
+	for name, value := range map[string]int{"pool.maxOpen": pool.MaxOpen, "pool.maxIdle": pool.MaxIdle, "pool.minPoolSize": pool.MinPoolSize} {
+		if value < 0 {
+			invalid(name, "cannot be negative, was %v", value)
+		}
+	}
+	if pool.IdleTimeout < 0 {
+		invalid("pool.idleTimeout", "cannot be negative, was %v", pool.IdleTimeout)
+	}
+	if pool.MaxLifetime < 0 {
+		invalid("pool.maxLifetime", "cannot be negative, was %v", pool.MaxLifetime)
+	}
+	if pool.MaxOpen > 0 && pool.MaxIdle > pool.MaxOpen {
+		invalid("pool.maxIdle", "cannot exceed pool.maxOpen %v, was %v", pool.MaxOpen, pool.MaxIdle)
+	}
+	if pool.MaxOpen > 0 && pool.MinPoolSize > pool.MaxOpen {
+		invalid("pool.minPoolSize", "cannot exceed pool.maxOpen %v, was %v", pool.MaxOpen, pool.MinPoolSize)
+	}
+	if len(fields) > 0 {
+		// Map iteration order is random
+		sort.SliceStable(fields, func(i, j int) bool {
+			return fields[i].Field < fields[j].Field
+		})
+		return &ConfigError{Fields: fields}
+	}
+	return nil
+}
+
+// Creates the source reading the configuration file, decoded as JSON, YAML or XML following the file extension.
+// Pool durations are given as Go durations, e.g. 30s, or as nanoseconds. The source reports the fields set by the
+// file keys, so the zero values given in the file override the previous sources.
+func FileSource(path string) ConfigSource {
+	return fieldsSourceFunc(func() (DbConfig, []string, error) {
+		var config DbConfig
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return config, nil, err
+		}
+		var document interface{}
+		var tag string
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+			tag = "json"
+			if err = json.Unmarshal(data, &config); err == nil {
+				err = json.Unmarshal(data, &document)
+			}
+		case ".yaml", ".yml":
+			tag = "yaml"
+			if err = yaml.UnmarshalStrict(data, &config); err == nil {
+				err = yaml.Unmarshal(data, &document)
+			}
+		case ".xml":
+			tag = "xml"
+			if err = xml.Unmarshal(data, &config); err == nil {
+				document, err = xmlDocument(data)
+			}
+		default:
+			return config, nil, errors.New(fmt.Sprintf("Unknown configuration file format: %s, expected json, yaml, yml or xml", path))
+		}
+		if err != nil {
+			return config, nil, errors.New(fmt.Sprintf("Unable to read configuration file %s: %v", path, err))
+		}
+		return config, documentFields(reflect.TypeOf(config), document, tag, ""), nil
+	})
+}
+
+// Environment variable descriptor structure
+type envField struct {
+	// Variable name suffix
+	suffix string
+	// Path of the configuration field
+	field string
+	// Assigns the variable value to the configuration
+	set func(config *DbConfig, value string) error
+}
+
+var envFields = []envField{
+	{"DRIVER", "Driver", func(c *DbConfig, v string) error { c.Driver = v; return nil }},
+	{"URL", "Url", func(c *DbConfig, v string) error { return setUrl(c, v) }},
+	{"HOST", "Host", func(c *DbConfig, v string) error { c.Host = v; return nil }},
+	{"PORT", "Port", func(c *DbConfig, v string) (err error) { c.Port, err = strconv.Atoi(v); return err }},
+	{"DATABASE", "Database.Database", func(c *DbConfig, v string) error { c.Database.Database = v; return nil }},
+	{"SCHEMA", "Database.Schema", func(c *DbConfig, v string) error { c.Database.Schema = v; return nil }},
+	{"USER", "Name", func(c *DbConfig, v string) error { c.Name = v; return nil }},
+	{"PASSWORD", "Password", func(c *DbConfig, v string) error { c.Password = v; return nil }},
+	{"DB_PASSWORD", "DbPassword", func(c *DbConfig, v string) error { c.DbPassword = v; return nil }},
+	{"CERTIFICATE", "Certificate", func(c *DbConfig, v string) error { c.Certificate = v; return nil }},
+	{"PRIVATE_KEY", "PrivateKey", func(c *DbConfig, v string) error { c.PrivateKey = v; return nil }},
+	{"POOL_MAX_OPEN", "Pool.MaxOpen", func(c *DbConfig, v string) (err error) { c.Pool.MaxOpen, err = strconv.Atoi(v); return err }},
+	{"POOL_MAX_IDLE", "Pool.MaxIdle", func(c *DbConfig, v string) (err error) { c.Pool.MaxIdle, err = strconv.Atoi(v); return err }},
+	{"POOL_MIN_SIZE", "Pool.MinPoolSize", func(c *DbConfig, v string) (err error) { c.Pool.MinPoolSize, err = strconv.Atoi(v); return err }},
+	{"POOL_IDLE_TIMEOUT", "Pool.IdleTimeout", func(c *DbConfig, v string) (err error) { c.Pool.IdleTimeout, err = parseDuration(v); return err }},
+	{"POOL_MAX_LIFETIME", "Pool.MaxLifetime", func(c *DbConfig, v string) (err error) { c.Pool.MaxLifetime, err = parseDuration(v); return err }},
+	{"TLS_ENABLED", "TLS.Enabled", func(c *DbConfig, v string) (err error) { c.TLS.Enabled, err = strconv.ParseBool(v); return err }},
+	{"TLS_CA_FILE", "TLS.CAFile", func(c *DbConfig, v string) error { c.TLS.CAFile = v; return nil }},
+	{"TLS_CERT_FILE", "TLS.CertFile", func(c *DbConfig, v string) error { c.TLS.CertFile = v; return nil }},
+	{"TLS_KEY_FILE", "TLS.KeyFile", func(c *DbConfig, v string) error { c.TLS.KeyFile = v; return nil }},
+	{"TLS_SERVER_NAME", "TLS.ServerName", func(c *DbConfig, v string) error { c.TLS.ServerName = v; return nil }},
+	{"TLS_MIN_VERSION", "TLS.MinVersion", func(c *DbConfig, v string) error { c.TLS.MinVersion = v; return nil }},
+	{"TLS_INSECURE_SKIP_VERIFY", "TLS.InsecureSkipVerify", func(c *DbConfig, v string) (err error) {
+		c.TLS.InsecureSkipVerify, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"TLS_X509_AUTH", "TLS.X509Auth", func(c *DbConfig, v string) (err error) { c.TLS.X509Auth, err = strconv.ParseBool(v); return err }},
+	{"MONGODB_SRV", "MongoDB.SRV", func(c *DbConfig, v string) (err error) { c.MongoDB.SRV, err = strconv.ParseBool(v); return err }},
+	{"MONGODB_REPLICA_SET", "MongoDB.ReplicaSet", func(c *DbConfig, v string) error { c.MongoDB.ReplicaSet = v; return nil }},
+	{"MONGODB_AUTH_SOURCE", "MongoDB.AuthSource", func(c *DbConfig, v string) error { c.MongoDB.AuthSource = v; return nil }},
+	{"MONGODB_AUTH_MECHANISM", "MongoDB.AuthMechanism", func(c *DbConfig, v string) error { c.MongoDB.AuthMechanism = v; return nil }},
+	{"MONGODB_READ_PREFERENCE", "MongoDB.ReadPreference", func(c *DbConfig, v string) error { c.MongoDB.ReadPreference = v; return nil }},
+	{"MONGODB_WRITE_CONCERN", "MongoDB.WriteConcern", func(c *DbConfig, v string) error { c.MongoDB.WriteConcern = v; return nil }},
+	{"MONGODB_APP_NAME", "MongoDB.AppName", func(c *DbConfig, v string) error { c.MongoDB.AppName = v; return nil }},
+	{"MONGODB_COMPRESSORS", "MongoDB.Compressors", func(c *DbConfig, v string) error { c.MongoDB.Compressors = strings.Split(v, ","); return nil }},
+}
+
+// Assigns the connection url, parsing the mysql://, postgres:// and mongodb:// urls as URLSource does
+func setUrl(config *DbConfig, value string) error {
+	if _, ok := urlDrivers[urlScheme(value)]; !ok {
+		config.Url = value
+		return nil
+	}
+	parsed, err := ParseURL(value)
+	if err != nil {
+		return err
+	}
+	mergeFields(reflect.ValueOf(config).Elem(), reflect.ValueOf(parsed), nil, "")
+	return nil
+}
+
+// Creates the source reading the GO_SERVICES_<NAME>_* environment variables, where NAME is the upper case
+// connection name, with the non alphanumeric characters replaced by underscores: DRIVER, URL, HOST, PORT,
+// DATABASE, SCHEMA, USER, PASSWORD, DB_PASSWORD, CERTIFICATE, PRIVATE_KEY, POOL_MAX_OPEN, POOL_MAX_IDLE,
+// POOL_MIN_SIZE, POOL_IDLE_TIMEOUT, POOL_MAX_LIFETIME, TLS_ENABLED, TLS_CA_FILE, TLS_CERT_FILE, TLS_KEY_FILE,
+// TLS_SERVER_NAME, TLS_MIN_VERSION, TLS_INSECURE_SKIP_VERIFY, TLS_X509_AUTH, MONGODB_SRV, MONGODB_REPLICA_SET,
+// MONGODB_AUTH_SOURCE, MONGODB_AUTH_MECHANISM, MONGODB_READ_PREFERENCE, MONGODB_WRITE_CONCERN, MONGODB_APP_NAME and
+// MONGODB_COMPRESSORS, a comma separated list
+func EnvSource(name string) ConfigSource {
+	return fieldsSourceFunc(func() (DbConfig, []string, error) {
+		var config DbConfig
+		prefix := EnvPrefix + envName(name) + "_"
+		var set = make([]string, 0)
+		var fields = make([]FieldError, 0)
+		for _, field := range envFields {
+			value, ok := os.LookupEnv(prefix + field.suffix)
+			if !ok || value == "" {
+				continue
+			}
+			// The url variable sets the fields parsed from the url too
+			var variable DbConfig
+			if err := field.set(&variable, value); err != nil {
+				fields = append(fields, FieldError{Field: prefix + field.suffix, Message: err.Error()})
+				continue
+			}
+			variableFields := append(nonZeroFields(reflect.ValueOf(variable), ""), field.field)
+			mergeFields(reflect.ValueOf(&config).Elem(), reflect.ValueOf(variable), fieldSet(variableFields), "")
+			set = append(set, variableFields...)
+		}
+		if len(fields) > 0 {
+			return config, nil, &ConfigError{Fields: fields}
+		}
+		return config, set, nil
+	})
+}
+
+// Converts the connection name to the environment variables name format
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToUpper(name))
+}
+
+// Creates the source parsing the connection url, see ParseURL
+func URLSource(rawURL string) ConfigSource {
+	return ConfigSourceFunc(func() (DbConfig, error) {
+		return ParseURL(rawURL)
+	})
+}
+
+// Driver names by url scheme
+var urlDrivers = map[string]string{
+	"mysql":       "mysql",
+	"postgres":    "postgres",
+	"postgresql":  "postgres",
+	"mongodb":     "mongodb",
+	"mongodb+srv": "mongodb",
+}
+
+// Returns the lower case url scheme, empty when not available
+func urlScheme(rawURL string) string {
+	if index := strings.Index(rawURL, "://"); index > 0 {
+		return strings.ToLower(rawURL[:index])
+	}
+	return ""
+}
+
+// Parses a mysql://, postgres:// (or postgresql://) and mongodb:// (or mongodb+srv://) url in the driver, host, port,
+// credentials and database fields. The PostgreSQL and MongoDB urls are kept as connection url, while the MySQL url
+// is converted to the user:password@tcp(host:port)/database?options data source name. MongoDB urls with many hosts
+// give the comma separated host:port list as host name. The PostgreSQL search_path option gives the schema name.
+func ParseURL(rawURL string) (DbConfig, error) {
+	var config DbConfig
+	driver, ok := urlDrivers[urlScheme(rawURL)]
+	if !ok {
+		return config, errors.New(fmt.Sprintf("Unsupported connection url scheme, expected mysql, postgres or mongodb: %s", urlScheme(rawURL)))
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return config, errors.New(fmt.Sprintf("Invalid connection url: %v", err))
+	}
+	config.Driver = driver
+	if u.User != nil {
+		config.Name = u.User.Username()
+		config.Password, _ = u.User.Password()
+	}
+	if strings.Contains(u.Host, ",") {
+		config.Host = u.Host
+	} else {
+		config.Host = u.Hostname()
+		if port := u.Port(); port != "" {
+			if config.Port, err = strconv.Atoi(port); err != nil {
+				return config, errors.New(fmt.Sprintf("Invalid connection url port: %s", port))
+			}
+		}
+	}
+	config.Database.Database = strings.TrimPrefix(u.Path, "/")
+	switch driver {
+	case "mysql":
+		config.Url = mySqlDataSourceName(u)
+	case "postgres":
+		config.Database.Schema = u.Query().Get("search_path")
+		config.Url = rawURL
+	default:
+		config.Url = rawURL
+	}
+	return config, nil
+}
+
+// Converts the mysql:// url to the MySQL driver data source name
+func mySqlDataSourceName(u *url.URL) string {
+	var dsn strings.Builder
+	if u.User != nil {
+		dsn.WriteString(u.User.Username())
+		if password, ok := u.User.Password(); ok {
+			dsn.WriteString(":" + password)
+		}
+		dsn.WriteString("@")
+	}
+	if u.Host != "" {
+		dsn.WriteString("tcp(" + u.Host + ")")
+	}
+	dsn.WriteString("/" + strings.TrimPrefix(u.Path, "/"))
+	if u.RawQuery != "" {
+		dsn.WriteString("?" + u.RawQuery)
+	}
+	return dsn.String()
+}
+
+// Parses a Go duration, e.g. 30s or 5m, or a number of nanoseconds
+func parseDuration(text string) (time.Duration, error) {
+	text = strings.TrimSpace(text)
+	if ns, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return time.Duration(ns), nil
+	}
+	return time.ParseDuration(text)
+}
@@ -1,8 +1,8 @@
 package database
 
 import (
+	"context"
 	"reflect"
-	"strings"
 )
 
 // Operation enumeration type
@@ -35,22 +35,18 @@ const (
 	Not Operation = 50
 	// MongoDb DriverType enumeration type
 	MongoDbDriver DriverType = iota + 1
-	// MongoDb DriverType enumeration type
+	// MySQL DriverType enumeration type
 	MySQLDriver
+	// PostgreSQL DriverType enumeration type
+	PostgreSQLDriver
+	// SQLite DriverType enumeration type
+	SQLiteDriver
+	// In memory DriverType enumeration type
+	MemoryDriver
 )
 
-func DriverToType(driver string) DriverType {
-	switch strings.ToLower(driver) {
-	case "mongo", "mongo-db", "mongodb":
-		return MongoDbDriver
-	case "mysql":
-		return MySQLDriver
-	default:
-		return DriverType(0)
-	}
-}
-
-//Database Configuration structure
+//Database Configuration structure. The formats and the JSON, YAML and XML encodings hold the redacted view, so the
+// encoded configuration can't be decoded back with its credentials: PlainDbConfig encodes all the values.
 type DbConfig struct {
 	// Database name
 	Driver string `json:"driver,omitempty" yaml:"driver,omitempty" xml:"driver,omitempty"`
@@ -64,14 +60,20 @@ type DbConfig struct {
 	Password string `json:"userPassword,omitempty" yaml:"userPassword,omitempty" xml:"user-password,omitempty"`
 	// Database Password field
 	DbPassword string `json:"dbPassword,omitempty" yaml:"dbPassword,omitempty" xml:"db-password,omitempty"`
-	// MongoDb Certificate file path field
+	// Client certificate file path field, default of the TLS CertFile
 	Certificate string `json:"certificateFile,omitempty" yaml:"certificateFile,omitempty" xml:"certificate-file,omitempty"`
-	// MongoDb Private Key file path field
+	// Client private key file path field, default of the TLS KeyFile
 	PrivateKey string `json:"privateKey,omitempty" yaml:"privateKey,omitempty" xml:"private-key,omitempty"`
 	// MongoDb Host name field
 	Host string `json:"hostname,omitempty" yaml:"hostname,omitempty" xml:"hostname,omitempty"`
 	// MongoDb Port field
 	Port int `json:"port,omitempty" yaml:"port,omitempty" xml:"port,omitempty"`
+	// Connection pool configuration
+	Pool PoolConfig `json:"pool,omitempty" yaml:"pool,omitempty" xml:"pool,omitempty"`
+	// TLS configuration, MySQL, PostgreSQL and MongoDB drivers only
+	TLS TLSConfig `json:"tls,omitempty" yaml:"tls,omitempty" xml:"tls,omitempty"`
+	// MongoDB client options, MongoDB driver only
+	MongoDB MongoOptions `json:"mongodb,omitempty" yaml:"mongodb,omitempty" xml:"mongodb,omitempty"`
 }
 
 // Field descriptor structure
@@ -84,6 +86,16 @@ type Field struct {
 	Size int64
 	// Field precision
 	Precision int
+	// Field is part of the entity primary key
+	PrimaryKey bool
+	// Field doesn't accept null values
+	NotNull bool
+	// Field default value expression, used verbatim (e.g. 'active', 0, CURRENT_TIMESTAMP)
+	Default string
+	// Field value is automatically generated incrementing a counter
+	AutoIncrement bool
+	// Field values must be unique in the entity
+	Unique bool
 }
 
 // Value descriptor structure
@@ -160,10 +172,64 @@ type DataRef struct {
 	Schema string
 	// Data Query / Table SQL Reference
 	SQL string
+	// Skip creation when the element already exists
+	IfNotExists bool
+}
+
+// Context aware Connection interface, any operation honors the given context deadline and cancellation
+type ContextConnection interface {
+	// Execute Query on the database instance within the given context
+	QueryContext(ctx context.Context, dbRef DataRef, fields []string, conditions []Condition, withAnd bool) (ResultSet, error)
+	// Insert record on the database instance within the given context
+	InsertContext(ctx context.Context, dbRef DataRef, fields []Field, values []Value) error
+	// Update one or more records on the database instance within the given context
+	UpdateContext(ctx context.Context, dbRef DataRef, conditions []Condition, fields []Field, values []Value, withAnd bool) (int64, error)
+	// Delete one or more records on the database instance within the given context
+	DeleteContext(ctx context.Context, dbRef DataRef, conditions []Condition, withAnd bool) (int64, error)
+	// Purge one or more records on the database instance within the given context
+	PurgeContext(ctx context.Context, dbRef DataRef) (int64, error)
+	// Create Namespace, Collection or Entity element on the database instance within the given context
+	CreateContext(ctx context.Context, dbRef DataRef, fields []Field) error
+	// Create new database instance within the given context
+	CreateDbContext(ctx context.Context, dbRef DataRef) error
+	// Drop Namespace, Collection or Entity element on the database instance within the given context
+	DropContext(ctx context.Context, dbRef DataRef) error
+	// Drop existing database instance within the given context
+	DropDbContext(ctx context.Context, dbRef DataRef) error
+}
+
+// Filter expression aware Connection interface
+type FilterConnection interface {
+	// Execute Query on the database instance, selecting records matching the Filter expression
+	QueryFilter(ctx context.Context, dbRef DataRef, fields []string, filter Filter) (ResultSet, error)
+	// Execute Query on the database instance, selecting records matching the Filter expression, sorted and paged
+	// following the QueryOptions
+	QueryPage(ctx context.Context, dbRef DataRef, fields []string, filter Filter, options QueryOptions) (ResultSet, error)
+	// Update records matching the Filter expression on the database instance
+	UpdateFilter(ctx context.Context, dbRef DataRef, filter Filter, fields []Field, values []Value) (int64, error)
+	// Delete records matching the Filter expression on the database instance
+	DeleteFilter(ctx context.Context, dbRef DataRef, filter Filter) (int64, error)
+	// Count the records matching the Filter expression on the database instance
+	Count(ctx context.Context, dbRef DataRef, filter Filter) (int64, error)
+}
+
+// Streaming queries Connection interface
+type StreamConnection interface {
+	// Execute Query on the database instance, returning an iterator over the records matching the Filter expression,
+	// sorted and paged following the QueryOptions
+	Stream(ctx context.Context, dbRef DataRef, fields []string, filter Filter, options QueryOptions) (Rows, error)
 }
 
 // Connection interface
 type Connection interface {
+	ContextConnection
+	FilterConnection
+	StreamConnection
+	TxConnection
+	BatchConnection
+	StatsConnection
+	HealthConnection
+	ScriptConnection
 	// Execute Query on the database instance
 	Query(dbRef DataRef, fields []string, conditions []Condition, withAnd bool) (ResultSet, error)
 	// Insert record on the database instance
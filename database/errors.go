@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// Closed connection error, returned by the operations of a closed or invalid connection
+	ErrClosed = errors.New(fmt.Sprint("Database is closed,please reconnect before any operation"))
+	// Missing entity, record, table or database error
+	ErrNotFound = errors.New(fmt.Sprint("Entity not found"))
+	// Unique index or primary key violation error
+	ErrDuplicateKey = errors.New(fmt.Sprint("Duplicate key"))
+	// Integrity constraint violation error, e.g. a foreign key, not null or check constraint
+	ErrConstraint = errors.New(fmt.Sprint("Constraint violation"))
+	// Expired operation error, an expired context deadline or a server side timeout
+	ErrTimeout = errors.New(fmt.Sprint("Operation timed out"))
+	// Operation not supported by the driver error
+	ErrUnsupported = errors.New(fmt.Sprint("Operation not supported"))
+)
+
+// Database operation error structure, wrapping the driver error
+type OpError struct {
+	// Operation name, e.g. Insert
+	Op string
+	// Driver name
+	Driver string
+	// Operation data reference
+	Ref DataRef
+	// Underlying error, matching the sentinel errors with errors.Is
+	Err error
+}
+
+func (e *OpError) Error() string {
+	var names = make([]string, 0)
+	for _, name := range []string{e.Ref.Database, e.Ref.Schema, e.Ref.Namespace} {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Sprintf("%s::%s %v", e.Driver, e.Op, e.Err)
+	}
+	return fmt.Sprintf("%s::%s %s: %v", e.Driver, e.Op, strings.Join(names, "."), e.Err)
+}
+
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
+// Driver error classified as one of the sentinel errors
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() error {
+	return e.err
+}
+
+func (e *kindError) Is(target error) bool {
+	return target == e.kind
+}
+
+// Classifies the driver error as the sentinel error kind, e.g. ErrDuplicateKey: the returned error matches both the
+// kind and the driver error with errors.Is and errors.As, and keeps the driver error message
+func WrapError(kind error, err error) error {
+	if err == nil || kind == nil || errors.Is(err, kind) {
+		return err
+	}
+	return &kindError{kind: kind, err: err}
+}
+
+// Wraps the error of the driver operation in an *OpError, classifying the expired context deadlines as ErrTimeout and
+// masking the credentials in the message, as RedactString does. Errors already wrapped, e.g. by a nested operation,
+// are returned unchanged.
+func NewOpError(op string, driver string, ref DataRef, err error) error {
+	if err == nil {
+		return nil
+	}
+	var opErr *OpError
+	if errors.As(err, &opErr) {
+		return err
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = WrapError(ErrTimeout, err)
+	}
+	return &OpError{Op: op, Driver: driver, Ref: ref, Err: Redact(err)}
+}
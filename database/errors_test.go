@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type codeError struct {
+	code int
+}
+
+func (e *codeError) Error() string {
+	return "server error"
+}
+
+func TestOpError(t *testing.T) {
+	if NewOpError("Insert", "test", DataRef{}, nil) != nil {
+		t.Fatal("Nil error should stay nil")
+	}
+	cause := &codeError{code: 1062}
+	err := NewOpError("Insert", "test", DataRef{Database: "app", Namespace: "users"}, WrapError(ErrDuplicateKey, cause))
+	if err.Error() != "test::Insert app.users: server error" {
+		t.Fatalf("Wrong operation error message: %v", err)
+	}
+	var opErr *OpError
+	var codeErr *codeError
+	if !errors.As(err, &opErr) || opErr.Op != "Insert" || !errors.As(err, &codeErr) || codeErr.code != 1062 {
+		t.Fatalf("Wrong wrapped errors: %+v", err)
+	}
+	if !errors.Is(err, ErrDuplicateKey) || errors.Is(err, ErrConstraint) {
+		t.Fatalf("Wrong error kind: %v", err)
+	}
+	if NewOpError("Query", "test", DataRef{}, err) != err {
+		t.Fatal("Operation errors should not be wrapped twice")
+	}
+	err = NewOpError("Ping", "test", DataRef{}, context.DeadlineExceeded)
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) || err.Error() != "test::Ping context deadline exceeded" {
+		t.Fatalf("Expected timeout error: %v", err)
+	}
+	err = NewOpError("Query", "test", DataRef{}, WrapError(ErrTimeout, errors.New("dial postgres://app:secret@db/app failed")))
+	if err.Error() != "test::Query dial postgres://app:*****@db/app failed" || !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Wrong redacted operation error: %v", err)
+	}
+	if WrapError(ErrClosed, ErrClosed) != ErrClosed {
+		t.Fatal("Sentinel errors should not be wrapped in their own kind")
+	}
+}
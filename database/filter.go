@@ -0,0 +1,98 @@
+package database
+
+// FilterType enumeration type
+type FilterType byte
+
+const (
+	// Single Condition leaf FilterType enumeration type
+	LeafFilter FilterType = iota + 1
+	// Logical conjunction of the sub-filters FilterType enumeration type
+	AndFilter
+	// Logical disjunction of the sub-filters FilterType enumeration type
+	OrFilter
+	// Logical negation of the sub-filters conjunction FilterType enumeration type
+	NotFilter
+)
+
+// Filter expression descriptor structure, a tree of And, Or and Not groups with Condition leaves.
+// Groups with no sub-filters don't apply any restriction.
+type Filter struct {
+	// Filter expression type
+	Type FilterType
+	// Leaf Condition, available for the LeafFilter type
+	Condition Condition
+	// Sub-filters, available for the AndFilter, OrFilter and NotFilter types
+	Filters []Filter
+}
+
+// Creates a leaf Filter with the given Condition
+func Leaf(cond Condition) Filter {
+	return Filter{
+		Type:      LeafFilter,
+		Condition: cond,
+	}
+}
+
+// Creates a Filter matching when all the given filters match
+func And(filters ...Filter) Filter {
+	return Filter{
+		Type:    AndFilter,
+		Filters: filters,
+	}
+}
+
+// Creates a Filter matching when any of the given filters matches
+func Or(filters ...Filter) Filter {
+	return Filter{
+		Type:    OrFilter,
+		Filters: filters,
+	}
+}
+
+// Creates a Filter matching when the given filter doesn't match
+func Negate(filter Filter) Filter {
+	return Filter{
+		Type:    NotFilter,
+		Filters: []Filter{filter},
+	}
+}
+
+// Creates a Filter from a flat list of conditions, joined with the AND or OR logical operator
+func FromConditions(conditions []Condition, withAnd bool) Filter {
+	var filters = make([]Filter, 0)
+	for _, cond := range conditions {
+		filters = append(filters, Leaf(cond))
+	}
+	if withAnd {
+		return And(filters...)
+	}
+	return Or(filters...)
+}
+
+// Verifies the Filter doesn't apply any restriction
+func (f Filter) IsEmpty() bool {
+	switch f.Type {
+	case LeafFilter:
+		return false
+	case AndFilter, OrFilter, NotFilter:
+		for _, sub := range f.Filters {
+			if !sub.IsEmpty() {
+				return false
+			}
+		}
+		return true
+	default:
+		return true
+	}
+}
+
+// Returns the not empty sub-filters
+func (f Filter) Children() []Filter {
+	var filters = make([]Filter, 0)
+	for _, sub := range f.Filters {
+		if !sub.IsEmpty() {
+			filters = append(filters, sub)
+		}
+	}
+	return filters
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// Health probing Connection interface
+type HealthConnection interface {
+	// Verifies the database server is reachable
+	Ping(ctx context.Context) error
+	// Returns the database server version
+	ServerVersion(ctx context.Context) (string, error)
+}
+
+// Connection health check result structure
+type HealthCheck struct {
+	// Ping round trip time
+	Latency time.Duration
+	// Database server version, when available
+	Version string
+	// Ping or server version error
+	Error error
+}
+
+// Verifies the health check hasn't failed
+func (h HealthCheck) Healthy() bool {
+	return h.Error == nil
+}
+
+// Pings the database server, measuring the latency, and reads the server version
+func CheckHealth(ctx context.Context, conn HealthConnection) HealthCheck {
+	var check HealthCheck
+	start := time.Now()
+	check.Error = conn.Ping(ctx)
+	check.Latency = time.Since(start)
+	if check.Error != nil {
+		return check
+	}
+	check.Version, check.Error = conn.ServerVersion(ctx)
+	return check
+}
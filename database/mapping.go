@@ -0,0 +1,314 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Date and time layouts accepted converting text values to time.Time
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// Struct field mapping descriptor
+type FieldMapping struct {
+	// Mapped field name
+	Name string
+	// Struct field index, as used by reflect.Value.FieldByIndex
+	Index []int
+	// Field skipped by FieldValues when holding the zero value
+	OmitEmpty bool
+	// Tag options following the field name
+	Options []string
+}
+
+// Verifies the tag options contain the given option
+func (m FieldMapping) HasOption(option string) bool {
+	for _, o := range m.Options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the mapped field name, from the db tag, the json tag or the lowercase field name, and the tag options
+func mappedName(f reflect.StructField) (string, []string, bool) {
+	tag := f.Tag.Get("db")
+	if tag == "" {
+		tag = f.Tag.Get("json")
+	}
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "-" && len(parts) == 1 {
+		return "", nil, false
+	}
+	if name == "" {
+		name = strings.ToLower(f.Name)
+	}
+	return name, parts[1:], true
+}
+
+// Lists the mapped exported fields of the struct type, flattening the untagged embedded structs. Fields are named
+// by the db tag, falling back to the json tag and to the lowercase field name, and the "-" name skips the field.
+func StructMapping(t reflect.Type) []FieldMapping {
+	return structFields(t, nil)
+}
+
+func structFields(t reflect.Type, index []int) []FieldMapping {
+	var fields = make([]FieldMapping, 0)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fieldIndex := append(append([]int{}, index...), i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag.Get("db") == "" && f.Tag.Get("json") == "" {
+			fields = append(fields, structFields(f.Type, fieldIndex)...)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		name, options, ok := mappedName(f)
+		if !ok {
+			continue
+		}
+		m := FieldMapping{
+			Name:    name,
+			Index:   fieldIndex,
+			Options: options,
+		}
+		m.OmitEmpty = m.HasOption("omitempty")
+		fields = append(fields, m)
+	}
+	return fields
+}
+
+type columnValue struct {
+	value  interface{}
+	goType reflect.Type
+}
+
+// Returns the record values by lowercase column name, from the result columns or from the map record document
+func recordValues(metaData MetaData, record Result) (map[string]columnValue, error) {
+	var values = make(map[string]columnValue)
+	if len(metaData.Columns) > 0 {
+		for i, col := range metaData.Columns {
+			if i < len(record.Values) {
+				values[strings.ToLower(col.Name)] = columnValue{
+					value:  record.Values[i],
+					goType: col.GoType,
+				}
+			}
+		}
+		return values, nil
+	}
+	if doc, ok := record.Document.(map[string]interface{}); ok {
+		for name, value := range doc {
+			values[strings.ToLower(name)] = columnValue{value: value}
+		}
+		return values, nil
+	}
+	return values, errors.New(fmt.Sprint("Result set columns are not available"))
+}
+
+// Converts the value to the given type: numbers are converted between them and from text, text is parsed as
+// boolean or time.Time and numbers are true when not zero
+func coerce(value interface{}, to reflect.Type) (interface{}, bool) {
+	v := reflect.ValueOf(value)
+	if v.Type() == to || to.Kind() == reflect.Interface {
+		return value, true
+	}
+	if convertible(v.Type(), to) {
+		if isNumber(v.Kind()) && overflows(v, to) {
+			return nil, false
+		}
+		return v.Convert(to).Interface(), true
+	}
+	if isNumber(v.Kind()) && to.Kind() == reflect.Bool {
+		return reflect.ValueOf(v.Convert(reflect.TypeOf(float64(0))).Float() != 0).Convert(to).Interface(), true
+	}
+	var text string
+	switch {
+	case v.Kind() == reflect.String:
+		text = v.String()
+	case isBytes(v.Type()):
+		text = string(v.Bytes())
+	default:
+		return nil, false
+	}
+	if to == timeType {
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, text); err == nil {
+				return t, true
+			}
+		}
+		return nil, false
+	}
+	result := reflect.New(to).Elem()
+	switch to.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(text), 10, to.Bits())
+		if err != nil {
+			return nil, false
+		}
+		result.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(text), 10, to.Bits())
+		if err != nil {
+			return nil, false
+		}
+		result.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(strings.TrimSpace(text), to.Bits())
+		if err != nil {
+			return nil, false
+		}
+		result.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(text))
+		if err != nil {
+			return nil, false
+		}
+		result.SetBool(b)
+	default:
+		return nil, false
+	}
+	return result.Interface(), true
+}
+
+// Scans the record values in the struct fields, coercing the values to the field type. The interface fields receive
+// the values coerced to the column GoType.
+func scanRecord(metaData MetaData, record Result, target reflect.Value, fields []FieldMapping) error {
+	values, err := recordValues(metaData, record)
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		cv, ok := values[strings.ToLower(f.Name)]
+		if !ok {
+			continue
+		}
+		value := cv.value
+		field := target.FieldByIndex(f.Index)
+		if value != nil && cv.goType != nil && field.Kind() == reflect.Interface {
+			if coerced, ok := coerce(value, cv.goType); ok {
+				value = coerced
+			}
+		}
+		if err = assign(value, field); err != nil {
+			return errors.New(fmt.Sprintf("Field %s scan error: %v", f.Name, err))
+		}
+	}
+	return nil
+}
+
+// Scans all the result set records in the slice of structs, or of pointers to structs, pointed by dest. Columns
+// are mapped to the struct fields by the db tag name, the json tag name or the lowercase field name, ignoring
+// the case; records without columns metadata are read from the map documents.
+func ScanInto(rs ResultSet, dest interface{}) error {
+	d := reflect.ValueOf(dest)
+	if d.Kind() != reflect.Ptr || d.IsNil() || d.Elem().Kind() != reflect.Slice {
+		return errors.New(fmt.Sprintf("Scan destination must be a pointer to a slice: %T", dest))
+	}
+	slice := d.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	structType := elemType
+	if isPtr {
+		structType = elemType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return errors.New(fmt.Sprintf("Scan destination must be a slice of structs: %T", dest))
+	}
+	fields := structFields(structType, nil)
+	result := reflect.MakeSlice(slice.Type(), 0, len(rs.Records))
+	for i, record := range rs.Records {
+		item := reflect.New(structType)
+		if err := scanRecord(rs.MetaData, record, item.Elem(), fields); err != nil {
+			return errors.New(fmt.Sprintf("Record %v: %v", i, err))
+		}
+		if isPtr {
+			result = reflect.Append(result, item)
+		} else {
+			result = reflect.Append(result, item.Elem())
+		}
+	}
+	slice.Set(result)
+	return nil
+}
+
+// Scans the first result set record in the struct pointed by dest, mapping the columns as ScanInto does
+func ScanOne(rs ResultSet, dest interface{}) error {
+	d := reflect.ValueOf(dest)
+	if d.Kind() != reflect.Ptr || d.IsNil() || d.Elem().Kind() != reflect.Struct {
+		return errors.New(fmt.Sprintf("Scan destination must be a pointer to a struct: %T", dest))
+	}
+	if len(rs.Records) == 0 {
+		return errors.New(fmt.Sprint("No records available in the result set"))
+	}
+	return ScanResult(rs.MetaData, rs.Records[0], dest)
+}
+
+// Verifies the destination is a pointer to a struct, not implementing sql.Scanner nor being a time.Time
+func isStructDest(dest interface{}) bool {
+	if _, ok := dest.(sql.Scanner); ok {
+		return false
+	}
+	t := reflect.TypeOf(dest)
+	return t != nil && t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct && t.Elem() != timeType
+}
+
+// Scans a single record in the struct pointed by dest, mapping the columns as ScanInto does
+func ScanResult(metaData MetaData, record Result, dest interface{}) error {
+	d := reflect.ValueOf(dest)
+	if d.Kind() != reflect.Ptr || d.IsNil() || d.Elem().Kind() != reflect.Struct {
+		return errors.New(fmt.Sprintf("Scan destination must be a pointer to a struct: %T", dest))
+	}
+	return scanRecord(metaData, record, d.Elem(), structFields(d.Elem().Type(), nil))
+}
+
+// Builds the Insert fields and values from the struct exported fields, named as for ScanInto. Fields with the
+// omitempty tag option are skipped when holding the zero value and nil pointers give null values.
+func FieldValues(src interface{}) ([]Field, []Value, error) {
+	var fields = make([]Field, 0)
+	var values = make([]Value, 0)
+	s := reflect.ValueOf(src)
+	if s.Kind() == reflect.Ptr {
+		if s.IsNil() {
+			return fields, values, errors.New(fmt.Sprint("Source struct pointer is nil"))
+		}
+		s = s.Elem()
+	}
+	if s.Kind() != reflect.Struct {
+		return fields, values, errors.New(fmt.Sprintf("Source must be a struct or a pointer to a struct: %T", src))
+	}
+	for _, f := range structFields(s.Type(), nil) {
+		fv := s.FieldByIndex(f.Index)
+		if f.OmitEmpty && fv.IsZero() {
+			continue
+		}
+		var value interface{}
+		if fv.Kind() == reflect.Ptr {
+			if !fv.IsNil() {
+				value = fv.Elem().Interface()
+			}
+		} else {
+			value = fv.Interface()
+		}
+		fields = append(fields, Field{Name: f.Name})
+		values = append(values, Value{
+			Type:  DataType(fv.Type().String()),
+			Value: value,
+		})
+	}
+	return fields, values, nil
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type audit struct {
+	Created time.Time `db:"created_at"`
+}
+
+type person struct {
+	audit
+	ID      int64          `db:"id,omitempty"`
+	Name    string         `json:"name"`
+	Age     int            `json:"age,omitempty"`
+	Balance float64        `db:"balance"`
+	Active  bool           `db:"active"`
+	Role    *string        `db:"role"`
+	Email   sql.NullString `db:"email"`
+	Notes   string         `db:"-"`
+	secret  string
+}
+
+func TestScanInto(t *testing.T) {
+	stringType := reflect.TypeOf("")
+	rs := ResultSet{
+		MetaData: MetaData{
+			Columns: []Column{
+				{Name: "ID"}, {Name: "name"}, {Name: "age"}, {Name: "balance", GoType: reflect.TypeOf(float64(0))},
+				{Name: "active"}, {Name: "role"}, {Name: "email", GoType: stringType}, {Name: "created_at"}, {Name: "notes"},
+			},
+		},
+		Records: []Result{
+			{Values: []interface{}{int64(1), "Fabrizio", int64(45), "10.50", int64(1), "Architect", []byte("f@t.it"), "2020-05-01 10:30:00", "x"}},
+			{Values: []interface{}{int64(2), []byte("Mario"), nil, 3, false, nil, nil, time.Date(2020, 5, 2, 0, 0, 0, 0, time.UTC), nil}},
+		},
+		Lines: 2,
+	}
+	var people []person
+	if err := ScanInto(rs, &people); err != nil {
+		t.Fatalf("Scan error occured: %v", err)
+	}
+	if len(people) != 2 {
+		t.Fatalf("Wrong number of scanned records: %v", len(people))
+	}
+	p := people[0]
+	if p.ID != 1 || p.Name != "Fabrizio" || p.Age != 45 || p.Balance != 10.5 || !p.Active || p.Role == nil ||
+		*p.Role != "Architect" || !p.Email.Valid || p.Email.String != "f@t.it" || p.Notes != "" ||
+		!p.Created.Equal(time.Date(2020, 5, 1, 10, 30, 0, 0, time.UTC)) {
+		t.Fatalf("Wrong first scanned record: %+v", p)
+	}
+	p = people[1]
+	if p.Name != "Mario" || p.Age != 0 || p.Balance != 3 || p.Active || p.Role != nil || p.Email.Valid || p.Created.Day() != 2 {
+		t.Fatalf("Wrong second scanned record: %+v", p)
+	}
+	var pointers []*person
+	if err := ScanInto(rs, &pointers); err != nil || len(pointers) != 2 || pointers[1].ID != 2 {
+		t.Fatalf("Wrong scanned pointers: %v %v", pointers, err)
+	}
+	var one person
+	if err := ScanOne(ResultSet{Records: []Result{{Document: map[string]interface{}{"Name": "Luca", "age": 30}}}}, &one); err != nil {
+		t.Fatalf("Scan error occured: %v", err)
+	}
+	if one.Name != "Luca" || one.Age != 30 {
+		t.Fatalf("Wrong document scanned record: %+v", one)
+	}
+	if err := ScanOne(ResultSet{}, &one); err == nil {
+		t.Fatal("Scan of an empty result set should fail")
+	}
+	rs.Records[0].Values[2] = "old"
+	if err := ScanInto(rs, &people); err == nil {
+		t.Fatal("Scan of a not numeric text in a number field should fail")
+	}
+	if err := ScanInto(rs, people); err == nil {
+		t.Fatal("Scan in a not pointer destination should fail")
+	}
+}
+
+func TestScanIntoFieldType(t *testing.T) {
+	type measure struct {
+		Delta int         `db:"delta"`
+		Ratio float64     `db:"ratio"`
+		Raw   interface{} `db:"raw"`
+	}
+	rs := ResultSet{
+		MetaData: MetaData{
+			Columns: []Column{
+				{Name: "delta", GoType: reflect.TypeOf(byte(0))}, {Name: "ratio", GoType: reflect.TypeOf(uint64(0))},
+				{Name: "raw", GoType: reflect.TypeOf(int64(0))},
+			},
+		},
+		Records: []Result{{Values: []interface{}{int64(-5), float64(-2.75), []byte("12")}}},
+		Lines:   1,
+	}
+	var m measure
+	if err := ScanOne(rs, &m); err != nil {
+		t.Fatalf("Scan error occured: %v", err)
+	}
+	if m.Delta != -5 || m.Ratio != -2.75 || m.Raw != int64(12) {
+		t.Fatalf("Wrong scanned record: %+v", m)
+	}
+}
+
+func TestFieldValues(t *testing.T) {
+	role := "Architect"
+	fields, values, err := FieldValues(&person{Name: "Fabrizio", Balance: 1.5, Role: &role})
+	if err != nil {
+		t.Fatalf("Field values error occured: %v", err)
+	}
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.Name)
+	}
+	expected := []string{"created_at", "name", "balance", "active", "role", "email"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Wrong field names, expected: %v but was: %v", expected, names)
+	}
+	if values[1].Value != "Fabrizio" || values[2].Value != 1.5 || values[4].Value != "Architect" || values[1].Type != "string" {
+		t.Fatalf("Wrong field values: %v", values)
+	}
+	if _, _, err = FieldValues(1); err == nil {
+		t.Fatal("Field values of a not struct should fail")
+	}
+}
@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+)
+
+func (c *memoryConnection) InsertBatch(dbRef database.DataRef, fields []database.Field, rows [][]database.Value, options database.BatchOptions) ([]database.BatchResult, error) {
+	return c.InsertBatchContext(context.Background(), dbRef, fields, rows, options)
+}
+
+// Inserts the rows as records, replacing on upsert the fields of the first record having the same key fields
+func (c *memoryConnection) InsertBatchContext(ctx context.Context, dbRef database.DataRef, fields []database.Field, rows [][]database.Value, options database.BatchOptions) (_ []database.BatchResult, err error) {
+	defer opError("InsertBatch", dbRef, &err)
+	var results = make([]database.BatchResult, 0)
+	if err := c.check(ctx); err != nil {
+		return results, err
+	}
+	if options.Upsert && len(options.KeyFields) == 0 {
+		return results, errors.New(fmt.Sprint("Upsert needs the list of key fields"))
+	}
+	batches, err := database.Batches(fields, rows, options)
+	if err != nil {
+		return results, err
+	}
+	c.store.lockWrite()
+	defer c.store.Unlock()
+	e, _ := c.store.entity(dbRef, true)
+	for _, batch := range batches {
+		var result database.BatchResult
+		for _, row := range batch {
+			var index = -1
+			if options.Upsert {
+				key := options.KeyFilter(fields, row)
+				for i, r := range e.records {
+					if matchesFilter(r, key) {
+						index = i
+						break
+					}
+				}
+			}
+			var record = make(map[string]interface{})
+			if index >= 0 {
+				record = copyRecord(e.records[index])
+			}
+			for i, f := range fields {
+				record[f.Name] = row[i].Value
+			}
+			if err := e.checkKeys(record, index); err != nil {
+				return results, err
+			}
+			if index < 0 {
+				e.add(record)
+				result.Inserted++
+				continue
+			}
+			e.records[index] = record
+			for _, f := range fields {
+				e.addColumn(f.Name)
+			}
+			result.Updated++
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,471 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+	"reflect"
+)
+
+type memoryConnection struct {
+	Configuration database.DbConfig
+	Valid         bool
+	store         *store
+	// Store the transaction snapshot is committed to, available for transaction connections
+	origin *store
+	err    error
+}
+
+func (c *memoryConnection) check(ctx context.Context) error {
+	if c.origin != nil && !c.Valid {
+		return errors.New(fmt.Sprint("Transaction has already been committed or rolled back"))
+	}
+	if !c.Valid || c.store == nil {
+		return database.ErrClosed
+	}
+	if ctx != nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Builds the result set metadata, using the requested fields or all known entity columns
+func (c *memoryConnection) metaData(dbRef database.DataRef, e *entity, fields []string, records []map[string]interface{}) database.MetaData {
+	var names = fields
+	if len(names) == 0 {
+		names = e.columns
+	}
+	var columns = make([]database.Column, 0)
+	for _, name := range names {
+		col := database.Column{
+			Name:   name,
+			GoType: reflect.TypeOf(new(interface{})).Elem(),
+		}
+		for _, record := range records {
+			if v, ok := record[name]; ok && v != nil {
+				col.GoType = reflect.TypeOf(v)
+				break
+			}
+		}
+		col.Type = database.DataType(col.GoType.String())
+		if f, ok := e.fields[name]; ok {
+			if f.Type != "" {
+				col.Type = database.DataType(f.Type)
+			}
+			col.Length = f.Size
+			col.Precision = int64(f.Precision)
+		}
+		columns = append(columns, col)
+	}
+	return database.MetaData{
+		EntityRef: dbRef,
+		Columns:   columns,
+	}
+}
+
+func (c *memoryConnection) Query(dbRef database.DataRef, fields []string, conditions []database.Condition, withAnd bool) (database.ResultSet, error) {
+	return c.QueryContext(context.Background(), dbRef, fields, conditions, withAnd)
+}
+
+func (c *memoryConnection) QueryContext(ctx context.Context, dbRef database.DataRef, fields []string, conditions []database.Condition, withAnd bool) (database.ResultSet, error) {
+	return c.QueryFilter(ctx, dbRef, fields, database.FromConditions(conditions, withAnd))
+}
+
+func (c *memoryConnection) QueryFilter(ctx context.Context, dbRef database.DataRef, fields []string, filter database.Filter) (database.ResultSet, error) {
+	return c.QueryPage(ctx, dbRef, fields, filter, database.QueryOptions{})
+}
+
+func (c *memoryConnection) QueryPage(ctx context.Context, dbRef database.DataRef, fields []string, filter database.Filter, options database.QueryOptions) (_ database.ResultSet, err error) {
+	defer opError("Query", dbRef, &err)
+	resultSet := database.ResultSet{
+		Records: make([]database.Result, 0),
+		Lines:   0,
+		MetaData: database.MetaData{
+			EntityRef: dbRef,
+			Columns:   make([]database.Column, 0),
+		},
+	}
+	if err := c.check(ctx); err != nil {
+		return resultSet, err
+	}
+	if dbRef.SQL != "" {
+		return resultSet, database.WrapError(database.ErrUnsupported, errors.New(fmt.Sprint("SQL queries are not supported by the in memory driver")))
+	}
+	if err := options.Validate(); err != nil {
+		return resultSet, err
+	}
+	after, err := options.AfterFilter()
+	if err != nil {
+		return resultSet, err
+	}
+	filter = database.And(filter, after)
+	c.store.RLock()
+	defer c.store.RUnlock()
+	e, err := c.store.entity(dbRef, false)
+	if err != nil {
+		return resultSet, err
+	}
+	var selected = make([]map[string]interface{}, 0)
+	for _, record := range e.records {
+		if matchesFilter(record, filter) {
+			selected = append(selected, copyRecord(record))
+		}
+	}
+	selected = page(selected, options)
+	resultSet.MetaData = c.metaData(dbRef, e, fields, selected)
+	for _, record := range selected {
+		var values = make([]interface{}, 0)
+		for _, col := range resultSet.MetaData.Columns {
+			values = append(values, record[col.Name])
+		}
+		resultSet.Lines++
+		resultSet.Records = append(resultSet.Records, database.Result{
+			Columns:  int64(len(values)),
+			Values:   values,
+			Document: record,
+		})
+	}
+	return resultSet, nil
+}
+
+// Returns an iterator over a snapshot of the matching records
+func (c *memoryConnection) Stream(ctx context.Context, dbRef database.DataRef, fields []string, filter database.Filter, options database.QueryOptions) (_ database.Rows, err error) {
+	defer opError("Stream", dbRef, &err)
+	resultSet, err := c.QueryPage(ctx, dbRef, fields, filter, options)
+	if err != nil {
+		return nil, err
+	}
+	return database.NewResultRows(resultSet), nil
+}
+
+func (c *memoryConnection) Insert(dbRef database.DataRef, fields []database.Field, values []database.Value) error {
+	return c.InsertContext(context.Background(), dbRef, fields, values)
+}
+
+// Inserts a record made of the given fields and values or, when no field is provided, a document for any given value
+func (c *memoryConnection) InsertContext(ctx context.Context, dbRef database.DataRef, fields []database.Field, values []database.Value) (err error) {
+	defer opError("Insert", dbRef, &err)
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return errors.New(fmt.Sprint("Insert statement needs list of Values"))
+	}
+	var records = make([]map[string]interface{}, 0)
+	if len(fields) > 0 {
+		if len(fields) != len(values) {
+			return errors.New(fmt.Sprintf("Columns and values must have same length: %v <> %v", len(fields), len(values)))
+		}
+		var record = make(map[string]interface{})
+		for i, f := range fields {
+			record[f.Name] = values[i].Value
+		}
+		records = append(records, record)
+	} else {
+		for _, v := range values {
+			doc, err := toDocument(v.Value)
+			if err != nil {
+				return err
+			}
+			records = append(records, doc)
+		}
+	}
+	c.store.lockWrite()
+	defer c.store.Unlock()
+	e, _ := c.store.entity(dbRef, true)
+	for _, record := range records {
+		if err := e.checkKeys(record, -1); err != nil {
+			return err
+		}
+		e.add(record)
+	}
+	return nil
+}
+
+func (c *memoryConnection) Update(dbRef database.DataRef, conditions []database.Condition, fields []database.Field, values []database.Value, withAnd bool) (int64, error) {
+	return c.UpdateContext(context.Background(), dbRef, conditions, fields, values, withAnd)
+}
+
+func (c *memoryConnection) UpdateContext(ctx context.Context, dbRef database.DataRef, conditions []database.Condition, fields []database.Field, values []database.Value, withAnd bool) (int64, error) {
+	return c.UpdateFilter(ctx, dbRef, database.FromConditions(conditions, withAnd), fields, values)
+}
+
+// Updates records matching the filter with the given fields and values or, when no field is provided, merging any
+// given document value (or its $set element) in the records
+func (c *memoryConnection) UpdateFilter(ctx context.Context, dbRef database.DataRef, filter database.Filter, fields []database.Field, values []database.Value) (_ int64, err error) {
+	defer opError("Update", dbRef, &err)
+	var records int64
+	if err := c.check(ctx); err != nil {
+		return records, err
+	}
+	if len(values) == 0 {
+		return records, errors.New(fmt.Sprint("Update statement needs list of Values"))
+	}
+	var changes = make(map[string]interface{})
+	if len(fields) > 0 {
+		if len(fields) != len(values) {
+			return records, errors.New(fmt.Sprintf("Columns and values must have same length: %v <> %v", len(fields), len(values)))
+		}
+		for i, f := range fields {
+			changes[f.Name] = values[i].Value
+		}
+	} else {
+		for _, v := range values {
+			doc, err := toDocument(v.Value)
+			if err != nil {
+				return records, err
+			}
+			if set, ok := doc["$set"].(map[string]interface{}); ok {
+				doc = set
+			}
+			for k, val := range doc {
+				changes[k] = val
+			}
+		}
+	}
+	c.store.lockWrite()
+	defer c.store.Unlock()
+	e, err := c.store.entity(dbRef, false)
+	if err != nil {
+		return records, err
+	}
+	for _, record := range e.records {
+		if matchesFilter(record, filter) {
+			for k, v := range changes {
+				record[k] = v
+				e.addColumn(k)
+			}
+			records++
+		}
+	}
+	return records, nil
+}
+
+func (c *memoryConnection) Delete(dbRef database.DataRef, conditions []database.Condition, withAnd bool) (int64, error) {
+	return c.DeleteContext(context.Background(), dbRef, conditions, withAnd)
+}
+
+func (c *memoryConnection) DeleteContext(ctx context.Context, dbRef database.DataRef, conditions []database.Condition, withAnd bool) (int64, error) {
+	return c.DeleteFilter(ctx, dbRef, database.FromConditions(conditions, withAnd))
+}
+
+func (c *memoryConnection) DeleteFilter(ctx context.Context, dbRef database.DataRef, filter database.Filter) (_ int64, err error) {
+	defer opError("Delete", dbRef, &err)
+	var records int64
+	if err := c.check(ctx); err != nil {
+		return records, err
+	}
+	c.store.lockWrite()
+	defer c.store.Unlock()
+	e, err := c.store.entity(dbRef, false)
+	if err != nil {
+		return records, err
+	}
+	var kept = make([]map[string]interface{}, 0)
+	for _, record := range e.records {
+		if matchesFilter(record, filter) {
+			records++
+		} else {
+			kept = append(kept, record)
+		}
+	}
+	e.records = kept
+	return records, nil
+}
+
+// Counts the matching records
+func (c *memoryConnection) Count(ctx context.Context, dbRef database.DataRef, filter database.Filter) (_ int64, err error) {
+	defer opError("Count", dbRef, &err)
+	var records int64
+	if err := c.check(ctx); err != nil {
+		return records, err
+	}
+	if dbRef.SQL != "" {
+		return records, database.WrapError(database.ErrUnsupported, errors.New(fmt.Sprint("SQL queries are not supported by the in memory driver")))
+	}
+	c.store.RLock()
+	defer c.store.RUnlock()
+	e, err := c.store.entity(dbRef, false)
+	if err != nil {
+		return records, err
+	}
+	for _, record := range e.records {
+		if matchesFilter(record, filter) {
+			records++
+		}
+	}
+	return records, nil
+}
+
+func (c *memoryConnection) Purge(dbRef database.DataRef) (int64, error) {
+	return c.PurgeContext(context.Background(), dbRef)
+}
+
+// Removes all records of the Namespace entity or, when no Namespace is provided, of all the Database entities
+func (c *memoryConnection) PurgeContext(ctx context.Context, dbRef database.DataRef) (_ int64, err error) {
+	defer opError("Purge", dbRef, &err)
+	var records int64
+	if err := c.check(ctx); err != nil {
+		return records, err
+	}
+	c.store.lockWrite()
+	defer c.store.Unlock()
+	if dbRef.Namespace != "" {
+		e, err := c.store.entity(dbRef, false)
+		if err != nil {
+			return records, err
+		}
+		records = int64(len(e.records))
+		e.records = make([]map[string]interface{}, 0)
+		return records, nil
+	}
+	db, ok := c.store.databases[dbRef.Database]
+	if !ok {
+		return records, database.WrapError(database.ErrNotFound, errors.New(fmt.Sprintf("Unknown database: %s", dbRef.Database)))
+	}
+	for _, e := range db {
+		records += int64(len(e.records))
+		e.records = make([]map[string]interface{}, 0)
+	}
+	return records, nil
+}
+
+func (c *memoryConnection) Create(dbRef database.DataRef, fields []database.Field) error {
+	return c.CreateContext(context.Background(), dbRef, fields)
+}
+
+func (c *memoryConnection) CreateContext(ctx context.Context, dbRef database.DataRef, fields []database.Field) (err error) {
+	defer opError("Create", dbRef, &err)
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	if dbRef.Namespace == "" {
+		return c.CreateDbContext(ctx, dbRef)
+	}
+	c.store.lockWrite()
+	defer c.store.Unlock()
+	db, ok := c.store.databases[dbRef.Database]
+	if !ok {
+		db = make(map[string]*entity)
+		c.store.databases[dbRef.Database] = db
+	}
+	if _, ok := db[dbRef.Namespace]; ok {
+		if dbRef.IfNotExists {
+			return nil
+		}
+		return errors.New(fmt.Sprintf("Namespace %s already exists in database: %s", dbRef.Namespace, dbRef.Database))
+	}
+	db[dbRef.Namespace] = newEntity(fields)
+	return nil
+}
+
+func (c *memoryConnection) CreateDb(dbRef database.DataRef) error {
+	return c.CreateDbContext(context.Background(), dbRef)
+}
+
+func (c *memoryConnection) CreateDbContext(ctx context.Context, dbRef database.DataRef) (err error) {
+	defer opError("CreateDb", dbRef, &err)
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	c.store.lockWrite()
+	defer c.store.Unlock()
+	if _, ok := c.store.databases[dbRef.Database]; !ok {
+		c.store.databases[dbRef.Database] = make(map[string]*entity)
+	}
+	return nil
+}
+
+func (c *memoryConnection) Drop(dbRef database.DataRef) error {
+	return c.DropContext(context.Background(), dbRef)
+}
+
+func (c *memoryConnection) DropContext(ctx context.Context, dbRef database.DataRef) (err error) {
+	defer opError("Drop", dbRef, &err)
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	if dbRef.Namespace == "" {
+		return c.DropDbContext(ctx, dbRef)
+	}
+	c.store.lockWrite()
+	defer c.store.Unlock()
+	if _, err := c.store.entity(dbRef, false); err != nil {
+		return err
+	}
+	delete(c.store.databases[dbRef.Database], dbRef.Namespace)
+	return nil
+}
+
+func (c *memoryConnection) DropDb(dbRef database.DataRef) error {
+	return c.DropDbContext(context.Background(), dbRef)
+}
+
+func (c *memoryConnection) DropDbContext(ctx context.Context, dbRef database.DataRef) (err error) {
+	defer opError("DropDb", dbRef, &err)
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	c.store.lockWrite()
+	defer c.store.Unlock()
+	if _, ok := c.store.databases[dbRef.Database]; !ok {
+		return database.WrapError(database.ErrNotFound, errors.New(fmt.Sprintf("Unknown database: %s", dbRef.Database)))
+	}
+	delete(c.store.databases, dbRef.Database)
+	return nil
+}
+
+func (c *memoryConnection) Close() (err error) {
+	defer opError("Close", database.DataRef{}, &err)
+	if !c.Valid {
+		return database.ErrClosed
+	}
+	c.Valid = false
+	c.store = nil
+	return nil
+}
+
+// Scripts are not supported by the in memory store
+func (c *memoryConnection) ExecScript(ctx context.Context, dbRef database.DataRef, script string) (err error) {
+	defer opError("ExecScript", dbRef, &err)
+	return database.WrapError(database.ErrUnsupported, errors.New(fmt.Sprint("Scripts are not supported by the in memory driver")))
+}
+
+func (c *memoryConnection) Ping(ctx context.Context) (err error) {
+	defer opError("Ping", database.DataRef{}, &err)
+	return c.check(ctx)
+}
+
+// Returns the memory store name, the in memory store has no version
+func (c *memoryConnection) ServerVersion(ctx context.Context) (_ string, err error) {
+	defer opError("ServerVersion", database.DataRef{}, &err)
+	if err := c.check(ctx); err != nil {
+		return "", err
+	}
+	return "memory", nil
+}
+
+// Returns the connection configuration, with the Driver name
+func (c *memoryConnection) Config() database.DbConfig {
+	config := c.Configuration
+	if config.Driver == "" {
+		config.Driver = "memory"
+	}
+	return config
+}
+
+// The in memory store has no connections pool, the statistics are always empty
+func (c *memoryConnection) Stats() database.PoolStats {
+	return database.PoolStats{}
+}
+
+func (c *memoryConnection) IsConnected() bool {
+	return c.Valid
+}
+
+func (c *memoryConnection) GetLastError() error {
+	return c.err
+}
@@ -0,0 +1,352 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"github.com/hellgate75/go-services/database"
+	"strings"
+	"testing"
+)
+
+type Data struct {
+	Code    string `json:"code,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Surname string `json:"surname,omitempty"`
+	Age     int    `json:"age,omitempty"`
+	Role    string `json:"role,omitempty"`
+}
+
+func connect(t *testing.T) database.Connection {
+	conn, err := GetMemoryDriver().Connect(database.DbConfig{})
+	if err != nil {
+		t.Fatalf("Connection error occured: %v", err)
+	}
+	return conn
+}
+
+func TestMemoryConnection(t *testing.T) {
+	conn := connect(t)
+	config := database.DataRef{
+		Database:  "test",
+		Namespace: "sample",
+	}
+	err := conn.Insert(config, []database.Field{}, []database.Value{
+		{Type: "struct", Value: Data{Code: "1", Name: "Fabrizio", Surname: "Torelli", Age: 45, Role: "System Architect"}},
+		{Type: "struct", Value: Data{Code: "2", Name: "Francesco", Surname: "Torelli", Age: 42, Role: "Software Developer"}},
+	})
+	if err != nil {
+		t.Fatalf("Database collection insert error occured: %v", err)
+	}
+	err = conn.Insert(config, []database.Field{{Name: "code"}, {Name: "name"}, {Name: "age"}},
+		[]database.Value{{Value: "3"}, {Value: "Mario"}, {Value: 30}})
+	if err != nil {
+		t.Fatalf("Database collection insert error occured: %v", err)
+	}
+	conditions := []database.Condition{
+		{Field: "name", Value: database.Value{Type: "string", Value: "Francesco"}},
+		{Field: "surname", Value: database.Value{Type: "string", Value: "Torelli"}},
+	}
+	rs, err := conn.Query(config, []string{}, conditions, true)
+	if err != nil {
+		t.Fatalf("Database collection querying error occured: %v", err)
+	}
+	if rs.Lines != 1 || len(rs.Records) != 1 {
+		t.Fatalf("Wrong number of results: %v", rs.Lines)
+	}
+	if len(rs.MetaData.Columns) != 5 || rs.MetaData.Columns[0].Name != "age" {
+		t.Fatalf("Wrong result set metadata: %v", rs.MetaData.Columns)
+	}
+	rs, err = conn.Query(config, []string{"code"}, conditions, false)
+	if err != nil {
+		t.Fatalf("Database collection querying error occured: %v", err)
+	}
+	if rs.Lines != 2 || len(rs.Records[0].Values) != 1 {
+		t.Fatalf("Wrong results for or conditions: %v", rs.Records)
+	}
+	count, err := conn.Update(config, conditions, []database.Field{}, []database.Value{
+		{Type: "map", Value: map[string]interface{}{"$set": map[string]interface{}{"role": "Hardware Specialist"}}},
+	}, true)
+	if err != nil {
+		t.Fatalf("Database collection update error occured: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Wrong number of updated records %v", count)
+	}
+	rs, _ = conn.Query(config, []string{"role"}, conditions, true)
+	if rs.Records[0].Values[0] != "Hardware Specialist" {
+		t.Fatalf("Wrong updated value: %v", rs.Records[0].Values)
+	}
+	count, err = conn.Delete(config, []database.Condition{
+		{Field: "role", Operation: database.Null},
+	}, true)
+	if err != nil {
+		t.Fatalf("Database collection delete error occured: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Wrong number of deleted records %v", count)
+	}
+	count, err = conn.Purge(config)
+	if err != nil {
+		t.Fatalf("Database collection purge error occured: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Wrong number of purged records %v", count)
+	}
+	err = conn.Drop(config)
+	if err != nil {
+		t.Fatalf("Database collection drop error occured: %v", err)
+	}
+	if _, err = conn.Query(config, []string{}, conditions, true); err == nil {
+		t.Fatal("Query on dropped collection should fail")
+	}
+	_ = conn.Close()
+	if _, err = conn.Query(config, []string{}, conditions, true); err == nil {
+		t.Fatal("Query on closed connection should fail")
+	}
+}
+
+func TestMatches(t *testing.T) {
+	record := map[string]interface{}{
+		"name": "Fabrizio",
+		"age":  45,
+		"role": nil,
+	}
+	var tests = []struct {
+		cond     database.Condition
+		expected bool
+	}{
+		{database.Condition{Field: "name", Operation: database.Equals, Value: database.Value{Value: "Fabrizio"}}, true},
+		{database.Condition{Field: "name", Operation: database.Not + database.Equals, Value: database.Value{Value: "Fabrizio"}}, false},
+		{database.Condition{Field: "age", Operation: database.LessThan, Value: database.Value{Value: int64(50)}}, true},
+		{database.Condition{Field: "age", Operation: database.Not + database.LessThan, Value: database.Value{Value: 45.0}}, false},
+		{database.Condition{Field: "age", Operation: database.LessThanEquals, Value: database.Value{Value: 45}}, true},
+		{database.Condition{Field: "age", Operation: database.GraterThan, Value: database.Value{Value: 45}}, false},
+		{database.Condition{Field: "age", Operation: database.GraterThanEquals, Value: database.Value{Value: 45}}, true},
+		{database.Condition{Field: "age", Operation: database.Not + database.GraterThanEquals, Value: database.Value{Value: 46}}, true},
+		{database.Condition{Field: "name", Operation: database.Like, Value: database.Value{Value: "fab%"}}, true},
+		{database.Condition{Field: "name", Operation: database.Like, Value: database.Value{Value: "F_b"}}, false},
+		{database.Condition{Field: "name", Operation: database.Not + database.Like, Value: database.Value{Value: "%zio"}}, false},
+		{database.Condition{Field: "age", Operation: database.In, Value: database.Value{Value: []int{30, 45}}}, true},
+		{database.Condition{Field: "age", Operation: database.Not + database.In, Value: database.Value{Value: []int{30, 45}}}, false},
+		{database.Condition{Field: "role", Operation: database.Null}, true},
+		{database.Condition{Field: "missing", Operation: database.Null}, true},
+		{database.Condition{Field: "name", Operation: database.Not + database.Null}, true},
+		{database.Condition{Field: "role", Operation: database.Not + database.Equals, Value: database.Value{Value: "x"}}, false},
+	}
+	for i, test := range tests {
+		if matches(record, test.cond) != test.expected {
+			t.Fatalf("Test %v: wrong match for condition %v, expected: %v", i, test.cond, test.expected)
+		}
+	}
+	conditions := []database.Condition{tests[0].cond, tests[1].cond}
+	if matchesFilter(record, database.FromConditions(conditions, true)) {
+		t.Fatal("All conditions should not match with AND")
+	}
+	if !matchesFilter(record, database.FromConditions(conditions, false)) {
+		t.Fatal("Any condition should match with OR")
+	}
+	nested := database.And(
+		database.Or(database.Leaf(tests[1].cond), database.Leaf(tests[2].cond)),
+		database.Negate(database.Leaf(tests[5].cond)),
+		database.Or(),
+	)
+	if !matchesFilter(record, nested) {
+		t.Fatal("Nested filter groups should match")
+	}
+	if matchesFilter(record, database.Negate(nested)) {
+		t.Fatal("Negated nested filter groups should not match")
+	}
+	if !matchesFilter(record, database.Or()) {
+		t.Fatal("Empty filter should match any record")
+	}
+}
+
+func TestMemoryQueryPage(t *testing.T) {
+	conn := connect(t)
+	config := database.DataRef{
+		Namespace: "sample",
+	}
+	for i, name := range []string{"e", "b", "d", "a", "c"} {
+		err := conn.Insert(config, []database.Field{{Name: "code"}, {Name: "name"}, {Name: "group"}},
+			[]database.Value{{Value: i + 1}, {Value: name}, {Value: i % 2}})
+		if err != nil {
+			t.Fatalf("Database collection insert error occured: %v", err)
+		}
+	}
+	options := database.QueryOptions{
+		OrderBy: []database.OrderBy{{Field: "group"}, {Field: "name", Direction: database.Descending}},
+		Limit:   2,
+		Offset:  1,
+	}
+	rs, err := conn.QueryPage(context.Background(), config, []string{"name"}, database.And(), options)
+	if err != nil {
+		t.Fatalf("Database collection querying error occured: %v", err)
+	}
+	if rs.Lines != 2 || rs.Records[0].Values[0] != "d" || rs.Records[1].Values[0] != "c" {
+		t.Fatalf("Wrong page records: %v", rs.Records)
+	}
+	options.Offset = 0
+	options.After, err = database.NextCursor(rs, options)
+	if err != nil {
+		t.Fatalf("Next cursor error occured: %v", err)
+	}
+	rs, err = conn.QueryPage(context.Background(), config, []string{"name"}, database.And(), options)
+	if err != nil {
+		t.Fatalf("Database collection querying error occured: %v", err)
+	}
+	if rs.Lines != 2 || rs.Records[0].Values[0] != "b" || rs.Records[1].Values[0] != "a" {
+		t.Fatalf("Wrong keyset page records: %v", rs.Records)
+	}
+	options.Offset = 10
+	rs, _ = conn.QueryPage(context.Background(), config, []string{}, database.And(), options)
+	if rs.Lines != 0 {
+		t.Fatalf("Wrong number of results after the last page: %v", rs.Lines)
+	}
+	group := database.Leaf(database.Condition{Field: "group", Operation: database.Equals, Value: database.Value{Value: 0}})
+	if count, err := conn.Count(context.Background(), config, group); err != nil || count != 3 {
+		t.Fatalf("Wrong number of counted records: %v %v", count, err)
+	}
+}
+
+func TestMemoryTransaction(t *testing.T) {
+	conn := connect(t)
+	config := database.DataRef{
+		Namespace: "sample",
+	}
+	insert := func(tx database.Tx, code int) error {
+		return tx.InsertContext(context.Background(), config, []database.Field{{Name: "code"}}, []database.Value{{Value: code}})
+	}
+	count := func() int64 {
+		rs, _ := conn.Query(config, []string{}, []database.Condition{}, true)
+		return rs.Lines
+	}
+	err := database.RunInTx(conn, func(tx database.Tx) error {
+		if err := insert(tx, 1); err != nil {
+			return err
+		}
+		if count() != 0 {
+			t.Fatal("Uncommitted records should not be visible outside the transaction")
+		}
+		return insert(tx, 2)
+	})
+	if err != nil {
+		t.Fatalf("Transaction error occured: %v", err)
+	}
+	if count() != 2 {
+		t.Fatalf("Wrong number of committed records: %v", count())
+	}
+	err = database.RunInTxContext(context.Background(), conn, database.TxOptions{}, func(tx database.Tx) error {
+		if _, err := tx.DeleteFilter(context.Background(), config, database.And()); err != nil {
+			return err
+		}
+		return errors.New("failure")
+	})
+	if err == nil || count() != 2 {
+		t.Fatalf("Transaction should be rolled back on error: %v %v", err, count())
+	}
+	err = database.RunInTx(conn, func(tx database.Tx) error {
+		// The rollback of the committed transaction fails
+		_ = tx.Rollback()
+		return database.ErrDuplicateKey
+	})
+	if !errors.Is(err, database.ErrDuplicateKey) || !strings.Contains(err.Error(), "rollback error") {
+		t.Fatalf("Expected the function error wrapped with the rollback error: %v", err)
+	}
+	tx, err := conn.Begin(context.Background(), database.TxOptions{})
+	if err != nil {
+		t.Fatalf("Transaction begin error occured: %v", err)
+	}
+	if _, ok := tx.(database.Connection); ok {
+		t.Fatal("Transaction should not expose the connection methods")
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("Transaction commit error occured: %v", err)
+	}
+	if err = insert(tx, 3); err == nil {
+		t.Fatal("Operations after commit should fail")
+	}
+	if tx, err = conn.Begin(context.Background(), database.TxOptions{}); err != nil {
+		t.Fatalf("Transaction begin error occured: %v", err)
+	}
+	if err = insert(tx, 4); err != nil {
+		t.Fatalf("Transaction insert error occured: %v", err)
+	}
+	if err = conn.Insert(config, []database.Field{{Name: "code"}}, []database.Value{{Value: 5}}); err != nil {
+		t.Fatalf("Database insert error occured: %v", err)
+	}
+	if err = tx.Commit(); !errors.Is(err, ErrConflict) || count() != 3 {
+		t.Fatalf("Expected conflict error keeping the concurrent write: %v %v", err, count())
+	}
+}
+
+func TestMemoryDuplicateKey(t *testing.T) {
+	conn := connect(t)
+	ref := database.DataRef{Namespace: "accounts"}
+	err := conn.Create(ref, []database.Field{
+		{Name: "id", Type: "integer", PrimaryKey: true},
+		{Name: "email", Type: "varchar", Unique: true},
+	})
+	if err != nil {
+		t.Fatalf("Table creation error occured: %v", err)
+	}
+	fields := []database.Field{{Name: "id"}, {Name: "email"}}
+	if err = conn.Insert(ref, fields, []database.Value{{Value: 1}, {Value: "a@b.c"}}); err != nil {
+		t.Fatalf("Database insert error occured: %v", err)
+	}
+	if err = conn.Insert(ref, fields, []database.Value{{Value: 1}, {Value: "d@e.f"}}); !errors.Is(err, database.ErrDuplicateKey) {
+		t.Fatalf("Expected duplicate primary key error: %v", err)
+	}
+	if err = conn.Insert(ref, fields, []database.Value{{Value: 2}, {Value: "a@b.c"}}); !errors.Is(err, database.ErrDuplicateKey) {
+		t.Fatalf("Expected duplicate unique field error: %v", err)
+	}
+	rows := [][]database.Value{{{Value: 2}, {Value: "g@h.i"}}, {{Value: 3}, {Value: "g@h.i"}}}
+	if _, err = conn.InsertBatch(ref, fields, rows, database.BatchOptions{}); !errors.Is(err, database.ErrDuplicateKey) {
+		t.Fatalf("Expected duplicate batch error: %v", err)
+	}
+	rows = [][]database.Value{{{Value: 1}, {Value: "g@h.i"}}}
+	if _, err = conn.InsertBatch(ref, fields, rows, database.BatchOptions{Upsert: true, KeyFields: []string{"id"}}); !errors.Is(err, database.ErrDuplicateKey) {
+		t.Fatalf("Expected duplicate upsert error: %v", err)
+	}
+	rows = [][]database.Value{{{Value: 1}, {Value: "j@k.l"}}}
+	if _, err = conn.InsertBatch(ref, fields, rows, database.BatchOptions{Upsert: true, KeyFields: []string{"id"}}); err != nil {
+		t.Fatalf("Database batch upsert error occured: %v", err)
+	}
+}
+
+func TestMemoryInsertBatch(t *testing.T) {
+	conn := connect(t)
+	config := database.DataRef{
+		Namespace: "sample",
+	}
+	fields := []database.Field{{Name: "code"}, {Name: "name"}}
+	rows := [][]database.Value{
+		{{Value: "1"}, {Value: "Mario"}},
+		{{Value: "2"}, {Value: "Luigi"}},
+		{{Value: "3"}, {Value: "Peach"}},
+	}
+	results, err := conn.InsertBatch(config, fields, rows, database.BatchOptions{BatchSize: 2})
+	if err != nil {
+		t.Fatalf("Database batch insert error occured: %v", err)
+	}
+	if len(results) != 2 || results[0].Inserted != 2 || results[1].Inserted != 1 {
+		t.Fatalf("Wrong batch results: %v", results)
+	}
+	rows = [][]database.Value{
+		{{Value: "2"}, {Value: "Luigi Bros"}},
+		{{Value: "4"}, {Value: "Toad"}},
+	}
+	results, err = conn.InsertBatch(config, fields, rows, database.BatchOptions{Upsert: true, KeyFields: []string{"code"}})
+	if err != nil {
+		t.Fatalf("Database batch upsert error occured: %v", err)
+	}
+	if len(results) != 1 || results[0].Inserted != 1 || results[0].Updated != 1 {
+		t.Fatalf("Wrong batch results: %v", results)
+	}
+	rs, _ := conn.Query(config, []string{"name"}, []database.Condition{{Field: "code", Value: database.Value{Value: "2"}}}, true)
+	if rs.Lines != 1 || rs.Records[0].Values[0] != "Luigi Bros" {
+		t.Fatalf("Record should be updated: %v", rs.Records)
+	}
+	if _, err = conn.InsertBatch(config, fields, rows, database.BatchOptions{Upsert: true}); err == nil {
+		t.Fatal("Expected missing key fields error")
+	}
+}
@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"github.com/hellgate75/go-services/database"
+	"sync"
+)
+
+func init() {
+	if err := database.RegisterType("memory", database.MemoryDriver, []string{"mem", "in-memory"}, GetMemoryDriver); err != nil {
+		panic(err)
+	}
+}
+
+var (
+	storesMutex sync.Mutex
+	// Named stores shared by all connections with the same configuration Url
+	stores = make(map[string]*store)
+)
+
+type memoryDriver struct {
+}
+
+// Connects to an in memory store, connections with the same not empty configuration Url share the same data,
+// while an empty Url always gives a new private store
+func (d *memoryDriver) Connect(config database.DbConfig) (database.Connection, error) {
+	var s *store
+	if config.Url == "" {
+		s = newStore()
+	} else {
+		storesMutex.Lock()
+		var ok bool
+		if s, ok = stores[config.Url]; !ok {
+			s = newStore()
+			stores[config.Url] = s
+		}
+		storesMutex.Unlock()
+	}
+	return &memoryConnection{
+		Configuration: config,
+		store:         s,
+		Valid:         true,
+	}, nil
+}
+
+// Retrieves the in memory database driver
+func GetMemoryDriver() database.Driver {
+	return &memoryDriver{}
+}
+
+// Removes all data from the named store shared by connections with the given configuration Url
+func Reset(url string) {
+	storesMutex.Lock()
+	defer storesMutex.Unlock()
+	delete(stores, url)
+}
@@ -0,0 +1,10 @@
+package memory
+
+import (
+	"github.com/hellgate75/go-services/database"
+)
+
+// Wraps the operation error in a database.OpError
+func opError(op string, dbRef database.DataRef, err *error) {
+	*err = database.NewOpError(op, "memory", dbRef, *err)
+}
@@ -0,0 +1,504 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Data entity (table or collection) descriptor
+type entity struct {
+	// Known column names in definition or discovery order
+	columns []string
+	// Column definitions by name, available for created entities
+	fields map[string]database.Field
+	// Entity records
+	records []map[string]interface{}
+}
+
+func newEntity(fields []database.Field) *entity {
+	e := &entity{
+		columns: make([]string, 0),
+		fields:  make(map[string]database.Field),
+		records: make([]map[string]interface{}, 0),
+	}
+	for _, f := range fields {
+		e.addColumn(f.Name)
+		e.fields[f.Name] = f
+	}
+	return e
+}
+
+func (e *entity) addColumn(name string) {
+	for _, col := range e.columns {
+		if col == name {
+			return
+		}
+	}
+	e.columns = append(e.columns, name)
+}
+
+// Returns an ErrDuplicateKey error when the record has the primary key, or a not null unique field value, of another
+// entity record than the one at the skipped index, -1 to check all records
+func (e *entity) checkKeys(record map[string]interface{}, skip int) error {
+	var keys = make([]string, 0)
+	var unique = make([]string, 0)
+	for _, col := range e.columns {
+		if f, ok := e.fields[col]; ok && f.PrimaryKey {
+			keys = append(keys, col)
+		} else if ok && f.Unique {
+			unique = append(unique, col)
+		}
+	}
+	for i, r := range e.records {
+		if i == skip {
+			continue
+		}
+		if len(keys) > 0 {
+			duplicate := true
+			for _, key := range keys {
+				duplicate = duplicate && equals(r[key], record[key])
+			}
+			if duplicate {
+				return database.WrapError(database.ErrDuplicateKey, errors.New(fmt.Sprintf("Duplicate primary key (%s)", strings.Join(keys, ", "))))
+			}
+		}
+		for _, col := range unique {
+			if record[col] != nil && equals(r[col], record[col]) {
+				return database.WrapError(database.ErrDuplicateKey, errors.New(fmt.Sprintf("Duplicate unique field %s value: %v", col, record[col])))
+			}
+		}
+	}
+	return nil
+}
+
+func (e *entity) add(record map[string]interface{}) {
+	for _, col := range sortedKeys(record, e.columns) {
+		e.addColumn(col)
+	}
+	e.records = append(e.records, record)
+}
+
+// In memory databases store
+type store struct {
+	sync.RWMutex
+	databases map[string]map[string]*entity
+	// Number of write operations, checked by the transactions commit
+	version uint64
+}
+
+// Locks the store for a write operation
+func (s *store) lockWrite() {
+	s.Lock()
+	s.version++
+}
+
+func newStore() *store {
+	return &store{
+		databases: make(map[string]map[string]*entity),
+	}
+}
+
+// Returns a deep copy of the store databases
+func (s *store) snapshot() *store {
+	copied := newStore()
+	for name, db := range s.databases {
+		copiedDb := make(map[string]*entity)
+		for namespace, e := range db {
+			copiedEntity := &entity{
+				columns: append([]string{}, e.columns...),
+				fields:  make(map[string]database.Field),
+				records: make([]map[string]interface{}, 0),
+			}
+			for k, f := range e.fields {
+				copiedEntity.fields[k] = f
+			}
+			for _, record := range e.records {
+				copiedEntity.records = append(copiedEntity.records, copyRecord(record))
+			}
+			copiedDb[namespace] = copiedEntity
+		}
+		copied.databases[name] = copiedDb
+	}
+	return copied
+}
+
+func (s *store) entity(dbRef database.DataRef, create bool) (*entity, error) {
+	db, ok := s.databases[dbRef.Database]
+	if !ok {
+		if !create {
+			return nil, database.WrapError(database.ErrNotFound, errors.New(fmt.Sprintf("Unknown database: %s", dbRef.Database)))
+		}
+		db = make(map[string]*entity)
+		s.databases[dbRef.Database] = db
+	}
+	e, ok := db[dbRef.Namespace]
+	if !ok {
+		if !create {
+			return nil, database.WrapError(database.ErrNotFound, errors.New(fmt.Sprintf("Unknown namespace: %s in database: %s", dbRef.Namespace, dbRef.Database)))
+		}
+		e = newEntity(nil)
+		db[dbRef.Namespace] = e
+	}
+	return e, nil
+}
+
+// Returns record keys, known columns first and then the new ones in alphabetical order
+func sortedKeys(record map[string]interface{}, known []string) []string {
+	var keys = make([]string, 0)
+	for _, col := range known {
+		if _, ok := record[col]; ok {
+			keys = append(keys, col)
+		}
+	}
+	var others = make([]string, 0)
+	for key := range record {
+		var found bool
+		for _, col := range known {
+			if col == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			others = append(others, key)
+		}
+	}
+	sort.Strings(others)
+	return append(keys, others...)
+}
+
+func copyRecord(record map[string]interface{}) map[string]interface{} {
+	var out = make(map[string]interface{}, len(record))
+	for k, v := range record {
+		out[k] = v
+	}
+	return out
+}
+
+// Returns the document field name of a struct field, using the json tag, when available, or the lower case field name
+func documentFieldName(f reflect.StructField) string {
+	if tag, ok := f.Tag.Lookup("json"); ok {
+		name := strings.TrimSpace(strings.Split(tag, ",")[0])
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return strings.ToLower(f.Name)
+}
+
+// Converts maps, structs and ordered key/value element slices (as bson.D) to a document
+func toDocument(value interface{}) (map[string]interface{}, error) {
+	if value == nil {
+		return nil, errors.New(fmt.Sprint("Document value cannot be nil"))
+	}
+	if doc, ok := value.(map[string]interface{}); ok {
+		return copyRecord(doc), nil
+	}
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, errors.New(fmt.Sprint("Document value cannot be nil"))
+		}
+		v = v.Elem()
+	}
+	var doc = make(map[string]interface{})
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, errors.New(fmt.Sprintf("Document map keys must be strings: %v", v.Type()))
+		}
+		for _, key := range v.MapKeys() {
+			doc[key.String()] = v.MapIndex(key).Interface()
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			if name := documentFieldName(f); name != "" {
+				doc[name] = v.Field(i).Interface()
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		elemType := v.Type().Elem()
+		if elemType.Kind() != reflect.Struct {
+			return nil, errors.New(fmt.Sprintf("Unsupported document type: %v", v.Type()))
+		}
+		keyField, hasKey := elemType.FieldByName("Key")
+		_, hasValue := elemType.FieldByName("Value")
+		if !hasKey || !hasValue || keyField.Type.Kind() != reflect.String {
+			return nil, errors.New(fmt.Sprintf("Unsupported document type: %v", v.Type()))
+		}
+		for i := 0; i < v.Len(); i++ {
+			elem := v.Index(i)
+			var val = elem.FieldByName("Value").Interface()
+			if isDocument(val) {
+				if sub, err := toDocument(val); err == nil {
+					val = sub
+				}
+			}
+			doc[elem.FieldByName("Key").String()] = val
+		}
+	default:
+		return nil, errors.New(fmt.Sprintf("Unsupported document type: %v", v.Type()))
+	}
+	return doc, nil
+}
+
+// Verifies the value is a key/value elements slice document
+func isDocument(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	t := reflect.TypeOf(value)
+	if (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() == reflect.Struct {
+		_, hasKey := t.Elem().FieldByName("Key")
+		_, hasValue := t.Elem().FieldByName("Value")
+		return hasKey && hasValue
+	}
+	return false
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// Compares two values, returning the comparison sign and whether values are comparable
+func compare(a interface{}, b interface{}) (int, bool) {
+	if fa, ok := toFloat(a); ok {
+		if fb, ok := toFloat(b); ok {
+			switch {
+			case fa < fb:
+				return -1, true
+			case fa > fb:
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+		return 0, false
+	}
+	switch va := a.(type) {
+	case string:
+		if vb, ok := b.(string); ok {
+			return strings.Compare(va, vb), true
+		}
+	case time.Time:
+		if vb, ok := b.(time.Time); ok {
+			switch {
+			case va.Before(vb):
+				return -1, true
+			case va.After(vb):
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+	case bool:
+		if vb, ok := b.(bool); ok {
+			switch {
+			case va == vb:
+				return 0, true
+			case !va:
+				return -1, true
+			default:
+				return 1, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func equals(a interface{}, b interface{}) bool {
+	if c, ok := compare(a, b); ok {
+		return c == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// Matches a value against a SQL LIKE pattern, where % matches any sequence and _ any single character, ignoring case
+func like(value string, pattern string) bool {
+	var expr = "(?is)^"
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			expr += ".*"
+		case '_':
+			expr += "."
+		default:
+			expr += regexp.QuoteMeta(string(r))
+		}
+	}
+	matched, err := regexp.MatchString(expr+"$", value)
+	return err == nil && matched
+}
+
+// Evaluates a condition on a record, following the SQL semantics: Not combinations are translated like the
+// MySQL driver does (e.g. Not + LessThan is GraterThan) and missing or null values match only the Null operation
+func matches(record map[string]interface{}, cond database.Condition) bool {
+	operation := byte(cond.Operation)
+	not := false
+	if operation > byte(database.Not) {
+		operation = operation - byte(database.Not)
+		not = true
+	}
+	value, exists := record[cond.Field]
+	if operation == byte(database.Null) {
+		isNull := !exists || value == nil
+		if not {
+			return !isNull
+		}
+		return isNull
+	}
+	if !exists || value == nil {
+		return false
+	}
+	expected := cond.Value.Value
+	switch operation {
+	case byte(database.LessThan):
+		c, ok := compare(value, expected)
+		if not {
+			return ok && c > 0
+		}
+		return ok && c < 0
+	case byte(database.LessThanEquals):
+		c, ok := compare(value, expected)
+		if not {
+			return ok && c >= 0
+		}
+		return ok && c <= 0
+	case byte(database.GraterThan):
+		c, ok := compare(value, expected)
+		if not {
+			return ok && c < 0
+		}
+		return ok && c > 0
+	case byte(database.GraterThanEquals):
+		c, ok := compare(value, expected)
+		if not {
+			return ok && c <= 0
+		}
+		return ok && c >= 0
+	case byte(database.Like):
+		matched := like(fmt.Sprint(value), fmt.Sprint(expected))
+		if not {
+			return !matched
+		}
+		return matched
+	case byte(database.In):
+		var found bool
+		for _, item := range database.ExpandValues(expected) {
+			if equals(value, item) {
+				found = true
+				break
+			}
+		}
+		if not {
+			return !found
+		}
+		return found
+	default:
+		if not {
+			return !equals(value, expected)
+		}
+		return equals(value, expected)
+	}
+}
+
+// Evaluates the filter expression on a record, empty filter groups match any record
+func matchesFilter(record map[string]interface{}, filter database.Filter) bool {
+	if filter.Type == database.LeafFilter {
+		return matches(record, filter.Condition)
+	}
+	children := filter.Children()
+	if len(children) == 0 {
+		return true
+	}
+	switch filter.Type {
+	case database.OrFilter:
+		for _, sub := range children {
+			if matchesFilter(record, sub) {
+				return true
+			}
+		}
+		return false
+	case database.NotFilter:
+		for _, sub := range children {
+			if !matchesFilter(record, sub) {
+				return true
+			}
+		}
+		return false
+	default:
+		for _, sub := range children {
+			if !matchesFilter(record, sub) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Compares the records on the order by fields, null or missing values precede any other value
+func less(a map[string]interface{}, b map[string]interface{}, orderBy []database.OrderBy) bool {
+	for _, order := range orderBy {
+		va, vb := a[order.Field], b[order.Field]
+		var result int
+		switch {
+		case va == nil && vb == nil:
+			result = 0
+		case va == nil:
+			result = -1
+		case vb == nil:
+			result = 1
+		default:
+			result, _ = compare(va, vb)
+		}
+		if order.IsDescending() {
+			result = -result
+		}
+		if result != 0 {
+			return result < 0
+		}
+	}
+	return false
+}
+
+// Sorts the records and applies the offset and limit query options
+func page(records []map[string]interface{}, options database.QueryOptions) []map[string]interface{} {
+	if len(options.OrderBy) > 0 {
+		sort.SliceStable(records, func(i, j int) bool {
+			return less(records[i], records[j], options.OrderBy)
+		})
+	}
+	if options.Offset >= int64(len(records)) {
+		return make([]map[string]interface{}, 0)
+	}
+	records = records[options.Offset:]
+	if options.Limit > 0 && options.Limit < int64(len(records)) {
+		records = records[:options.Limit]
+	}
+	return records
+}
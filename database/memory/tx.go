@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+)
+
+// Transaction commit conflict error, returned when the store has been changed by other connections after the
+// transaction began
+var ErrConflict = errors.New(fmt.Sprint("Store changed by another connection since the transaction began"))
+
+// Transaction exposing the records operations of the connection working on the store snapshot
+type memoryTx struct {
+	database.TxOperations
+	conn *memoryConnection
+	// Store version the snapshot was taken at
+	version uint64
+}
+
+// Replaces the store data with the transaction snapshot, failing with ErrConflict when the store has been changed
+// after the transaction began. The transaction ends in both cases.
+func (t *memoryTx) Commit() (err error) {
+	defer opError("Commit", database.DataRef{}, &err)
+	if err := t.conn.check(context.Background()); err != nil {
+		return err
+	}
+	t.conn.Valid = false
+	t.conn.origin.Lock()
+	defer t.conn.origin.Unlock()
+	if t.conn.origin.version != t.version {
+		return ErrConflict
+	}
+	t.conn.store.RLock()
+	t.conn.origin.databases = t.conn.store.databases
+	t.conn.store.RUnlock()
+	t.conn.origin.version++
+	return nil
+}
+
+func (t *memoryTx) Rollback() (err error) {
+	defer opError("Rollback", database.DataRef{}, &err)
+	if err := t.conn.check(context.Background()); err != nil {
+		return err
+	}
+	t.conn.Valid = false
+	return nil
+}
+
+// Starts a transaction working on a snapshot of the store data, which replaces the store data on commit: the commit
+// fails with ErrConflict when other connections changed the store in the meanwhile. The transaction options are ignored.
+func (c *memoryConnection) Begin(ctx context.Context, options database.TxOptions) (_ database.Tx, err error) {
+	defer opError("Begin", database.DataRef{}, &err)
+	if err := c.check(ctx); err != nil {
+		return nil, err
+	}
+	if c.origin != nil {
+		return nil, database.WrapError(database.ErrUnsupported, errors.New(fmt.Sprint("Nested transactions are not supported")))
+	}
+	c.store.RLock()
+	snapshot := c.store.snapshot()
+	version := c.store.version
+	c.store.RUnlock()
+	conn := &memoryConnection{
+		Configuration: c.Configuration,
+		Valid:         true,
+		store:         snapshot,
+		origin:        c.store,
+	}
+	return &memoryTx{
+		TxOperations: conn,
+		conn:         conn,
+		version:      version,
+	}, nil
+}
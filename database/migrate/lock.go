@@ -0,0 +1,134 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Interval between the lock acquisition attempts
+const lockPollInterval = time.Second
+
+func (m *Migrator) lockRef() database.DataRef {
+	return m.ref(m.options.table() + "_lock")
+}
+
+// Returns the lock record key field, the document id for MongoDB
+func (m *Migrator) lockKey() string {
+	if m.mongo {
+		return "_id"
+	}
+	return "id"
+}
+
+// Sequence of the locks taken by the process, telling apart the migrators of the same process
+var lockSequence uint64
+
+// Returns a new lock owner, identifying the host, the process and the lock
+func lockOwner() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%v:%v", host, os.Getpid(), atomic.AddUint64(&lockSequence, 1))
+}
+
+// Takes the migrations lock, inserting the single lock record: concurrent inserts fail on the record key, so only
+// one migrator holds the lock. Waits for the lock held by another migrator, removing the abandoned locks, and
+// returns the lock release function, which removes the lock record only while owned. The lock time is refreshed while the lock is held, so the locks of the running
+// migrators never become stale.
+func (m *Migrator) lock(ctx context.Context) (func(), error) {
+	ref := m.lockRef()
+	key := m.lockKey()
+	err := m.conn.CreateContext(ctx, ref, []database.Field{
+		{Name: key, Type: "BIGINT", PrimaryKey: true, NotNull: true},
+		{Name: "owner", Type: "VARCHAR", Size: 255},
+		{Name: "locked_at", Type: "BIGINT"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	wait, stale := m.options.LockWait, m.options.StaleLock
+	if wait <= 0 {
+		wait = time.Minute
+	}
+	if stale <= 0 {
+		stale = 15 * time.Minute
+	}
+	keyFilter := database.Leaf(database.Condition{Field: key, Operation: database.Equals, Value: database.Value{Value: int64(1)}})
+	owner := lockOwner()
+	ownerFilter := database.And(keyFilter, database.Leaf(database.Condition{
+		Field:     "owner",
+		Operation: database.Equals,
+		Value:     database.Value{Value: owner},
+	}))
+	deadline := time.Now().Add(wait)
+	for {
+		_, _ = m.conn.DeleteFilter(ctx, ref, database.And(keyFilter, database.Leaf(database.Condition{
+			Field:     "locked_at",
+			Operation: database.LessThan,
+			Value:     database.Value{Value: time.Now().Add(-stale).Unix()},
+		})))
+		err = m.conn.InsertContext(ctx, ref, []database.Field{{Name: key}, {Name: "owner"}, {Name: "locked_at"}},
+			[]database.Value{{Value: int64(1)}, {Value: owner}, {Value: time.Now().Unix()}})
+		if err == nil {
+			stop, done := make(chan struct{}), make(chan struct{})
+			go m.heartbeat(ownerFilter, stale/3, stop, done)
+			return func() {
+				close(stop)
+				<-done
+				_, _ = m.conn.DeleteFilter(context.Background(), ref, ownerFilter)
+			}, nil
+		}
+		held, queryErr := m.lockHeld(ctx, keyFilter)
+		if queryErr != nil {
+			return nil, queryErr
+		}
+		if !held {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.New(fmt.Sprintf("Migrations lock is held by another migrator since %v", wait))
+		}
+		timer := time.NewTimer(lockPollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Refreshes the lock time of the owned lock every interval, until stopped
+func (m *Migrator) heartbeat(ownerFilter database.Filter, interval time.Duration, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			_, _ = m.conn.UpdateFilter(context.Background(), m.lockRef(), ownerFilter, []database.Field{{Name: "locked_at"}},
+				[]database.Value{{Value: time.Now().Unix()}})
+		}
+	}
+}
+
+// Verifies the lock record exists
+func (m *Migrator) lockHeld(ctx context.Context, keyFilter database.Filter) (bool, error) {
+	rows, err := m.conn.Stream(ctx, m.lockRef(), []string{m.lockKey()}, keyFilter, database.QueryOptions{Limit: 1})
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	held := rows.Next()
+	return held, rows.Err()
+}
@@ -0,0 +1,436 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Direction enumeration type
+type Direction byte
+
+const (
+	// Apply migration Direction enumeration type
+	Up Direction = iota + 1
+	// Revert migration Direction enumeration type
+	Down
+)
+
+func (d Direction) String() string {
+	if d == Down {
+		return "down"
+	}
+	return "up"
+}
+
+// Default bookkeeping table or collection name
+const DefaultTable = "schema_migrations"
+
+// Migration file names: <version>_<name>.<up|down>.<sql|json>
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.(sql|json)$`)
+
+// Versioned migration descriptor structure
+type Migration struct {
+	// Migration version, ordering the migrations
+	Version int64
+	// Migration name
+	Name string
+	// Apply script
+	Up string
+	// Revert script, empty when the migration can't be reverted
+	Down string
+}
+
+// Migration step descriptor structure
+type Step struct {
+	// Migration to apply or revert
+	Migration Migration
+	// Step direction
+	Direction Direction
+}
+
+// Returns the step script
+func (s Step) Script() string {
+	if s.Direction == Down {
+		return s.Migration.Down
+	}
+	return s.Migration.Up
+}
+
+// Migration status descriptor structure
+type Status struct {
+	// Migration version
+	Version int64
+	// Migration name
+	Name string
+	// Migration has been applied
+	Applied bool
+	// Migration application time, RFC3339 formatted
+	AppliedAt string
+	// Applied migration has no migration file
+	Missing bool
+}
+
+// Migrator options structure, zero values take the defaults
+type Options struct {
+	// Bookkeeping table or collection name, DefaultTable when empty; the lock uses the same name with the _lock suffix
+	Table string
+	// Migration files extension, sql or json, chosen by the connection driver when empty
+	Extension string
+	// Returns the steps without running them nor taking the lock
+	DryRun bool
+	// Maximum time waiting for the lock held by another migrator, 1 minute when not positive
+	LockWait time.Duration
+	// Age of the locks considered abandoned by a crashed migrator and removed, 15 minutes when not positive. The lock
+	// holder refreshes its lock every third of this age.
+	StaleLock time.Duration
+}
+
+func (o Options) table() string {
+	if o.Table == "" {
+		return DefaultTable
+	}
+	return o.Table
+}
+
+// Reads the migrations with the given extension from the file system directory, e.g. an embed.FS or os.DirFS
+func Load(fsys fs.FS, dir string, extension string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var byVersion = make(map[int64]*Migration)
+	for _, entry := range entries {
+		parts := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || parts == nil || parts[4] != extension {
+			continue
+		}
+		version, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Migration file %s version error: %v", entry.Name(), err))
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = m
+		} else if m.Name != parts[2] {
+			return nil, errors.New(fmt.Sprintf("Migration version %v is used by %s and %s", version, m.Name, parts[2]))
+		}
+		if parts[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+	var migrations = make([]Migration, 0)
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, errors.New(fmt.Sprintf("Migration %v_%s has no up script", m.Version, m.Name))
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Schema migrator, applying and reverting the migrations and tracking the applied versions in the bookkeeping
+// table or collection
+type Migrator struct {
+	conn       database.Connection
+	dbRef      database.DataRef
+	migrations []Migration
+	options    Options
+	mongo      bool
+}
+
+// Returns true when the connection is a MongoDB one
+func isMongo(conn database.Connection) bool {
+	if configurable, ok := conn.(database.ConfigConnection); ok {
+		return database.DriverToType(configurable.Config().Driver) == database.MongoDbDriver
+	}
+	return false
+}
+
+// Creates the migrator of the migrations in the file system directory, reading the sql files for the SQL drivers
+// and the json files, holding command documents, for MongoDB. The data reference selects the database and schema.
+func New(conn database.Connection, dbRef database.DataRef, fsys fs.FS, dir string, options Options) (*Migrator, error) {
+	mongo := isMongo(conn)
+	if options.Extension == "" {
+		options.Extension = "sql"
+		if mongo {
+			options.Extension = "json"
+		}
+	}
+	migrations, err := Load(fsys, dir, options.Extension)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithMigrations(conn, dbRef, migrations, options), nil
+}
+
+// Creates the migrator of the given migrations
+func NewWithMigrations(conn database.Connection, dbRef database.DataRef, migrations []Migration, options Options) *Migrator {
+	var sorted = append([]Migration{}, migrations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	return &Migrator{
+		conn:       conn,
+		dbRef:      dbRef,
+		migrations: sorted,
+		options:    options,
+		mongo:      isMongo(conn),
+	}
+}
+
+// Returns the known migrations, in version order
+func (m *Migrator) Migrations() []Migration {
+	return append([]Migration{}, m.migrations...)
+}
+
+func (m *Migrator) ref(namespace string) database.DataRef {
+	ref := m.dbRef
+	ref.Namespace = namespace
+	ref.IfNotExists = true
+	return ref
+}
+
+type appliedRecord struct {
+	Version   int64  `db:"version"`
+	Name      string `db:"name"`
+	AppliedAt string `db:"applied_at"`
+}
+
+// Creates the bookkeeping table, if not exists
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	return m.conn.CreateContext(ctx, m.ref(m.options.table()), []database.Field{
+		{Name: "version", Type: "BIGINT", PrimaryKey: true, NotNull: true},
+		{Name: "name", Type: "VARCHAR", Size: 255},
+		{Name: "applied_at", Type: "VARCHAR", Size: 64},
+	})
+}
+
+// Reads the applied migrations by version
+func (m *Migrator) applied(ctx context.Context) (map[int64]appliedRecord, error) {
+	var applied = make(map[int64]appliedRecord)
+	rows, err := m.conn.Stream(ctx, m.ref(m.options.table()), []string{"version", "name", "applied_at"}, database.And(),
+		database.QueryOptions{OrderBy: []database.OrderBy{{Field: "version"}}})
+	if err != nil {
+		return applied, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	for rows.Next() {
+		var record appliedRecord
+		if err = rows.Scan(&record); err != nil {
+			return applied, err
+		}
+		applied[record.Version] = record
+	}
+	return applied, rows.Err()
+}
+
+// Reads the applied migrations, creating the bookkeeping table unless running dry
+func (m *Migrator) load(ctx context.Context) (map[int64]appliedRecord, error) {
+	if !m.options.DryRun {
+		if err := m.ensureTable(ctx); err != nil {
+			return nil, err
+		}
+		return m.applied(ctx)
+	}
+	applied, err := m.applied(ctx)
+	if errors.Is(err, database.ErrNotFound) {
+		// The bookkeeping table doesn't exist until the first migration
+		return make(map[int64]appliedRecord), nil
+	}
+	return applied, err
+}
+
+// Returns the status of the known and applied migrations, in version order
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var statuses = make([]Status, 0)
+	for _, migration := range m.migrations {
+		record, ok := applied[migration.Version]
+		statuses = append(statuses, Status{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Applied:   ok,
+			AppliedAt: record.AppliedAt,
+		})
+		delete(applied, migration.Version)
+	}
+	for _, record := range applied {
+		statuses = append(statuses, Status{
+			Version:   record.Version,
+			Name:      record.Name,
+			Applied:   true,
+			AppliedAt: record.AppliedAt,
+			Missing:   true,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// Returns the highest applied version, zero when no migration has been applied
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	applied, err := m.load(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var version int64
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// Applies all the pending migrations, returning the applied steps
+func (m *Migrator) Up(ctx context.Context) ([]Step, error) {
+	return m.run(ctx, func(applied map[int64]appliedRecord) ([]Step, error) {
+		return m.upSteps(applied, -1), nil
+	})
+}
+
+// Reverts the given number of applied migrations, the latest first, returning the reverted steps
+func (m *Migrator) Down(ctx context.Context, count int) ([]Step, error) {
+	return m.run(ctx, func(applied map[int64]appliedRecord) ([]Step, error) {
+		steps := m.downSteps(applied, -1)
+		if count >= 0 && len(steps) > count {
+			steps = steps[:count]
+		}
+		return steps, checkDown(steps)
+	})
+}
+
+// Migrates to the given version, applying the pending migrations up to it and reverting the applied ones following
+// it, returning the run steps
+func (m *Migrator) Goto(ctx context.Context, version int64) ([]Step, error) {
+	return m.run(ctx, func(applied map[int64]appliedRecord) ([]Step, error) {
+		known := version == 0
+		for _, migration := range m.migrations {
+			known = known || migration.Version == version
+		}
+		if !known {
+			return nil, errors.New(fmt.Sprintf("Unknown migration version: %v", version))
+		}
+		steps := m.downSteps(applied, version)
+		if err := checkDown(steps); err != nil {
+			return nil, err
+		}
+		return append(steps, m.upSteps(applied, version)...), nil
+	})
+}
+
+// Lists the pending migrations up to the given version, all of them when negative
+func (m *Migrator) upSteps(applied map[int64]appliedRecord, version int64) []Step {
+	var steps = make([]Step, 0)
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok && (version < 0 || migration.Version <= version) {
+			steps = append(steps, Step{Migration: migration, Direction: Up})
+		}
+	}
+	return steps
+}
+
+// Lists the applied migrations following the given version, all of them when negative, the latest first
+func (m *Migrator) downSteps(applied map[int64]appliedRecord, version int64) []Step {
+	var steps = make([]Step, 0)
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; ok && migration.Version > version {
+			steps = append(steps, Step{Migration: migration, Direction: Down})
+		}
+	}
+	return steps
+}
+
+// Verifies the reverted migrations have a down script
+func checkDown(steps []Step) error {
+	for _, step := range steps {
+		if step.Migration.Down == "" {
+			return errors.New(fmt.Sprintf("Migration %v_%s has no down script", step.Migration.Version, step.Migration.Name))
+		}
+	}
+	return nil
+}
+
+// Plans the steps holding the lock and runs them, unless running dry
+func (m *Migrator) run(ctx context.Context, plan func(applied map[int64]appliedRecord) ([]Step, error)) ([]Step, error) {
+	if m.options.DryRun {
+		applied, err := m.load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return plan(applied)
+	}
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	steps, err := plan(applied)
+	if err != nil {
+		return nil, err
+	}
+	for i, step := range steps {
+		if err = m.runStep(ctx, step); err != nil {
+			return steps[:i], err
+		}
+	}
+	return steps, nil
+}
+
+// Runs the step script and records it in the bookkeeping table. Scripts are not run in a transaction, as most
+// databases commit schema changes immediately: a failing script may be partially applied.
+func (m *Migrator) runStep(ctx context.Context, step Step) error {
+	name := fmt.Sprintf("%v_%s.%s", step.Migration.Version, step.Migration.Name, step.Direction)
+	if err := m.conn.ExecScript(ctx, m.dbRef, step.Script()); err != nil {
+		return errors.New(fmt.Sprintf("Migration %s failed: %v", name, err))
+	}
+	table := m.ref(m.options.table())
+	var err error
+	if step.Direction == Up {
+		err = m.conn.InsertContext(ctx, table, []database.Field{{Name: "version"}, {Name: "name"}, {Name: "applied_at"}},
+			[]database.Value{{Value: step.Migration.Version}, {Value: step.Migration.Name}, {Value: time.Now().UTC().Format(time.RFC3339)}})
+	} else {
+		_, err = m.conn.DeleteFilter(ctx, table, database.Leaf(database.Condition{
+			Field:     "version",
+			Operation: database.Equals,
+			Value:     database.Value{Value: step.Migration.Version},
+		}))
+	}
+	if err != nil {
+		return errors.New(fmt.Sprintf("Migration %s bookkeeping failed: %v", name, err))
+	}
+	return nil
+}
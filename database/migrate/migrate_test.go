@@ -0,0 +1,150 @@
+package migrate
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"github.com/hellgate75/go-services/database"
+	"github.com/hellgate75/go-services/database/sqlite"
+	"testing"
+	"time"
+)
+
+//go:embed testdata/migrations
+var migrations embed.FS
+
+func connect(t *testing.T) database.Connection {
+	conn, err := sqlite.GetSqliteDriver().Connect(database.DbConfig{Url: sqlite.MemoryDatabase})
+	if err != nil {
+		t.Fatalf("Connection error occured: %v", err)
+	}
+	return conn
+}
+
+func TestLoad(t *testing.T) {
+	sqlMigrations, err := Load(migrations, "testdata/migrations", "sql")
+	if err != nil {
+		t.Fatalf("Migrations loading error occured: %v", err)
+	}
+	if len(sqlMigrations) != 3 || sqlMigrations[1].Version != 2 || sqlMigrations[1].Name != "add_user_name" || sqlMigrations[2].Down == "" {
+		t.Fatalf("Wrong migrations: %+v", sqlMigrations)
+	}
+	jsonMigrations, err := Load(migrations, "testdata/migrations", "json")
+	if err != nil {
+		t.Fatalf("Migrations loading error occured: %v", err)
+	}
+	if len(jsonMigrations) != 1 || jsonMigrations[0].Version != 1 {
+		t.Fatalf("Wrong migrations: %+v", jsonMigrations)
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	conn := connect(t)
+	defer func() {
+		_ = conn.Close()
+	}()
+	ctx := context.Background()
+	dryRun, err := New(conn, database.DataRef{}, migrations, "testdata/migrations", Options{DryRun: true})
+	if err != nil {
+		t.Fatalf("Migrator creation error occured: %v", err)
+	}
+	steps, err := dryRun.Up(ctx)
+	if err != nil {
+		t.Fatalf("Dry run error occured: %v", err)
+	}
+	if len(steps) != 3 || steps[0].Direction != Up {
+		t.Fatalf("Wrong dry run steps: %v", steps)
+	}
+	if _, err = conn.Query(database.DataRef{Namespace: "users"}, []string{}, []database.Condition{}, true); err == nil {
+		t.Fatal("Dry run should not apply the migrations")
+	}
+	migrator, _ := New(conn, database.DataRef{}, migrations, "testdata/migrations", Options{})
+	if steps, err = migrator.Up(ctx); err != nil || len(steps) != 3 {
+		t.Fatalf("Migration error occured: %v %v", err, steps)
+	}
+	err = conn.Insert(database.DataRef{Namespace: "users"}, []database.Field{{Name: "id"}, {Name: "email"}}, []database.Value{{Value: 1}, {Value: "john@example.com"}})
+	if err != nil {
+		t.Fatalf("Migrated table insert error occured: %v", err)
+	}
+	if steps, err = migrator.Up(ctx); err != nil || len(steps) != 0 {
+		t.Fatalf("Applied migrations should not run again: %v %v", err, steps)
+	}
+	closed := connect(t)
+	_ = closed.Close()
+	dryRun, _ = New(closed, database.DataRef{}, migrations, "testdata/migrations", Options{DryRun: true})
+	if _, err = dryRun.Status(ctx); !errors.Is(err, database.ErrClosed) {
+		t.Fatalf("Dry run should report the connection errors: %v", err)
+	}
+	steps, err = migrator.Goto(ctx, 1)
+	if err != nil {
+		t.Fatalf("Migration error occured: %v", err)
+	}
+	if len(steps) != 2 || steps[0].Migration.Version != 3 || steps[1].Direction != Down {
+		t.Fatalf("Wrong goto steps: %v", steps)
+	}
+	version, err := migrator.Version(ctx)
+	if err != nil || version != 1 {
+		t.Fatalf("Wrong version: %v %v", version, err)
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Migration status error occured: %v", err)
+	}
+	if len(statuses) != 3 || !statuses[0].Applied || statuses[0].AppliedAt == "" || statuses[1].Applied {
+		t.Fatalf("Wrong statuses: %+v", statuses)
+	}
+	if steps, err = migrator.Down(ctx, 1); err != nil || len(steps) != 1 {
+		t.Fatalf("Migration error occured: %v %v", err, steps)
+	}
+	if version, _ = migrator.Version(ctx); version != 0 {
+		t.Fatalf("Wrong version: %v", version)
+	}
+}
+
+func TestMigratorLock(t *testing.T) {
+	conn := connect(t)
+	defer func() {
+		_ = conn.Close()
+	}()
+	ctx := context.Background()
+	migrator, _ := New(conn, database.DataRef{}, migrations, "testdata/migrations", Options{LockWait: time.Millisecond})
+	unlock, err := migrator.lock(ctx)
+	if err != nil {
+		t.Fatalf("Lock error occured: %v", err)
+	}
+	if _, err = migrator.Up(ctx); err == nil {
+		t.Fatal("Migrations should not run while the lock is held")
+	}
+	unlock()
+	if _, err = migrator.Up(ctx); err != nil {
+		t.Fatalf("Migration error occured: %v", err)
+	}
+	holder, _ := New(conn, database.DataRef{}, migrations, "testdata/migrations", Options{StaleLock: 1500 * time.Millisecond})
+	if unlock, err = holder.lock(ctx); err != nil {
+		t.Fatalf("Lock error occured: %v", err)
+	}
+	contender, _ := New(conn, database.DataRef{}, migrations, "testdata/migrations", Options{LockWait: 2 * time.Second, StaleLock: time.Second})
+	if _, err = contender.Up(ctx); err == nil {
+		t.Fatal("The lock of a running migrator should not be removed")
+	}
+	unlock()
+	err = conn.Insert(migrator.lockRef(), []database.Field{{Name: "id"}, {Name: "owner"}, {Name: "locked_at"}},
+		[]database.Value{{Value: int64(1)}, {Value: "crashed"}, {Value: time.Now().Add(-time.Hour).Unix()}})
+	if err != nil {
+		t.Fatalf("Lock insert error occured: %v", err)
+	}
+	if _, err = contender.Up(ctx); err != nil {
+		t.Fatalf("Abandoned lock should be removed: %v", err)
+	}
+	if unlock, err = migrator.lock(ctx); err != nil {
+		t.Fatalf("Lock error occured: %v", err)
+	}
+	if _, err = conn.UpdateFilter(ctx, migrator.lockRef(), database.And(), []database.Field{{Name: "owner"}},
+		[]database.Value{{Value: "other"}}); err != nil {
+		t.Fatalf("Lock update error occured: %v", err)
+	}
+	unlock()
+	if held, err := migrator.lockHeld(ctx, database.And()); err != nil || !held {
+		t.Fatalf("The lock taken by another owner should not be released: %v %v", held, err)
+	}
+}
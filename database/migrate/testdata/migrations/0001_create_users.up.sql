@@ -0,0 +1,6 @@
+-- Users table
+CREATE TABLE users (
+	id INTEGER PRIMARY KEY,
+	email VARCHAR(255) NOT NULL
+);
+CREATE UNIQUE INDEX users_email ON users (email);
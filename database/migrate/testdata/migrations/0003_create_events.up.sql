@@ -0,0 +1 @@
+CREATE TABLE events (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users (id));
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MongoDB client options structure, merged into the connection url options
+type MongoOptions struct {
+	// Connects with the mongodb+srv:// scheme, resolving the hosts from the DNS SRV records of the host name
+	SRV bool `json:"srv,omitempty" yaml:"srv,omitempty" xml:"srv,omitempty"`
+	// Replica set name
+	ReplicaSet string `json:"replicaSet,omitempty" yaml:"replicaSet,omitempty" xml:"replica-set,omitempty"`
+	// Authentication database, admin when empty
+	AuthSource string `json:"authSource,omitempty" yaml:"authSource,omitempty" xml:"auth-source,omitempty"`
+	// Authentication mechanism, e.g. SCRAM-SHA-256, negotiated with the server when empty
+	AuthMechanism string `json:"authMechanism,omitempty" yaml:"authMechanism,omitempty" xml:"auth-mechanism,omitempty"`
+	// Read preference: primary, primaryPreferred, secondary, secondaryPreferred or nearest
+	ReadPreference string `json:"readPreference,omitempty" yaml:"readPreference,omitempty" xml:"read-preference,omitempty"`
+	// Write concern: majority, the number of acknowledging members or a tag set name
+	WriteConcern string `json:"writeConcern,omitempty" yaml:"writeConcern,omitempty" xml:"write-concern,omitempty"`
+	// Application name reported to the server
+	AppName string `json:"appName,omitempty" yaml:"appName,omitempty" xml:"app-name,omitempty"`
+	// Wire protocol compressors by preference: snappy, zlib or zstd
+	Compressors []string `json:"compressors,omitempty" yaml:"compressors,omitempty" xml:"compressor,omitempty"`
+}
+
+var (
+	// Read preference modes
+	mongoReadPreferences = []string{"primary", "primaryPreferred", "secondary", "secondaryPreferred", "nearest"}
+	// Authentication mechanisms
+	mongoAuthMechanisms = []string{"SCRAM-SHA-1", "SCRAM-SHA-256", "MONGODB-X509", "MONGODB-AWS", "GSSAPI", "PLAIN"}
+	// Wire protocol compressors
+	mongoCompressors = []string{"snappy", "zlib", "zstd"}
+)
+
+// Verifies the value is one of the names, ignoring the case
+func containsName(names []string, value string) bool {
+	for _, name := range names {
+		if strings.EqualFold(name, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validates the MongoDB options, reporting the invalid fields
+func (o MongoOptions) validate(config DbConfig, invalid func(field string, format string, args ...interface{})) {
+	if o.SRV && config.Url == "" {
+		if strings.ContainsAny(config.Host, ":,") {
+			invalid("mongodb.srv", "requires a single host name without port, was %s", config.Host)
+		}
+		if config.Port != 0 {
+			invalid("port", "must be empty with mongodb.srv, was %v", config.Port)
+		}
+	}
+	if o.ReadPreference != "" && !containsName(mongoReadPreferences, o.ReadPreference) {
+		invalid("mongodb.readPreference", "must be one of %s, was %s", strings.Join(mongoReadPreferences, ", "), o.ReadPreference)
+	}
+	if o.AuthMechanism != "" && !containsName(mongoAuthMechanisms, o.AuthMechanism) {
+		invalid("mongodb.authMechanism", "must be one of %s, was %s", strings.Join(mongoAuthMechanisms, ", "), o.AuthMechanism)
+	}
+	if w, err := strconv.Atoi(o.WriteConcern); err == nil && w < 0 {
+		invalid("mongodb.writeConcern", "cannot be negative, was %v", w)
+	}
+	for _, compressor := range o.Compressors {
+		if !containsName(mongoCompressors, compressor) {
+			invalid("mongodb.compressors", "must be %s, was %s", strings.Join(mongoCompressors, ", "), compressor)
+		}
+	}
+}
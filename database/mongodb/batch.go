@@ -0,0 +1,72 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Builds the bulk write models of the batch rows: inserts or, on upsert, replacements of the documents having the
+// same key fields
+func prepareWriteModels(fields []database.Field, rows [][]database.Value, batchOptions database.BatchOptions) ([]mongo.WriteModel, error) {
+	var models = make([]mongo.WriteModel, 0)
+	for _, row := range rows {
+		doc, err := fieldsDocument(fields, row)
+		if err != nil {
+			return models, err
+		}
+		if batchOptions.Upsert {
+			models = append(models, mongo.NewReplaceOneModel().
+				SetFilter(prepareFilter(batchOptions.KeyFilter(fields, row))).
+				SetReplacement(doc).
+				SetUpsert(true))
+		} else {
+			models = append(models, mongo.NewInsertOneModel().SetDocument(doc))
+		}
+	}
+	return models, nil
+}
+
+func (conn *mongoConnection) InsertBatch(dbRef database.DataRef, fields []database.Field, rows [][]database.Value, options database.BatchOptions) ([]database.BatchResult, error) {
+	return conn.InsertBatchContext(context.Background(), dbRef, fields, rows, options)
+}
+
+// Writes the rows with ordered BulkWrite calls, on upsert matched documents are counted as updated
+func (conn *mongoConnection) InsertBatchContext(ctx context.Context, dbRef database.DataRef, fields []database.Field, rows [][]database.Value, batchOptions database.BatchOptions) (_ []database.BatchResult, err error) {
+	defer opError("InsertBatch", dbRef, &err)
+	var results = make([]database.BatchResult, 0)
+	if !conn.Valid || conn.Client == nil {
+		return results, database.ErrClosed
+	}
+	if batchOptions.Upsert && len(batchOptions.KeyFields) == 0 {
+		return results, errors.New(fmt.Sprint("Upsert needs the list of key fields"))
+	}
+	batches, err := database.Batches(fields, rows, batchOptions)
+	if err != nil {
+		return results, err
+	}
+	collection := conn.Client.Database(dbRef.Database).Collection(dbRef.Namespace)
+	for _, batch := range batches {
+		models, err := prepareWriteModels(fields, batch, batchOptions)
+		if err != nil {
+			return results, err
+		}
+		var res *mongo.BulkWriteResult
+		err = conn.withSession(ctx, func(ctx context.Context) error {
+			var writeErr error
+			res, writeErr = collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(true))
+			return writeErr
+		})
+		if err != nil {
+			return results, err
+		}
+		results = append(results, database.BatchResult{
+			Inserted: res.InsertedCount + res.UpsertedCount,
+			Updated:  res.MatchedCount,
+		})
+	}
+	return results, nil
+}
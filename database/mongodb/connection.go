@@ -2,12 +2,19 @@ package mongodb
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/hellgate75/go-services/database"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"regexp"
+	"strings"
+	"time"
 )
 
 type mongoConnection struct {
@@ -16,69 +23,246 @@ type mongoConnection struct {
 	Context       *context.Context
 	Valid         bool
 	Cancel        context.CancelFunc
-	err           error
+	// Transaction session, available for transaction connections
+	session mongo.Session
+	// Connections pool monitor
+	pool *poolMonitor
+	err  error
 }
 
 func (conn *mongoConnection) Query(dbRef database.DataRef, fields []string, conditions []database.Condition, withAnd bool) (database.ResultSet, error) {
+	return conn.QueryContext(context.Background(), dbRef, fields, conditions, withAnd)
+}
+
+func (conn *mongoConnection) QueryContext(ctx context.Context, dbRef database.DataRef, fields []string, conditions []database.Condition, withAnd bool) (database.ResultSet, error) {
+	return conn.find(ctx, dbRef, prepareFilter(database.FromConditions(conditions, withAnd)), prepareFindOptions(fields, database.QueryOptions{}))
+}
+
+func (conn *mongoConnection) QueryFilter(ctx context.Context, dbRef database.DataRef, fields []string, filter database.Filter) (database.ResultSet, error) {
+	return conn.find(ctx, dbRef, prepareFilter(filter), prepareFindOptions(fields, database.QueryOptions{}))
+}
+
+func (conn *mongoConnection) QueryPage(ctx context.Context, dbRef database.DataRef, fields []string, filter database.Filter, queryOptions database.QueryOptions) (_ database.ResultSet, err error) {
+	defer opError("Query", dbRef, &err)
+	if err := queryOptions.Validate(); err != nil {
+		return database.ResultSet{}, err
+	}
+	after, err := queryOptions.AfterFilter()
+	if err != nil {
+		return database.ResultSet{}, err
+	}
+	return conn.find(ctx, dbRef, prepareFilter(database.And(filter, after)), prepareFindOptions(fields, queryOptions))
+}
+
+// Prepares the find options, translating the selected fields in the projection, the sort fields, limit and offset.
+// The document _id is excluded, unless selected, and all the fields are returned when no field is selected.
+func prepareFindOptions(fields []string, queryOptions database.QueryOptions) *options.FindOptions {
+	findOptions := options.Find()
+	if len(fields) > 0 {
+		var projection = bson.D{{Key: "_id", Value: 0}}
+		for _, field := range fields {
+			if field == "_id" {
+				projection[0].Value = 1
+			} else {
+				projection = append(projection, bson.E{Key: field, Value: 1})
+			}
+		}
+		findOptions.SetProjection(projection)
+	}
+	if len(queryOptions.OrderBy) > 0 {
+		var sort = make(bson.D, 0)
+		for _, order := range queryOptions.OrderBy {
+			direction := 1
+			if order.IsDescending() {
+				direction = -1
+			}
+			sort = append(sort, bson.E{Key: order.Field, Value: direction})
+		}
+		findOptions.SetSort(sort)
+	}
+	if queryOptions.Limit > 0 {
+		findOptions.SetLimit(queryOptions.Limit)
+	}
+	if queryOptions.Offset > 0 {
+		findOptions.SetSkip(queryOptions.Offset)
+	}
+	return findOptions
+}
+
+func (conn *mongoConnection) find(ctx context.Context, dbRef database.DataRef, filter bson.D, findOptions *options.FindOptions) (_ database.ResultSet, err error) {
+	defer opError("Query", dbRef, &err)
+	rows, err := conn.cursor(ctx, dbRef, filter, findOptions)
+	if err != nil {
+		return database.ResultSet{
+			MetaData: database.MetaData{
+				Columns:   make([]database.Column, 0),
+				EntityRef: dbRef,
+			},
+			Records: make([]database.Result, 0),
+			Lines:   int64(0),
+		}, err
+	}
+	return database.Collect(rows)
+}
+
+// Returns an iterator over the matching documents, backed by the collection cursor
+func (conn *mongoConnection) Stream(ctx context.Context, dbRef database.DataRef, fields []string, filter database.Filter, queryOptions database.QueryOptions) (_ database.Rows, err error) {
+	defer opError("Stream", dbRef, &err)
+	if err := queryOptions.Validate(); err != nil {
+		return nil, err
+	}
+	after, err := queryOptions.AfterFilter()
+	if err != nil {
+		return nil, err
+	}
+	return conn.cursor(ctx, dbRef, prepareFilter(database.And(filter, after)), prepareFindOptions(fields, queryOptions))
+}
+
+func (conn *mongoConnection) cursor(ctx context.Context, dbRef database.DataRef, filter bson.D, findOptions *options.FindOptions) (rows database.Rows, err error) {
+	defer opError("Query", dbRef, &err)
 	if !conn.Valid || conn.Client == nil {
-		return database.ResultSet{}, errors.New("Connection is closed or invalid")
+		return nil, database.ErrClosed
 	}
-	var err error
 	defer func() {
 		if r := recover(); r != nil {
 			err = errors.New(fmt.Sprintf("Mongo-Connection::Query %v", r))
 			conn.err = err
 		}
 	}()
-	var resultSet = database.ResultSet{
-		MetaData: database.MetaData{
+	if conn.Context == nil {
+		return nil, errors.New(fmt.Sprint("Mongo Context unavailable"))
+	}
+	var cursor *mongo.Cursor
+	err = conn.withSession(ctx, func(ctx context.Context) error {
+		var findErr error
+		cursor, findErr = conn.Client.Database(dbRef.Database).Collection(dbRef.Namespace).Find(ctx, filter, findOptions)
+		return findErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &mongoRows{
+		ctx:    ctx,
+		cursor: cursor,
+		metaData: database.MetaData{
 			Columns:   make([]database.Column, 0),
 			EntityRef: dbRef,
 		},
-		Records: make([]database.Result, 0),
-		Lines:   int64(0),
+	}, nil
+}
+
+// Translates a SQL like pattern in a case insensitive regular expression
+func likeRegex(pattern string) primitive.Regex {
+	var expr = "^"
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			expr += ".*"
+		case '_':
+			expr += "."
+		default:
+			expr += regexp.QuoteMeta(string(r))
+		}
 	}
-	if conn.Context == nil {
-		err = errors.New(fmt.Sprint("Mongo Context unavailable"))
-	} else {
-		var cursor *mongo.Cursor
-		var filter = make(bson.D, 0)
-		for _, cond := range conditions {
-			filter = append(filter, bson.E{
-				Key:   cond.Field,
-				Value: cond.Value.Value,
-			})
+	return primitive.Regex{
+		Pattern: expr + "$",
+		Options: "is",
+	}
+}
+
+// Prepares the filter document of a condition, following the SQL semantics: Not combinations are translated like
+// the MySQL driver does (e.g. Not + LessThan is $gt) and missing or null values match only the Null operation
+func prepareCondition(cond database.Condition) bson.D {
+	operation := byte(cond.Operation)
+	not := false
+	if operation > byte(database.Not) {
+		operation = operation - byte(database.Not)
+		not = true
+	}
+	value := cond.Value.Value
+	var expr interface{}
+	switch database.Operation(operation) {
+	case database.LessThan:
+		if not {
+			expr = bson.D{{Key: "$gt", Value: value}}
+		} else {
+			expr = bson.D{{Key: "$lt", Value: value}}
 		}
-		cursor, err = conn.Client.Database(dbRef.Database).Collection(dbRef.Namespace).Find(*conn.Context, filter)
-		records := 0
-		recordSet := make([]database.Result, 0)
-		for cursor.Next(*conn.Context) {
-			raw := cursor.Current
-			if err = cursor.Err(); err != nil {
-				return database.ResultSet{}, err
-			}
-			values, err := raw.Values()
-			if err == nil {
-				records += 1
-				res := database.Result{
-					Document: raw,
-					Columns:  int64(len(values)),
-					Values:   make([]interface{}, 0),
-				}
-				for _, value := range values {
-					val := convertRawValue(value)
-					res.Values = append(res.Values, val)
-				}
-				recordSet = append(recordSet, res)
-			} else {
-				return database.ResultSet{}, err
-			}
-			resultSet.Lines = int64(len(recordSet))
-			resultSet.Records = recordSet
-			return resultSet, nil
+	case database.LessThanEquals:
+		if not {
+			expr = bson.D{{Key: "$gte", Value: value}}
+		} else {
+			expr = bson.D{{Key: "$lte", Value: value}}
+		}
+	case database.GraterThan:
+		if not {
+			expr = bson.D{{Key: "$lt", Value: value}}
+		} else {
+			expr = bson.D{{Key: "$gt", Value: value}}
+		}
+	case database.GraterThanEquals:
+		if not {
+			expr = bson.D{{Key: "$lte", Value: value}}
+		} else {
+			expr = bson.D{{Key: "$gte", Value: value}}
+		}
+	case database.Like:
+		if not {
+			expr = bson.D{{Key: "$not", Value: likeRegex(fmt.Sprint(value))}, {Key: "$ne", Value: nil}}
+		} else {
+			expr = bson.D{{Key: "$regex", Value: likeRegex(fmt.Sprint(value))}}
+		}
+	case database.In:
+		if not {
+			expr = bson.D{{Key: "$nin", Value: append(bson.A(database.ExpandValues(value)), nil)}}
+		} else {
+			expr = bson.D{{Key: "$in", Value: bson.A(database.ExpandValues(value))}}
+		}
+	case database.Null:
+		if not {
+			expr = bson.D{{Key: "$ne", Value: nil}}
+		} else {
+			expr = nil
+		}
+	default:
+		if not {
+			expr = bson.D{{Key: "$nin", Value: bson.A{value, nil}}}
+		} else {
+			expr = value
 		}
 	}
-	return resultSet, err
+	return bson.D{{Key: cond.Field, Value: expr}}
+}
+
+// Prepares the filter document, mapping And, Or and Not groups to the $and, $or and $nor operators
+func prepareFilter(filter database.Filter) bson.D {
+	if filter.Type == database.LeafFilter {
+		return prepareCondition(filter.Condition)
+	}
+	var docs = make(bson.A, 0)
+	for _, sub := range filter.Children() {
+		docs = append(docs, prepareFilter(sub))
+	}
+	if len(docs) == 0 {
+		return bson.D{}
+	}
+	switch filter.Type {
+	case database.OrFilter:
+		if len(docs) == 1 {
+			return docs[0].(bson.D)
+		}
+		return bson.D{{Key: "$or", Value: docs}}
+	case database.NotFilter:
+		if len(docs) == 1 {
+			return bson.D{{Key: "$nor", Value: docs}}
+		}
+		return bson.D{{Key: "$nor", Value: bson.A{bson.D{{Key: "$and", Value: docs}}}}}
+	default:
+		if len(docs) == 1 {
+			return docs[0].(bson.D)
+		}
+		return bson.D{{Key: "$and", Value: docs}}
+	}
 }
 
 func convertRawValue(value bson.RawValue) interface{} {
@@ -167,10 +351,27 @@ func convertRawValue(value bson.RawValue) interface{} {
 }
 
 func (conn *mongoConnection) Insert(dbRef database.DataRef, fields []database.Field, values []database.Value) error {
+	return conn.InsertContext(context.Background(), dbRef, fields, values)
+}
+
+// Builds the document made of the given fields and values
+func fieldsDocument(fields []database.Field, values []database.Value) (bson.D, error) {
+	if len(fields) != len(values) {
+		return nil, errors.New(fmt.Sprintf("Columns and values must have same length: %v <> %v", len(fields), len(values)))
+	}
+	var doc = make(bson.D, 0)
+	for i, f := range fields {
+		doc = append(doc, bson.E{Key: f.Name, Value: values[i].Value})
+	}
+	return doc, nil
+}
+
+// Inserts a document made of the given fields and values or, when no field is provided, any given document value
+func (conn *mongoConnection) InsertContext(ctx context.Context, dbRef database.DataRef, fields []database.Field, values []database.Value) (err error) {
+	defer opError("Insert", dbRef, &err)
 	if !conn.Valid || conn.Client == nil {
-		return errors.New("Connection is closed or invalid")
+		return database.ErrClosed
 	}
-	var err error
 	defer func() {
 		if r := recover(); r != nil {
 			err = errors.New(fmt.Sprintf("Mongo-Connection::Insert %v", r))
@@ -181,19 +382,44 @@ func (conn *mongoConnection) Insert(dbRef database.DataRef, fields []database.Fi
 		err = errors.New("Mongo Context unavailable")
 	} else {
 		var valMany = make([]interface{}, 0)
-		for _, v := range values {
-			valMany = append(valMany, v.Value)
+		if len(fields) > 0 {
+			doc, docErr := fieldsDocument(fields, values)
+			if docErr != nil {
+				return docErr
+			}
+			valMany = append(valMany, doc)
+		} else {
+			for _, v := range values {
+				valMany = append(valMany, v.Value)
+			}
 		}
-		_, err = conn.Client.Database(dbRef.Database).Collection(dbRef.Namespace).InsertMany(*conn.Context, valMany)
+		err = conn.withSession(ctx, func(ctx context.Context) error {
+			_, insertErr := conn.Client.Database(dbRef.Database).Collection(dbRef.Namespace).InsertMany(ctx, valMany)
+			return insertErr
+		})
 	}
 	return err
 }
 
 func (conn *mongoConnection) Update(dbRef database.DataRef, conditions []database.Condition, fields []database.Field, values []database.Value, withAnd bool) (int64, error) {
+	return conn.UpdateContext(context.Background(), dbRef, conditions, fields, values, withAnd)
+}
+
+func (conn *mongoConnection) UpdateContext(ctx context.Context, dbRef database.DataRef, conditions []database.Condition, fields []database.Field, values []database.Value, withAnd bool) (int64, error) {
+	return conn.update(ctx, dbRef, prepareFilter(database.FromConditions(conditions, withAnd)), fields, values)
+}
+
+func (conn *mongoConnection) UpdateFilter(ctx context.Context, dbRef database.DataRef, filter database.Filter, fields []database.Field, values []database.Value) (int64, error) {
+	return conn.update(ctx, dbRef, prepareFilter(filter), fields, values)
+}
+
+// Updates the matching documents with the given fields and values or, when no field is provided, with any
+// given update document value
+func (conn *mongoConnection) update(ctx context.Context, dbRef database.DataRef, filter bson.D, fields []database.Field, values []database.Value) (_ int64, err error) {
+	defer opError("Update", dbRef, &err)
 	if !conn.Valid || conn.Client == nil {
-		return 0, errors.New("Connection is closed or invalid")
+		return 0, database.ErrClosed
 	}
-	var err error
 	defer func() {
 		if r := recover(); r != nil {
 			err = errors.New(fmt.Sprintf("Mongo-Connection::Update %v", r))
@@ -203,16 +429,20 @@ func (conn *mongoConnection) Update(dbRef database.DataRef, conditions []databas
 	if conn.Context == nil {
 		err = errors.New("Mongo Context unavailable")
 	} else {
-		var filter = make(bson.D, 0)
-		for _, cond := range conditions {
-			filter = append(filter, bson.E{
-				Key:   cond.Field,
-				Value: cond.Value.Value,
-			})
+		if len(fields) > 0 {
+			doc, docErr := fieldsDocument(fields, values)
+			if docErr != nil {
+				return 0, docErr
+			}
+			values = []database.Value{{Value: bson.D{{Key: "$set", Value: doc}}}}
 		}
 		var res *mongo.UpdateResult
 		for _, v := range values {
-			res, err = conn.Client.Database(dbRef.Database).Collection(dbRef.Namespace).UpdateMany(*conn.Context, filter, v.Value)
+			err = conn.withSession(ctx, func(ctx context.Context) error {
+				var updateErr error
+				res, updateErr = conn.Client.Database(dbRef.Database).Collection(dbRef.Namespace).UpdateMany(ctx, filter, v.Value)
+				return updateErr
+			})
 			if err != nil {
 				return 0, err
 			}
@@ -225,10 +455,22 @@ func (conn *mongoConnection) Update(dbRef database.DataRef, conditions []databas
 }
 
 func (conn *mongoConnection) Delete(dbRef database.DataRef, conditions []database.Condition, withAnd bool) (int64, error) {
+	return conn.DeleteContext(context.Background(), dbRef, conditions, withAnd)
+}
+
+func (conn *mongoConnection) DeleteContext(ctx context.Context, dbRef database.DataRef, conditions []database.Condition, withAnd bool) (int64, error) {
+	return conn.delete(ctx, dbRef, prepareFilter(database.FromConditions(conditions, withAnd)))
+}
+
+func (conn *mongoConnection) DeleteFilter(ctx context.Context, dbRef database.DataRef, filter database.Filter) (int64, error) {
+	return conn.delete(ctx, dbRef, prepareFilter(filter))
+}
+
+func (conn *mongoConnection) delete(ctx context.Context, dbRef database.DataRef, filter bson.D) (_ int64, err error) {
+	defer opError("Delete", dbRef, &err)
 	if !conn.Valid || conn.Client == nil {
-		return 0, errors.New("Connection is closed or invalid")
+		return 0, database.ErrClosed
 	}
-	var err error
 	defer func() {
 		if r := recover(); r != nil {
 			err = errors.New(fmt.Sprintf("Mongo-Connection::Delete %v", r))
@@ -238,15 +480,12 @@ func (conn *mongoConnection) Delete(dbRef database.DataRef, conditions []databas
 	if conn.Context == nil {
 		err = errors.New("Mongo Context unavailable")
 	} else {
-		var filter = make(bson.D, 0)
-		for _, cond := range conditions {
-			filter = append(filter, bson.E{
-				Key:   cond.Field,
-				Value: cond.Value.Value,
-			})
-		}
 		var res *mongo.DeleteResult
-		res, err = conn.Client.Database(dbRef.Database).Collection(dbRef.Namespace).DeleteMany(*conn.Context, filter)
+		err = conn.withSession(ctx, func(ctx context.Context) error {
+			var deleteErr error
+			res, deleteErr = conn.Client.Database(dbRef.Database).Collection(dbRef.Namespace).DeleteMany(ctx, filter)
+			return deleteErr
+		})
 		if err == nil {
 			return res.DeletedCount, nil
 		}
@@ -254,11 +493,39 @@ func (conn *mongoConnection) Delete(dbRef database.DataRef, conditions []databas
 	return 0, err
 }
 
+// Counts the matching documents
+func (conn *mongoConnection) Count(ctx context.Context, dbRef database.DataRef, filter database.Filter) (_ int64, err error) {
+	defer opError("Count", dbRef, &err)
+	if !conn.Valid || conn.Client == nil {
+		return 0, database.ErrClosed
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New(fmt.Sprintf("Mongo-Connection::Count %v", r))
+			conn.err = err
+		}
+	}()
+	if conn.Context == nil {
+		return 0, errors.New(fmt.Sprint("Mongo Context unavailable"))
+	}
+	var count int64
+	err = conn.withSession(ctx, func(ctx context.Context) error {
+		var countErr error
+		count, countErr = conn.Client.Database(dbRef.Database).Collection(dbRef.Namespace).CountDocuments(ctx, prepareFilter(filter))
+		return countErr
+	})
+	return count, err
+}
+
 func (conn *mongoConnection) Purge(dbRef database.DataRef) (int64, error) {
+	return conn.PurgeContext(context.Background(), dbRef)
+}
+
+func (conn *mongoConnection) PurgeContext(ctx context.Context, dbRef database.DataRef) (_ int64, err error) {
+	defer opError("Purge", dbRef, &err)
 	if !conn.Valid || conn.Client == nil {
-		return 0, errors.New("Connection is closed or invalid")
+		return 0, database.ErrClosed
 	}
-	var err error
 	defer func() {
 		if r := recover(); r != nil {
 			err = errors.New(fmt.Sprintf("Mongo-Connection::Purge %v", r))
@@ -269,7 +536,7 @@ func (conn *mongoConnection) Purge(dbRef database.DataRef) (int64, error) {
 		err = errors.New("Mongo Context unavailable")
 	} else {
 		var res *mongo.DeleteResult
-		res, err = conn.Client.Database(dbRef.Database).Collection(dbRef.Namespace).DeleteMany(*conn.Context, bson.D{})
+		res, err = conn.Client.Database(dbRef.Database).Collection(dbRef.Namespace).DeleteMany(ctx, bson.D{})
 		if err == nil {
 			return res.DeletedCount, nil
 		}
@@ -278,10 +545,14 @@ func (conn *mongoConnection) Purge(dbRef database.DataRef) (int64, error) {
 }
 
 func (conn *mongoConnection) Create(dbRef database.DataRef, fields []database.Field) error {
+	return conn.CreateContext(context.Background(), dbRef, fields)
+}
+
+func (conn *mongoConnection) CreateContext(ctx context.Context, dbRef database.DataRef, fields []database.Field) (err error) {
+	defer opError("Create", dbRef, &err)
 	if !conn.Valid || conn.Client == nil {
-		return errors.New("Connection is closed or invalid")
+		return database.ErrClosed
 	}
-	var err error
 	defer func() {
 		if r := recover(); r != nil {
 			err = errors.New(fmt.Sprintf("Mongo-Connection::Create %v", r))
@@ -296,7 +567,7 @@ func (conn *mongoConnection) Create(dbRef database.DataRef, fields []database.Fi
 		if db == nil {
 			return errors.New("Errors retriving databse")
 		} else {
-			//			err = db.CreateCollection(*conn.Context, dbRef.Namespace)
+			//			err = db.CreateCollection(ctx, dbRef.Namespace)
 			_ = db.Collection(dbRef.Namespace)
 			if err != nil {
 				collName := conn.Client.Database(dbRef.Database).Collection(dbRef.Namespace).Name()
@@ -306,11 +577,16 @@ func (conn *mongoConnection) Create(dbRef database.DataRef, fields []database.Fi
 	}
 	return err
 }
+
 func (conn *mongoConnection) CreateDb(dbRef database.DataRef) error {
+	return conn.CreateDbContext(context.Background(), dbRef)
+}
+
+func (conn *mongoConnection) CreateDbContext(ctx context.Context, dbRef database.DataRef) (err error) {
+	defer opError("CreateDb", dbRef, &err)
 	if !conn.Valid || conn.Client == nil {
-		return errors.New("Connection is closed or invalid")
+		return database.ErrClosed
 	}
-	var err error
 	defer func() {
 		if r := recover(); r != nil {
 			err = errors.New(fmt.Sprintf("Mongo-Connection::CreateDb %v", r))
@@ -327,10 +603,14 @@ func (conn *mongoConnection) CreateDb(dbRef database.DataRef) error {
 }
 
 func (conn *mongoConnection) Drop(dbRef database.DataRef) error {
+	return conn.DropContext(context.Background(), dbRef)
+}
+
+func (conn *mongoConnection) DropContext(ctx context.Context, dbRef database.DataRef) (err error) {
+	defer opError("Drop", dbRef, &err)
 	if !conn.Valid || conn.Client == nil {
-		return errors.New("Connection is closed or invalid")
+		return database.ErrClosed
 	}
-	var err error
 	defer func() {
 		if r := recover(); r != nil {
 			err = errors.New(fmt.Sprintf("Mongo-Connection::Drop %v", r))
@@ -342,7 +622,7 @@ func (conn *mongoConnection) Drop(dbRef database.DataRef) error {
 	} else {
 		name := conn.Client.Database(dbRef.Database).Name()
 		collName := conn.Client.Database(dbRef.Database).Collection(dbRef.Namespace).Name()
-		err = conn.Client.Database(dbRef.Database).Collection(dbRef.Namespace).Drop(*conn.Context)
+		err = conn.Client.Database(dbRef.Database).Collection(dbRef.Namespace).Drop(ctx)
 		if err == nil {
 			fmt.Printf("Dropped database: %s collection: %s\n", name, collName)
 		}
@@ -351,10 +631,14 @@ func (conn *mongoConnection) Drop(dbRef database.DataRef) error {
 }
 
 func (conn *mongoConnection) DropDb(dbRef database.DataRef) error {
+	return conn.DropDbContext(context.Background(), dbRef)
+}
+
+func (conn *mongoConnection) DropDbContext(ctx context.Context, dbRef database.DataRef) (err error) {
+	defer opError("DropDb", dbRef, &err)
 	if !conn.Valid || conn.Client == nil {
-		return errors.New("Connection is closed or invalid")
+		return database.ErrClosed
 	}
-	var err error
 	defer func() {
 		if r := recover(); r != nil {
 			err = errors.New(fmt.Sprintf("Mongo-Connection::DropDb %v", r))
@@ -365,7 +649,7 @@ func (conn *mongoConnection) DropDb(dbRef database.DataRef) error {
 		err = errors.New("Mongo Context unavailable")
 	} else {
 		name := conn.Client.Database(dbRef.Database).Name()
-		err = conn.Client.Database(dbRef.Database).Drop(*conn.Context)
+		err = conn.Client.Database(dbRef.Database).Drop(ctx)
 		if err == nil {
 			fmt.Printf("Dropped database: %s\n", name)
 		}
@@ -377,25 +661,116 @@ func (conn *mongoConnection) GetLastError() error {
 	return conn.err
 }
 
-func (conn *mongoConnection) Close() error {
-	var err error
+// Maximum time waited for the in use connections to be returned to the pool when closing
+const disconnectTimeout = 10 * time.Second
+
+// Disconnects the client, closing the pooled connections and stopping the server monitors
+func (conn *mongoConnection) Close() (err error) {
+	defer opError("Close", database.DataRef{}, &err)
 	defer func() {
 		if r := recover(); r != nil {
 			err = errors.New(fmt.Sprintf("Mongo-Driver::Disconnect %v", r))
 		}
 	}()
-	if conn.Valid {
-		if conn.Cancel != nil {
-			conn.Cancel()
-		}
-		conn.Context = nil
-		conn.Client = nil
+	if !conn.Valid || conn.Client == nil {
+		return database.ErrClosed
+	}
+	client := conn.Client
+	conn.Valid = false
+	conn.Client = nil
+	conn.Context = nil
+	if conn.session == nil {
+		// Transaction connections share the client of the connection starting them
+		ctx, cancel := context.WithTimeout(context.Background(), disconnectTimeout)
+		defer cancel()
+		err = client.Disconnect(ctx)
+	}
+	if conn.Cancel != nil {
+		conn.Cancel()
 		conn.Cancel = nil
-	} else {
-		err = errors.New("Connection is already closed")
 	}
 	return err
 }
+
+// Parses the JSON script, a command document or an array of command documents, in Extended JSON format
+func parseCommands(script string) ([]bson.D, error) {
+	var commands = make([]bson.D, 0)
+	script = strings.TrimSpace(script)
+	if script == "" {
+		return commands, nil
+	}
+	var raw = make([]json.RawMessage, 0)
+	if strings.HasPrefix(script, "[") {
+		if err := json.Unmarshal([]byte(script), &raw); err != nil {
+			return commands, err
+		}
+	} else {
+		raw = append(raw, json.RawMessage(script))
+	}
+	for i, r := range raw {
+		var command bson.D
+		if err := bson.UnmarshalExtJSON(r, false, &command); err != nil {
+			return commands, errors.New(fmt.Sprintf("Command %v parse error: %v", i+1, err))
+		}
+		commands = append(commands, command)
+	}
+	return commands, nil
+}
+
+// Runs the script commands in order on the data reference database, stopping at the first failing one
+func (conn *mongoConnection) ExecScript(ctx context.Context, dbRef database.DataRef, script string) (err error) {
+	defer opError("ExecScript", dbRef, &err)
+	if !conn.Valid || conn.Client == nil {
+		return database.ErrClosed
+	}
+	commands, err := parseCommands(script)
+	if err != nil {
+		return err
+	}
+	for i, command := range commands {
+		err = conn.withSession(ctx, func(ctx context.Context) error {
+			return conn.Client.Database(dbRef.Database).RunCommand(ctx, command).Err()
+		})
+		if err != nil {
+			return fmt.Errorf("Command %v failed: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (conn *mongoConnection) Ping(ctx context.Context) (err error) {
+	defer opError("Ping", database.DataRef{}, &err)
+	if !conn.Valid || conn.Client == nil {
+		return database.ErrClosed
+	}
+	return conn.Client.Ping(ctx, readpref.Primary())
+}
+
+func (conn *mongoConnection) ServerVersion(ctx context.Context) (_ string, err error) {
+	defer opError("ServerVersion", database.DataRef{}, &err)
+	if !conn.Valid || conn.Client == nil {
+		return "", database.ErrClosed
+	}
+	var info struct {
+		Version string `bson:"version"`
+	}
+	err = conn.Client.Database("admin").RunCommand(ctx, bson.D{{Key: "buildInfo", Value: 1}}).Decode(&info)
+	return info.Version, err
+}
+
+// Returns the connection configuration, with the Driver name
+func (conn *mongoConnection) Config() database.DbConfig {
+	config := conn.Configuration
+	if config.Driver == "" {
+		config.Driver = "mongodb"
+	}
+	return config
+}
+
+func (conn *mongoConnection) Stats() database.PoolStats {
+	return conn.pool.stats()
+}
+
 func (conn *mongoConnection) IsConnected() bool {
 	return conn.Valid
 }
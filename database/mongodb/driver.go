@@ -6,14 +6,37 @@ import (
 	"fmt"
 	"github.com/hellgate75/go-services/database"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	"net/url"
 	"strings"
 )
 
+func init() {
+	if err := database.RegisterType("mongodb", database.MongoDbDriver, []string{"mongo", "mongo-db"}, GetMongoDriver); err != nil {
+		panic(err)
+	}
+}
+
 type mongoDriver struct {
 }
 
+// Connects resolving the configuration secrets, with the credentials masked in the returned errors. The connection
+// keeps the configuration with the secret references, resolved again by each new connection.
 func (md *mongoDriver) Connect(config database.DbConfig) (database.Connection, error) {
+	resolved, err := database.ResolveSecrets(context.Background(), config)
+	if err != nil {
+		return nil, err
+	}
+	return md.ConnectResolved(resolved, config)
+}
+
+// Connects with the configuration secrets already resolved, with the credentials masked in the returned errors
+func (md *mongoDriver) ConnectResolved(resolved database.DbConfig, config database.DbConfig) (database.Connection, error) {
+	conn, err := md.connect(resolved, config)
+	return conn, resolved.RedactError(err)
+}
+
+// Connects with the resolved configuration, keeping the unresolved one as the connection configuration
+func (md *mongoDriver) connect(config database.DbConfig, unresolved database.DbConfig) (database.Connection, error) {
 	var client *mongo.Client
 	var err error
 	defer func() {
@@ -21,69 +44,22 @@ func (md *mongoDriver) Connect(config database.DbConfig) (database.Connection, e
 			err = errors.New(fmt.Sprintf("Mongo-Driver::Connect %v", r))
 		}
 	}()
-	if config.Name != "" && config.Password != "" {
-		if strings.Index(config.Host, ":") > 0 {
-			if strings.Index(config.Host, ",") > 0 {
-				//Cluster compressed configuration
-				var clusterURI = ""
-				for _, srv := range strings.Split(config.Host, ",") {
-					lst := strings.Split(srv, ":")
-					xhost, xport := lst[0], lst[1]
-					var uri = fmt.Sprintf("%s:%s@%s:%s", config.Name, config.Password, xhost, xport)
-					var sep = ""
-					if len(clusterURI) > 0 {
-						sep = ","
-					}
-					clusterURI = fmt.Sprintf("%s%s%s", clusterURI, sep, uri)
-				}
-				clusterURI = fmt.Sprintf("mongodb://%s", clusterURI)
-				client, err = mongo.NewClient(options.Client().ApplyURI(clusterURI))
-			} else {
-				//Single server compressed configuration
-				lst := strings.Split(config.Host, ":")
-				xhost, xport := lst[0], lst[1]
-				var uri = fmt.Sprintf("mongodb://%s:%s@%s:%s", config.Name, config.Password, xhost, xport)
-				client, err = mongo.NewClient(options.Client().ApplyURI(uri))
-			}
-		} else {
-			//No compressed configuration
-			client, err = mongo.NewClient(options.Client().ApplyURI(fmt.Sprintf("mongodb://%s:%s@%s:%v", config.Name, config.Password, config.Host, config.Port)))
-		}
-	} else {
-		if strings.Index(config.Host, ":") > 0 {
-			if strings.Index(config.Host, ",") > 0 {
-				//Cluster compressed configuration
-				var clusterURI = ""
-				for _, srv := range strings.Split(config.Host, ",") {
-					lst := strings.Split(srv, ":")
-					xhost, xport := lst[0], lst[1]
-					var uri = fmt.Sprintf("%s:%s", xhost, xport)
-					var sep = ""
-					if len(clusterURI) > 0 {
-						sep = ","
-					}
-					clusterURI = fmt.Sprintf("%s%s%s", clusterURI, sep, uri)
-				}
-				clusterURI = fmt.Sprintf("mongodb://%s", clusterURI)
-				client, err = mongo.NewClient(options.Client().ApplyURI(clusterURI))
-			} else {
-				//Single server compressed configuration
-				lst := strings.Split(config.Host, ":")
-				xhost, xport := lst[0], lst[1]
-				var uri = fmt.Sprintf("mongodb://%s:%s", xhost, xport)
-				client, err = mongo.NewClient(options.Client().ApplyURI(uri))
-			}
-		} else {
-			//No compressed configuration
-			client, err = mongo.NewClient(options.Client().ApplyURI(fmt.Sprintf("mongodb://%s:%v", config.Host, config.Port)))
-		}
+	pool := &poolMonitor{}
+	opts := clientOptions(connectionURI(config), config.Pool, pool)
+	if err = applyTLS(opts, config); err != nil {
+		return nil, err
+	}
+	if err = applyOptions(opts, config.MongoDB); err != nil {
+		return nil, err
 	}
+	client, err = mongo.NewClient(opts)
 	if err != nil {
 		return nil, err
 	}
 	ctx, cancel := context.WithCancel(context.Background())
 	err = client.Connect(ctx)
 	if err != nil {
+		cancel()
 		return nil, err
 	}
 	var conn = mongoConnection{
@@ -91,11 +67,46 @@ func (md *mongoDriver) Connect(config database.DbConfig) (database.Connection, e
 		Client:        client,
 		Context:       &ctx,
 		Cancel:        cancel,
-		Configuration: config,
+		Configuration: unresolved,
+		pool:          pool,
 	}
 	return &conn, err
 }
 
+// Verifies the host name, an IPv6 address in square brackets included, holds the port
+func hasPort(host string) bool {
+	if strings.HasPrefix(host, "[") {
+		return strings.Contains(host, "]:")
+	}
+	return strings.Contains(host, ":")
+}
+
+// Returns the connection url verbatim when set, otherwise builds it from the host, a single host name or a compressed
+// host[:port][,host[:port]] list, the port, applied to the hosts without port, and the user credentials
+func connectionURI(config database.DbConfig) string {
+	if config.Url != "" {
+		return config.Url
+	}
+	uri := url.URL{Scheme: "mongodb", Host: config.Host}
+	if config.MongoDB.SRV {
+		uri.Scheme = "mongodb+srv"
+	} else if config.Port > 0 {
+		var hosts = make([]string, 0)
+		for _, host := range strings.Split(config.Host, ",") {
+			host = strings.TrimSpace(host)
+			if !hasPort(host) {
+				host = fmt.Sprintf("%s:%v", host, config.Port)
+			}
+			hosts = append(hosts, host)
+		}
+		uri.Host = strings.Join(hosts, ",")
+	}
+	if config.Name != "" && config.Password != "" {
+		uri.User = url.UserPassword(config.Name, config.Password)
+	}
+	return uri.String()
+}
+
 func GetMongoDriver() database.Driver {
 	return &mongoDriver{}
 }
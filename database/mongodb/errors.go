@@ -0,0 +1,87 @@
+package mongodb
+
+import (
+	"errors"
+	"github.com/hellgate75/go-services/database"
+	"go.mongodb.org/mongo-driver/mongo"
+	"strings"
+)
+
+// MongoDB server error codes of transient failures
+var transientErrors = map[int32]bool{
+	6:     true, // HostUnreachable
+	7:     true, // HostNotFound
+	89:    true, // NetworkTimeout
+	91:    true, // ShutdownInProgress
+	189:   true, // PrimarySteppedDown
+	9001:  true, // SocketException
+	10107: true, // NotMaster
+	11600: true, // InterruptedAtShutdown
+	11602: true, // InterruptedDueToReplStateChange
+	13435: true, // NotMasterNoSlaveOk
+	13436: true, // NotMasterOrSecondary
+}
+
+// MongoDB server error codes by error kind
+var errorKinds = map[int]error{
+	11000: database.ErrDuplicateKey, // DuplicateKey
+	11001: database.ErrDuplicateKey, // DuplicateKeyValue
+	12582: database.ErrDuplicateKey, // DuplicateKeyUpdate
+	121:   database.ErrConstraint,   // DocumentValidationFailure
+	50:    database.ErrTimeout,      // MaxTimeMSExpired
+	262:   database.ErrTimeout,      // ExceededTimeLimit
+	26:    database.ErrNotFound,     // NamespaceNotFound
+}
+
+// Classifies the missing documents and the server errors by code, for write exceptions the first classified write
+// error or the write concern error
+func classify(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return database.WrapError(database.ErrNotFound, err)
+	}
+	var codes = make([]int, 0)
+	var writeErr mongo.WriteException
+	var bulkErr mongo.BulkWriteException
+	var cmdErr mongo.CommandError
+	if errors.As(err, &writeErr) {
+		for _, e := range writeErr.WriteErrors {
+			codes = append(codes, e.Code)
+		}
+		if writeErr.WriteConcernError != nil {
+			codes = append(codes, writeErr.WriteConcernError.Code)
+		}
+	} else if errors.As(err, &bulkErr) {
+		for _, e := range bulkErr.WriteErrors {
+			codes = append(codes, e.Code)
+		}
+		if bulkErr.WriteConcernError != nil {
+			codes = append(codes, bulkErr.WriteConcernError.Code)
+		}
+	} else if errors.As(err, &cmdErr) {
+		codes = append(codes, int(cmdErr.Code))
+	}
+	for _, code := range codes {
+		if kind, ok := errorKinds[code]; ok {
+			return database.WrapError(kind, err)
+		}
+	}
+	return err
+}
+
+// Wraps the operation error in a database.OpError, classifying the MongoDB server errors
+func opError(op string, dbRef database.DataRef, err *error) {
+	*err = database.NewOpError(op, "mongodb", dbRef, classify(*err))
+}
+
+// Verifies the error is a network error, a server selection failure, a primary step down or a server shutdown
+func (conn *mongoConnection) IsTransient(err error) bool {
+	if database.IsNetworkError(err) || errors.Is(err, mongo.ErrClientDisconnected) {
+		return true
+	}
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return transientErrors[cmdErr.Code] || cmdErr.HasErrorLabel("TransientTransactionError") ||
+			cmdErr.HasErrorLabel("RetryableWriteError")
+	}
+	return strings.Contains(err.Error(), "server selection error")
+}
@@ -0,0 +1,72 @@
+package mongodb
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"strconv"
+	"strings"
+)
+
+// Merges the configuration MongoDB options into the client options, overriding the connection url ones
+func applyOptions(clientOptions *options.ClientOptions, mongoOptions database.MongoOptions) error {
+	if mongoOptions.ReplicaSet != "" {
+		clientOptions.SetReplicaSet(mongoOptions.ReplicaSet)
+	}
+	if mongoOptions.AppName != "" {
+		clientOptions.SetAppName(mongoOptions.AppName)
+	}
+	if len(mongoOptions.Compressors) > 0 {
+		var compressors = make([]string, 0)
+		for _, compressor := range mongoOptions.Compressors {
+			compressors = append(compressors, strings.ToLower(strings.TrimSpace(compressor)))
+		}
+		clientOptions.SetCompressors(compressors)
+	}
+	if mongoOptions.ReadPreference != "" {
+		mode, err := readpref.ModeFromString(mongoOptions.ReadPreference)
+		if err != nil {
+			return errors.New(fmt.Sprintf("Mongo-Driver::Options invalid read preference: %v", err))
+		}
+		readPreference, err := readpref.New(mode)
+		if err != nil {
+			return errors.New(fmt.Sprintf("Mongo-Driver::Options invalid read preference: %v", err))
+		}
+		clientOptions.SetReadPreference(readPreference)
+	}
+	if mongoOptions.WriteConcern != "" {
+		clientOptions.SetWriteConcern(writeConcern(mongoOptions.WriteConcern))
+	}
+	if mongoOptions.AuthSource != "" || mongoOptions.AuthMechanism != "" {
+		var credential options.Credential
+		if clientOptions.Auth != nil {
+			credential = *clientOptions.Auth
+		} else if mongoOptions.AuthMechanism == "" {
+			// Without credentials there is nothing to authenticate against the source
+			return nil
+		}
+		if mongoOptions.AuthSource != "" {
+			credential.AuthSource = mongoOptions.AuthSource
+		}
+		if mongoOptions.AuthMechanism != "" {
+			credential.AuthMechanism = strings.ToUpper(mongoOptions.AuthMechanism)
+		}
+		clientOptions.SetAuth(credential)
+	}
+	return nil
+}
+
+// Returns the write concern acknowledged by the majority of the members, the given number of members or the members
+// of the tag set
+func writeConcern(w string) *writeconcern.WriteConcern {
+	if strings.EqualFold(w, "majority") {
+		return writeconcern.New(writeconcern.WMajority())
+	}
+	if members, err := strconv.Atoi(w); err == nil {
+		return writeconcern.New(writeconcern.W(members))
+	}
+	return writeconcern.New(writeconcern.WTagSet(w))
+}
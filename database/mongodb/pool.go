@@ -0,0 +1,77 @@
+package mongodb
+
+import (
+	"github.com/hellgate75/go-services/database"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"sync"
+)
+
+// Connections pool monitor, counting the connections from the pool events
+type poolMonitor struct {
+	sync.Mutex
+	maxOpen int
+	open    int
+	inUse   int
+	waiting int
+}
+
+func (p *poolMonitor) handle(e *event.PoolEvent) {
+	p.Lock()
+	defer p.Unlock()
+	switch e.Type {
+	case event.ConnectionCreated:
+		p.open++
+	case event.ConnectionClosed:
+		p.open--
+	case event.GetStarted:
+		p.waiting++
+	case event.GetFailed:
+		p.waiting--
+	case event.GetSucceeded:
+		p.waiting--
+		p.inUse++
+	case event.ConnectionReturned:
+		p.inUse--
+	}
+}
+
+func (p *poolMonitor) stats() database.PoolStats {
+	if p == nil {
+		return database.PoolStats{}
+	}
+	p.Lock()
+	defer p.Unlock()
+	idle := p.open - p.inUse
+	if idle < 0 {
+		idle = 0
+	}
+	return database.PoolStats{
+		MaxOpen: p.maxOpen,
+		Open:    p.open,
+		InUse:   p.inUse,
+		Idle:    idle,
+		Waiting: p.waiting,
+	}
+}
+
+// Builds the client options of the connection URI, applying the pool configuration and the pool monitor
+func clientOptions(uri string, pool database.PoolConfig, monitor *poolMonitor) *options.ClientOptions {
+	clientOptions := options.Client().ApplyURI(uri)
+	if pool.MaxOpen > 0 {
+		clientOptions.SetMaxPoolSize(uint64(pool.MaxOpen))
+	}
+	if pool.MinPoolSize > 0 {
+		clientOptions.SetMinPoolSize(uint64(pool.MinPoolSize))
+	}
+	if pool.IdleTimeout > 0 {
+		clientOptions.SetMaxConnIdleTime(pool.IdleTimeout)
+	}
+	if monitor != nil {
+		monitor.maxOpen = pool.MaxOpen
+		clientOptions.SetPoolMonitor(&event.PoolMonitor{
+			Event: monitor.handle,
+		})
+	}
+	return clientOptions
+}
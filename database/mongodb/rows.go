@@ -0,0 +1,125 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"reflect"
+	"time"
+)
+
+type mongoRows struct {
+	ctx      context.Context
+	cursor   *mongo.Cursor
+	metaData database.MetaData
+	current  database.Result
+	err      error
+}
+
+func (r *mongoRows) Next() bool {
+	r.current = database.Result{}
+	if r.err != nil || !r.cursor.Next(r.ctx) {
+		return false
+	}
+	raw := append(bson.Raw{}, r.cursor.Current...)
+	values, err := raw.Values()
+	if err != nil {
+		r.err = err
+		return false
+	}
+	res := database.Result{
+		Document: raw,
+		Columns:  int64(len(values)),
+		Values:   make([]interface{}, 0),
+	}
+	for _, value := range values {
+		res.Values = append(res.Values, convertRawValue(value))
+	}
+	r.current = res
+	return true
+}
+
+// Decodes the current document when a single struct, map or bson.D destination is provided, otherwise copies the
+// document values in the destinations. Structs with db tags are mapped as database.ScanResult does, other
+// structs are decoded following the bson tags.
+func (r *mongoRows) Scan(dest ...interface{}) error {
+	raw, ok := r.current.Document.(bson.Raw)
+	if !ok {
+		return errors.New(fmt.Sprint("Scan called without calling Next"))
+	}
+	if len(dest) == 1 && isDocument(dest[0]) {
+		if hasDbTags(reflect.TypeOf(dest[0]).Elem()) {
+			var doc bson.M
+			if err := bson.Unmarshal(raw, &doc); err != nil {
+				return err
+			}
+			var record = make(map[string]interface{})
+			for k, v := range doc {
+				if dt, ok := v.(primitive.DateTime); ok {
+					v = time.Unix(int64(dt)/1000, int64(dt)%1000*int64(time.Millisecond))
+				}
+				record[k] = v
+			}
+			return database.ScanResult(database.MetaData{}, database.Result{Document: record}, dest[0])
+		}
+		return bson.Unmarshal(raw, dest[0])
+	}
+	return database.ScanValues(r.current.Values, dest...)
+}
+
+func hasDbTags(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if _, ok := f.Tag.Lookup("db"); ok {
+			return true
+		}
+		if f.Anonymous && hasDbTags(f.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDocument(dest interface{}) bool {
+	if _, ok := dest.(*bson.D); ok {
+		return true
+	}
+	t := reflect.TypeOf(dest)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return false
+	}
+	switch t.Elem().Kind() {
+	case reflect.Map:
+		return true
+	case reflect.Struct:
+		return t.Elem() != reflect.TypeOf(time.Time{})
+	default:
+		return false
+	}
+}
+
+func (r *mongoRows) Current() database.Result {
+	return r.current
+}
+
+func (r *mongoRows) MetaData() database.MetaData {
+	return r.metaData
+}
+
+func (r *mongoRows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.cursor.Err()
+}
+
+func (r *mongoRows) Close() error {
+	return r.cursor.Close(r.ctx)
+}
@@ -0,0 +1,27 @@
+package mongodb
+
+import (
+	"github.com/hellgate75/go-services/database"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// X.509 client certificate authentication mechanism
+const x509Mechanism = "MONGODB-X509"
+
+// Applies the configuration TLS settings to the client options and, with X509Auth, authenticates with the client
+// certificate: the user name is taken from the certificate subject when not provided
+func applyTLS(clientOptions *options.ClientOptions, config database.DbConfig) error {
+	tlsConfig, err := config.ClientTLS()
+	if err != nil || tlsConfig == nil {
+		return err
+	}
+	clientOptions.SetTLSConfig(tlsConfig)
+	if config.TLS.X509Auth {
+		clientOptions.SetAuth(options.Credential{
+			AuthMechanism: x509Mechanism,
+			AuthSource:    "$external",
+			Username:      config.Name,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package mongodb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// Transaction exposing the records operations of the connection bound to the transaction session
+type mongoTx struct {
+	database.TxOperations
+	session mongo.Session
+	ctx     context.Context
+	// Transaction managed by RunInTx, committed or aborted by the session
+	managed bool
+}
+
+func (t *mongoTx) Commit() (err error) {
+	defer opError("Commit", database.DataRef{}, &err)
+	if t.managed {
+		return errors.New(fmt.Sprint("Transaction is managed by RunInTx"))
+	}
+	defer t.session.EndSession(t.ctx)
+	return t.session.CommitTransaction(t.ctx)
+}
+
+func (t *mongoTx) Rollback() (err error) {
+	defer opError("Rollback", database.DataRef{}, &err)
+	if t.managed {
+		return errors.New(fmt.Sprint("Transaction is managed by RunInTx"))
+	}
+	defer t.session.EndSession(t.ctx)
+	return t.session.AbortTransaction(t.ctx)
+}
+
+// Runs the operation within the transaction session, when available
+func (conn *mongoConnection) withSession(ctx context.Context, fn func(ctx context.Context) error) error {
+	if conn.session == nil {
+		return fn(ctx)
+	}
+	return mongo.WithSession(ctx, conn.session, func(sc mongo.SessionContext) error {
+		return fn(sc)
+	})
+}
+
+// Prepares the transaction options, the snapshot isolation level uses the snapshot read concern and the
+// majority write concern, the default level uses the client defaults. Read only transactions and the other
+// isolation levels are not supported.
+func transactionOptions(txOptions database.TxOptions) (*options.TransactionOptions, error) {
+	if txOptions.ReadOnly {
+		return nil, database.WrapError(database.ErrUnsupported, errors.New(fmt.Sprint("Read only transactions are not supported")))
+	}
+	opts := options.Transaction()
+	switch txOptions.Isolation {
+	case sql.LevelDefault:
+	case sql.LevelSnapshot:
+		opts.SetReadConcern(readconcern.Snapshot())
+		opts.SetWriteConcern(writeconcern.New(writeconcern.WMajority()))
+	default:
+		return nil, database.WrapError(database.ErrUnsupported, errors.New(fmt.Sprintf("Isolation level %v is not supported", txOptions.Isolation)))
+	}
+	return opts, nil
+}
+
+func (conn *mongoConnection) startSession() (mongo.Session, error) {
+	if !conn.Valid || conn.Client == nil {
+		return nil, database.ErrClosed
+	}
+	if conn.session != nil {
+		return nil, database.WrapError(database.ErrUnsupported, errors.New(fmt.Sprint("Nested transactions are not supported")))
+	}
+	return conn.Client.StartSession()
+}
+
+func (conn *mongoConnection) sessionConnection(session mongo.Session) *mongoConnection {
+	return &mongoConnection{
+		Configuration: conn.Configuration,
+		Client:        conn.Client,
+		Context:       conn.Context,
+		Valid:         true,
+		session:       session,
+		pool:          conn.pool,
+	}
+}
+
+// Starts a multi-document transaction, available on replica sets and sharded clusters
+func (conn *mongoConnection) Begin(ctx context.Context, txOptions database.TxOptions) (_ database.Tx, err error) {
+	defer opError("Begin", database.DataRef{}, &err)
+	opts, err := transactionOptions(txOptions)
+	if err != nil {
+		return nil, err
+	}
+	session, err := conn.startSession()
+	if err != nil {
+		return nil, err
+	}
+	if err = session.StartTransaction(opts); err != nil {
+		session.EndSession(ctx)
+		return nil, err
+	}
+	return &mongoTx{
+		TxOperations: conn.sessionConnection(session),
+		session:      session,
+		ctx:          ctx,
+	}, nil
+}
+
+// Runs the function with the session WithTransaction, retrying it and the commit on transient transaction errors
+func (conn *mongoConnection) RunInTx(ctx context.Context, txOptions database.TxOptions, fn func(tx database.Tx) error) error {
+	opts, err := transactionOptions(txOptions)
+	if err != nil {
+		return err
+	}
+	session, err := conn.startSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(&mongoTx{
+			TxOperations: conn.sessionConnection(session),
+			session:      session,
+			ctx:          sc,
+			managed:      true,
+		})
+	}, opts)
+	return err
+}
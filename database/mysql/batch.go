@@ -0,0 +1,74 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+	"strings"
+)
+
+// Builds the multi-row INSERT statement and its values, updating the non key fields ON DUPLICATE KEY on upsert
+func prepareInsertBatch(dbRef database.DataRef, fields []database.Field, rows [][]database.Value, options database.BatchOptions) (string, []interface{}) {
+	var cols = make([]string, 0)
+	var updates = make([]string, 0)
+	for _, f := range fields {
+		cols = append(cols, f.Name)
+		if !options.IsKey(f.Name) {
+			updates = append(updates, fmt.Sprintf("%s = VALUES(%s)", f.Name, f.Name))
+		}
+	}
+	tuple := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(fields)), ", ") + ")"
+	var tuples = make([]string, 0)
+	var sqlValues = make([]interface{}, 0)
+	for _, row := range rows {
+		tuples = append(tuples, tuple)
+		for _, val := range row {
+			sqlValues = append(sqlValues, val.Value)
+		}
+	}
+	sqlText := fmt.Sprintf("INSERT INTO %s(%s) VALUES %s", database.TableName(dbRef), strings.Join(cols, ", "), strings.Join(tuples, ", "))
+	if options.Upsert && len(updates) > 0 {
+		sqlText += " ON DUPLICATE KEY UPDATE " + strings.Join(updates, ", ")
+	}
+	return sqlText, sqlValues
+}
+
+func (c *mySqlConnection) InsertBatch(dbRef database.DataRef, fields []database.Field, rows [][]database.Value, options database.BatchOptions) ([]database.BatchResult, error) {
+	return c.InsertBatchContext(context.Background(), dbRef, fields, rows, options)
+}
+
+// Returns the counts of the batch from the affected rows. On upsert MySQL reports 1 affected row for each inserted
+// record, 2 for each updated one and 0 for each unchanged one, or 1 with clientFoundRows, so the inserted and updated
+// records can't be told apart and only the affected rows are reported.
+func batchResult(affected int64, upsert bool) database.BatchResult {
+	if upsert {
+		return database.BatchResult{Affected: affected}
+	}
+	return database.BatchResult{Inserted: affected}
+}
+
+// Inserts the rows with multi-row INSERT statements
+func (c *mySqlConnection) InsertBatchContext(ctx context.Context, dbRef database.DataRef, fields []database.Field, rows [][]database.Value, options database.BatchOptions) (_ []database.BatchResult, err error) {
+	defer opError("InsertBatch", dbRef, &err)
+	var results = make([]database.BatchResult, 0)
+	if c.DB == nil {
+		return results, database.ErrClosed
+	}
+	batches, err := database.Batches(fields, rows, options)
+	if err != nil {
+		return results, err
+	}
+	for _, batch := range batches {
+		sqlText, sqlValues := prepareInsertBatch(dbRef, fields, batch, options)
+		r, err := c.executor().ExecContext(ctx, sqlText, sqlValues...)
+		if err != nil {
+			return results, err
+		}
+		affected, err := r.RowsAffected()
+		if err != nil {
+			return results, err
+		}
+		results = append(results, batchResult(affected, options.Upsert))
+	}
+	return results, nil
+}
@@ -15,77 +15,17 @@ import (
 type mySqlConnection struct {
 	Configuration database.DbConfig
 	DB            *sql.DB
+	tx            *sql.Tx
 	Context       *context.Context
 	Valid         bool
 	Cancel        context.CancelFunc
 	err           error
 }
 
-func prepareCondition(cond database.Condition) string {
-	fld := cond.Field
-	operationSymbol := byte(cond.Operation)
-	not := false
-	if operationSymbol > byte(database.Not) {
-		operationSymbol = operationSymbol - byte(database.Not)
-		not = true
-	}
-	switch operationSymbol {
-	case byte(database.LessThan):
-		if not {
-			return fld + " > ?"
-		} else {
-			return fld + " < ?"
-		}
-	case byte(database.LessThanEquals):
-		if not {
-			return fld + " >= ?"
-		} else {
-			return fld + " <= ?"
-		}
-	case byte(database.GraterThan):
-		if not {
-			return fld + " < ?"
-		} else {
-			return fld + " > ?"
-		}
-	case byte(database.GraterThanEquals):
-		if not {
-			return fld + " <= ?"
-		} else {
-			return fld + " >= ?"
-		}
-	case byte(database.Like):
-		if not {
-			return fld + " NOT LIKE ?"
-		} else {
-			return fld + " LIKE ?"
-		}
-	case byte(database.In):
-		if not {
-			return fld + " NOT IN (?)"
-		} else {
-			return fld + " IN (?)"
-		}
-	case byte(database.Null):
-		if not {
-			return fld + " IS NOT NULL"
-		} else {
-			return fld + " IS NULL"
-		}
-	default:
-		if not {
-			return fld + " <> ?"
-		} else {
-			return fld + " = ?"
-		}
-	}
-
-}
-
 func toMySqlTypeInstance(typeName string) (reflect.Type, interface{}) {
 	switch strings.ToLower(typeName) {
 	case "tinyint":
-		v := byte(0)
+		v := int8(0)
 		return reflect.TypeOf(v), v
 	case "integer", "int", "smallint":
 		v := int(0)
@@ -103,7 +43,7 @@ func toMySqlTypeInstance(typeName string) (reflect.Type, interface{}) {
 		v := float64(0)
 		return reflect.TypeOf(v), v
 	case "real":
-		v := uint64(0)
+		v := float64(0)
 		return reflect.TypeOf(v), v
 	case "char", "varchar", "text":
 		v := ""
@@ -119,22 +59,68 @@ func toMySqlTypeInstance(typeName string) (reflect.Type, interface{}) {
 	}
 }
 
+// MySQL statements dialect
+var dialect = database.SqlDialect{
+	NoLimit: "18446744073709551615",
+	Constraints: func(f database.Field, _ bool) (string, error) {
+		var constraints = " NULL"
+		if f.NotNull || f.PrimaryKey {
+			constraints = " NOT NULL"
+		}
+		if f.AutoIncrement {
+			constraints += " AUTO_INCREMENT"
+		}
+		return constraints, nil
+	},
+}
+
 func (c *mySqlConnection) Query(dbRef database.DataRef, fields []string, conditions []database.Condition, withAnd bool) (database.ResultSet, error) {
-	resultSet := database.ResultSet{
-		Records: make([]database.Result, 0),
-		Lines:   0,
-		MetaData: database.MetaData{
-			EntityRef: dbRef,
-			Columns:   make([]database.Column, 0),
-		},
-	}
+	return c.QueryContext(context.Background(), dbRef, fields, conditions, withAnd)
+}
+
+func (c *mySqlConnection) QueryContext(ctx context.Context, dbRef database.DataRef, fields []string, conditions []database.Condition, withAnd bool) (database.ResultSet, error) {
+	return c.QueryFilter(ctx, dbRef, fields, database.FromConditions(conditions, withAnd))
+}
+
+func (c *mySqlConnection) QueryFilter(ctx context.Context, dbRef database.DataRef, fields []string, filter database.Filter) (database.ResultSet, error) {
+	return c.QueryPage(ctx, dbRef, fields, filter, database.QueryOptions{})
+}
+
+// Executes the query applying the options, reading all the records in the result set
+func (c *mySqlConnection) QueryPage(ctx context.Context, dbRef database.DataRef, fields []string, filter database.Filter, options database.QueryOptions) (_ database.ResultSet, err error) {
+	defer opError("Query", dbRef, &err)
+	rows, err := c.Stream(ctx, dbRef, fields, filter, options)
+	if err != nil {
+		return database.ResultSet{
+			Records: make([]database.Result, 0),
+			Lines:   0,
+			MetaData: database.MetaData{
+				EntityRef: dbRef,
+				Columns:   make([]database.Column, 0),
+			},
+		}, err
+	}
+	return database.Collect(rows)
+}
+
+// Executes the query applying the options, which are ignored for SQL statements queries, the returned rows
+// hold a database connection until closed
+func (c *mySqlConnection) Stream(ctx context.Context, dbRef database.DataRef, fields []string, filter database.Filter, options database.QueryOptions) (_ database.Rows, err error) {
+	defer opError("Stream", dbRef, &err)
 	if c.DB == nil {
-		return resultSet, errors.New(fmt.Sprint("Database is closed,please reconnect before any operation"))
+		return nil, database.ErrClosed
 	}
-	var err error
 	var rows *sql.Rows
+	if err = options.Validate(); err != nil {
+		return nil, err
+	}
+	var after database.Filter
+	if after, err = options.AfterFilter(); err != nil {
+		return nil, err
+	}
+	filter = database.And(filter, after)
 	if dbRef.SQL != "" {
-		rows, err = c.DB.Query(dbRef.SQL)
+		rows, err = c.executor().QueryContext(ctx, dbRef.SQL)
 	} else {
 		var selCols = ""
 		for _, f := range fields {
@@ -143,97 +129,26 @@ func (c *mySqlConnection) Query(dbRef database.DataRef, fields []string, conditi
 			}
 			selCols += f
 		}
-		if selCols != "" {
+		if selCols == "" {
 			selCols = "*"
 		}
-		if len(conditions) == 0 {
-			rows, err = c.DB.Query(fmt.Sprintf("SELECT %s FROM %s", selCols, dbRef.Namespace))
-		} else {
-			var where string
-			var values = make([]interface{}, 0)
-			for _, c := range conditions {
-				if where != "" {
-					if withAnd {
-						where += " AND "
-					} else {
-						where += " OR "
-					}
-				}
-				cond := prepareCondition(c)
-				if byte(c.Operation) != byte(database.Null) &&
-					byte(c.Operation) != byte(database.Not)+byte(database.Null) {
-					values = append(values, c.Value)
-				}
-				where += cond
-			}
-			if where != "" {
-				where = " WHERE " + where
-			}
-			stmt, err := c.DB.Prepare(fmt.Sprintf("SELECT %s FROM %s%s", selCols, dbRef.Namespace, where))
-			if err != nil {
-				return resultSet, err
-			}
-			defer func() {
-				_ = stmt.Close()
-			}()
-			rows, err = stmt.Query(values...)
-		}
+		where, values := dialect.Where(filter, 0)
+		rows, err = c.executor().QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s%s%s", selCols, database.TableName(dbRef), where, dialect.QueryOptions(options)), values...)
 	}
 	if err != nil {
-		return resultSet, err
-	}
-	defer func() {
-		_ = rows.Close()
-	}()
-	cols, _ := rows.Columns()
-	colTypes, _ := rows.ColumnTypes()
-	var values = make([]interface{}, len(cols))
-	var queryArgs = make([]interface{}, len(cols))
-	for i, colName := range cols {
-		cType := colTypes[i]
-		length, _ := cType.Length()
-		precision, scale, _ := cType.DecimalSize()
-		dataType := cType.Name()
-		goType, defValue := toMySqlTypeInstance(cType.Name())
-		values[i] = defValue
-		queryArgs[i] = &values[i]
-		resultSet.MetaData.Columns =
-			append(resultSet.MetaData.Columns,
-				database.Column{
-					Name:      colName,
-					Length:    length,
-					Precision: precision,
-					Scale:     scale,
-					Type:      database.DataType(dataType),
-					GoType:    goType,
-				})
-	}
-	resultSet.Lines = 0
-	for rows.Next() {
-		err = rows.Scan(queryArgs)
-		if err != nil {
-			var resultValues = make([]interface{}, len(values))
-			for i, _ := range values {
-				resultValues[i] = values[i]
-				if reflect.TypeOf(values[i]).Kind().String() == "sql.RawBytes" {
-					resultValues[i] = string(values[i].(sql.RawBytes))
-				}
-			}
-			resultSet.Lines++
-			resultSet.Records = append(
-				resultSet.Records, database.Result{
-					Columns:  int64(len(resultSet.MetaData.Columns)),
-					Document: nil,
-					Values:   resultValues,
-				})
-		}
+		return nil, err
 	}
-	return resultSet, err
+	return database.NewSqlRows(rows, dbRef, toMySqlTypeInstance)
 }
 
 func (c *mySqlConnection) Insert(dbRef database.DataRef, fields []database.Field, values []database.Value) error {
+	return c.InsertContext(context.Background(), dbRef, fields, values)
+}
+
+func (c *mySqlConnection) InsertContext(ctx context.Context, dbRef database.DataRef, fields []database.Field, values []database.Value) (err error) {
+	defer opError("Insert", dbRef, &err)
 	if c.DB == nil {
-		return errors.New(fmt.Sprint("Database is closed,please reconnect before any operation"))
+		return database.ErrClosed
 	}
 	if len(fields) != len(values) {
 		return errors.New(fmt.Sprintf("Columns and values must have same length: %v <> %v", len(fields), len(values)))
@@ -263,8 +178,8 @@ func (c *mySqlConnection) Insert(dbRef database.DataRef, fields []database.Field
 	} else {
 		return errors.New(fmt.Sprintf("Need column fields to create the insert"))
 	}
-	sqlText := fmt.Sprintf("INSERT INTO %s%s%s"+dbRef.Namespace, cols, colValues)
-	prep, err := c.DB.Prepare(sqlText)
+	sqlText := fmt.Sprintf("INSERT INTO %s%s %s", database.TableName(dbRef), cols, colValues)
+	prep, err := c.executor().PrepareContext(ctx, sqlText)
 	if err != nil {
 		return err
 	}
@@ -275,7 +190,7 @@ func (c *mySqlConnection) Insert(dbRef database.DataRef, fields []database.Field
 	for _, val := range values {
 		sqlValues = append(sqlValues, val.Value)
 	}
-	_, err = prep.Exec(sqlValues...)
+	_, err = prep.ExecContext(ctx, sqlValues...)
 	if err != nil {
 		return err
 	}
@@ -283,9 +198,18 @@ func (c *mySqlConnection) Insert(dbRef database.DataRef, fields []database.Field
 }
 
 func (c *mySqlConnection) Update(dbRef database.DataRef, conditions []database.Condition, fields []database.Field, values []database.Value, withAnd bool) (int64, error) {
+	return c.UpdateContext(context.Background(), dbRef, conditions, fields, values, withAnd)
+}
+
+func (c *mySqlConnection) UpdateContext(ctx context.Context, dbRef database.DataRef, conditions []database.Condition, fields []database.Field, values []database.Value, withAnd bool) (int64, error) {
+	return c.UpdateFilter(ctx, dbRef, database.FromConditions(conditions, withAnd), fields, values)
+}
+
+func (c *mySqlConnection) UpdateFilter(ctx context.Context, dbRef database.DataRef, filter database.Filter, fields []database.Field, values []database.Value) (_ int64, err error) {
+	defer opError("Update", dbRef, &err)
 	var records int64
 	if c.DB == nil {
-		return records, errors.New(fmt.Sprint("Database is closed,please reconnect before any operation"))
+		return records, database.ErrClosed
 	}
 	if len(fields) != len(values) {
 		return records, errors.New(fmt.Sprintf("Columns and values must have same length: %v <> %v", len(fields), len(values)))
@@ -305,28 +229,9 @@ func (c *mySqlConnection) Update(dbRef database.DataRef, conditions []database.C
 	} else {
 		return records, errors.New(fmt.Sprintf("Need column fields to create the insert"))
 	}
-	var where string
-	var whereValues = make([]interface{}, 0)
-	for _, c := range conditions {
-		if where != "" {
-			if withAnd {
-				where += " AND "
-			} else {
-				where += " OR "
-			}
-		}
-		cond := prepareCondition(c)
-		if byte(c.Operation) != byte(database.Null) &&
-			byte(c.Operation) != byte(database.Not)+byte(database.Null) {
-			whereValues = append(whereValues, c.Value)
-		}
-		where += cond
-	}
-	if where != "" {
-		where = " WHERE " + where
-	}
-	sqlText := fmt.Sprintf("UPDATE %s%s%s"+dbRef.Namespace, cols, where)
-	prep, err := c.DB.Prepare(sqlText)
+	where, whereValues := dialect.Where(filter, 0)
+	sqlText := fmt.Sprintf("UPDATE %s%s%s", database.TableName(dbRef), cols, where)
+	prep, err := c.executor().PrepareContext(ctx, sqlText)
 	if err != nil {
 		return records, err
 	}
@@ -338,7 +243,7 @@ func (c *mySqlConnection) Update(dbRef database.DataRef, conditions []database.C
 		sqlValues = append(sqlValues, val.Value)
 	}
 	sqlValues = append(sqlValues, whereValues...)
-	r, err := prep.Exec(sqlValues...)
+	r, err := prep.ExecContext(ctx, sqlValues...)
 	if err != nil {
 		return records, err
 	}
@@ -347,41 +252,29 @@ func (c *mySqlConnection) Update(dbRef database.DataRef, conditions []database.C
 }
 
 func (c *mySqlConnection) Delete(dbRef database.DataRef, conditions []database.Condition, withAnd bool) (int64, error) {
+	return c.DeleteContext(context.Background(), dbRef, conditions, withAnd)
+}
+
+func (c *mySqlConnection) DeleteContext(ctx context.Context, dbRef database.DataRef, conditions []database.Condition, withAnd bool) (int64, error) {
+	return c.DeleteFilter(ctx, dbRef, database.FromConditions(conditions, withAnd))
+}
+
+func (c *mySqlConnection) DeleteFilter(ctx context.Context, dbRef database.DataRef, filter database.Filter) (_ int64, err error) {
+	defer opError("Delete", dbRef, &err)
 	var records int64
 	if c.DB == nil {
-		return records, errors.New(fmt.Sprint("Database is closed,please reconnect before any operation"))
-	}
-	var where string
-	var whereValues = make([]interface{}, 0)
-	for _, c := range conditions {
-		if where != "" {
-			if withAnd {
-				where += " AND "
-			} else {
-				where += " OR "
-			}
-		}
-		cond := prepareCondition(c)
-		if byte(c.Operation) != byte(database.Null) &&
-			byte(c.Operation) != byte(database.Not)+byte(database.Null) {
-			whereValues = append(whereValues, c.Value)
-		}
-		where += cond
-	}
-	if where != "" {
-		where = " WHERE " + where
+		return records, database.ErrClosed
 	}
-	sqlText := fmt.Sprintf("DELETE FROM %s%s"+dbRef.Namespace, where)
-	prep, err := c.DB.Prepare(sqlText)
+	where, whereValues := dialect.Where(filter, 0)
+	sqlText := fmt.Sprintf("DELETE FROM %s%s", database.TableName(dbRef), where)
+	prep, err := c.executor().PrepareContext(ctx, sqlText)
 	if err != nil {
 		return records, err
 	}
 	defer func() {
 		_ = prep.Close()
 	}()
-	var sqlValues = make([]interface{}, 0)
-	sqlValues = append(sqlValues, whereValues...)
-	r, err := prep.Exec(sqlValues...)
+	r, err := prep.ExecContext(ctx, whereValues...)
 	if err != nil {
 		return records, err
 	}
@@ -389,11 +282,11 @@ func (c *mySqlConnection) Delete(dbRef database.DataRef, conditions []database.C
 	return records, err
 }
 
-func (c *mySqlConnection) dropTable(name string) (int64, error) {
+func (c *mySqlConnection) dropTable(ctx context.Context, name string) (int64, error) {
 	if c.DB == nil {
-		return 0, errors.New(fmt.Sprint("Database is closed,please reconnect before any operation"))
+		return 0, database.ErrClosed
 	}
-	_, err := c.DB.Query(fmt.Sprintf("DROP TABLE %s CASCADE", name))
+	_, err := c.executor().ExecContext(ctx, fmt.Sprintf("DROP TABLE %s CASCADE", name))
 	if err != nil {
 		return 0, err
 	}
@@ -401,11 +294,11 @@ func (c *mySqlConnection) dropTable(name string) (int64, error) {
 
 }
 
-func (c *mySqlConnection) truncateTable(name string) (int64, error) {
+func (c *mySqlConnection) truncateTable(ctx context.Context, name string) (int64, error) {
 	if c.DB == nil {
-		return 0, errors.New(fmt.Sprint("Database is closed,please reconnect before any operation"))
+		return 0, database.ErrClosed
 	}
-	_, err := c.DB.Query(fmt.Sprintf("TRUNCATE TABLE %s", name))
+	_, err := c.executor().ExecContext(ctx, fmt.Sprintf("TRUNCATE TABLE %s", name))
 	if err != nil {
 		return 0, err
 	}
@@ -413,29 +306,55 @@ func (c *mySqlConnection) truncateTable(name string) (int64, error) {
 
 }
 
+// Counts the matching records, or the records of the SQL statement query
+func (c *mySqlConnection) Count(ctx context.Context, dbRef database.DataRef, filter database.Filter) (_ int64, err error) {
+	defer opError("Count", dbRef, &err)
+	if c.DB == nil {
+		return 0, database.ErrClosed
+	}
+	var count int64
+	if dbRef.SQL != "" {
+		err = c.executor().QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM (%s) AS counted", dbRef.SQL)).Scan(&count)
+		return count, err
+	}
+	where, values := dialect.Where(filter, 0)
+	err = c.executor().QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s%s", database.TableName(dbRef), where), values...).Scan(&count)
+	return count, err
+}
+
 func (c *mySqlConnection) Purge(dbRef database.DataRef) (int64, error) {
-	var err error
+	return c.PurgeContext(context.Background(), dbRef)
+}
+
+func (c *mySqlConnection) PurgeContext(ctx context.Context, dbRef database.DataRef) (_ int64, err error) {
+	defer opError("Purge", dbRef, &err)
 	var count int64
 	if c.DB == nil {
-		return count, errors.New(fmt.Sprint("Database is closed,please reconnect before any operation"))
+		return count, database.ErrClosed
 	}
 	if dbRef.Namespace != "" {
-		return c.truncateTable(dbRef.Namespace)
+		return c.truncateTable(ctx, database.TableName(dbRef))
 	} else if dbRef.Database != "" {
-		rows, err := c.DB.Query(fmt.Sprint("show tables"))
+		rows, err := c.DB.QueryContext(ctx, fmt.Sprint("show tables"))
 		if err != nil {
 			return count, err
 		}
+		var tables = make([]string, 0)
 		var tableName string
-		var scanArgs = make([]interface{}, 1)
-		scanArgs[0] = &tableName
 		for rows.Next() {
-			err = rows.Scan(scanArgs)
-			if err == nil {
-				fmt.Println("Truncating table:", tableName)
-				var n int64
-				n, err = c.truncateTable(tableName)
-				count += n
+			if err = rows.Scan(&tableName); err != nil {
+				_ = rows.Close()
+				return count, err
+			}
+			tables = append(tables, tableName)
+		}
+		_ = rows.Close()
+		for _, tableName := range tables {
+			var n int64
+			n, err = c.truncateTable(ctx, tableName)
+			count += n
+			if err != nil {
+				return count, err
 			}
 		}
 	} else {
@@ -444,55 +363,75 @@ func (c *mySqlConnection) Purge(dbRef database.DataRef) (int64, error) {
 	if err != nil {
 		return count, err
 	}
-	return 1, nil
+	return count, nil
 }
 
 func (c *mySqlConnection) Create(dbRef database.DataRef, fields []database.Field) error {
+	return c.CreateContext(context.Background(), dbRef, fields)
+}
+
+func (c *mySqlConnection) CreateContext(ctx context.Context, dbRef database.DataRef, fields []database.Field) (err error) {
+	defer opError("Create", dbRef, &err)
 	if c.DB == nil {
-		return errors.New(fmt.Sprint("Database is closed,please reconnect before any operation"))
+		return database.ErrClosed
 	}
-	var err error
 	if dbRef.Namespace != "" {
 		//Create table
-		//TODO: Implement MySql create table task
-		return errors.New(fmt.Sprint("Create table not implemented yet"))
+		var sqlText string
+		sqlText, err = dialect.CreateTable(dbRef, fields)
+		if err != nil {
+			return err
+		}
+		_, err = c.DB.ExecContext(ctx, sqlText)
 	} else if dbRef.FieldSetRef != "" {
 		//Create table
-		_, err = c.DB.Query(fmt.Sprintf("CREATE TABLESPACE %s", dbRef.Schema))
+		_, err = c.DB.ExecContext(ctx, fmt.Sprintf("CREATE TABLESPACE %s", dbRef.FieldSetRef))
 	} else if dbRef.Database != "" {
-		err = c.CreateDb(dbRef)
+		err = c.CreateDbContext(ctx, dbRef)
 	} else if dbRef.Schema != "" {
-		err = c.CreateDb(dbRef)
+		err = c.CreateDbContext(ctx, dbRef)
 	}
 	return err
 }
 
 func (c *mySqlConnection) CreateDb(dbRef database.DataRef) error {
-	var err error
+	return c.CreateDbContext(context.Background(), dbRef)
+}
+
+func (c *mySqlConnection) CreateDbContext(ctx context.Context, dbRef database.DataRef) (err error) {
+	defer opError("CreateDb", dbRef, &err)
 	if c.DB == nil {
-		return errors.New(fmt.Sprint("Database is closed,please reconnect before any operation"))
+		return database.ErrClosed
+	}
+	var ifNotExists string
+	if dbRef.IfNotExists {
+		ifNotExists = "IF NOT EXISTS "
 	}
 	if dbRef.Database != "" {
-		_, err = c.DB.Query(fmt.Sprintf("CREATE DATABASE %s", dbRef.Database))
+		_, err = c.DB.ExecContext(ctx, fmt.Sprintf("CREATE DATABASE %s%s", ifNotExists, dbRef.Database))
 	} else if dbRef.Schema != "" {
-		_, err = c.DB.Query(fmt.Sprintf("CREATE SCHEMA %s", dbRef.Schema))
+		_, err = c.DB.ExecContext(ctx, fmt.Sprintf("CREATE SCHEMA %s%s", ifNotExists, dbRef.Schema))
 	}
 	return err
 }
 
 func (c *mySqlConnection) Drop(dbRef database.DataRef) error {
-	var err error
+	return c.DropContext(context.Background(), dbRef)
+}
+
+func (c *mySqlConnection) DropContext(ctx context.Context, dbRef database.DataRef) (err error) {
+	defer opError("Drop", dbRef, &err)
 	if c.DB == nil {
-		return errors.New(fmt.Sprint("Database is closed,please reconnect before any operation"))
+		return database.ErrClosed
 	}
 	if dbRef.Namespace != "" {
-		_, err = c.dropTable(dbRef.Namespace)
+		_, err = c.dropTable(ctx, database.TableName(dbRef))
 	} else if dbRef.Database != "" {
-		return c.DropDb(dbRef)
+		return c.DropDbContext(ctx, dbRef)
 	} else if dbRef.FieldSetRef != "" {
-		_, err = c.DB.Query(fmt.Sprintf("DROP TABLESPACE %s", dbRef.Namespace))
+		_, err = c.DB.ExecContext(ctx, fmt.Sprintf("DROP TABLESPACE %s", dbRef.FieldSetRef))
 	} else if dbRef.Schema != "" {
-		_, err = c.DB.Query(fmt.Sprintf("DROP SCHEMA %s", dbRef.Schema))
+		_, err = c.DB.ExecContext(ctx, fmt.Sprintf("DROP SCHEMA %s", dbRef.Schema))
 	} else {
 		return errors.New(fmt.Sprint("Please choose drop entity between Namespace for Table, FieldSet for Tablespace and Database for all Tables"))
 	}
@@ -500,21 +439,26 @@ func (c *mySqlConnection) Drop(dbRef database.DataRef) error {
 }
 
 func (c *mySqlConnection) DropDb(dbRef database.DataRef) error {
+	return c.DropDbContext(context.Background(), dbRef)
+}
+
+func (c *mySqlConnection) DropDbContext(ctx context.Context, dbRef database.DataRef) (err error) {
+	defer opError("DropDb", dbRef, &err)
 	if c.DB == nil {
-		return errors.New(fmt.Sprint("Database is closed,please reconnect before any operation"))
+		return database.ErrClosed
 	}
-	_, err := c.DB.Query(fmt.Sprintf("DROP DATABASE %s", dbRef.Database))
+	_, err = c.DB.ExecContext(ctx, fmt.Sprintf("DROP DATABASE %s", dbRef.Database))
 	return err
 }
 
-func (c *mySqlConnection) Close() error {
+func (c *mySqlConnection) Close() (err error) {
+	defer opError("Close", database.DataRef{}, &err)
 	if !c.IsConnected() {
-		return errors.New(fmt.Sprint("Database connection is already closed"))
+		return database.ErrClosed
 	}
 	if c.DB == nil {
-		return errors.New(fmt.Sprint("Database is closed,please reconnect before any operation"))
+		return database.ErrClosed
 	}
-	var err error
 	defer func() {
 		if r := recover(); r != nil {
 			err = errors.New(fmt.Sprintf("%v", r))
@@ -525,6 +469,51 @@ func (c *mySqlConnection) Close() error {
 	return err
 }
 
+// Executes the script statements in order, stopping at the first failing one
+func (c *mySqlConnection) ExecScript(ctx context.Context, dbRef database.DataRef, script string) (err error) {
+	defer opError("ExecScript", dbRef, &err)
+	if c.DB == nil {
+		return database.ErrClosed
+	}
+	for i, statement := range database.SplitStatements(script) {
+		if _, err := c.executor().ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("Statement %v failed: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (c *mySqlConnection) Ping(ctx context.Context) (err error) {
+	defer opError("Ping", database.DataRef{}, &err)
+	if c.DB == nil {
+		return database.ErrClosed
+	}
+	return c.DB.PingContext(ctx)
+}
+
+func (c *mySqlConnection) ServerVersion(ctx context.Context) (_ string, err error) {
+	defer opError("ServerVersion", database.DataRef{}, &err)
+	var version string
+	if c.DB == nil {
+		return version, database.ErrClosed
+	}
+	err = c.DB.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version)
+	return version, err
+}
+
+// Returns the connection configuration, with the Driver name
+func (c *mySqlConnection) Config() database.DbConfig {
+	config := c.Configuration
+	if config.Driver == "" {
+		config.Driver = "mysql"
+	}
+	return config
+}
+
+func (c *mySqlConnection) Stats() database.PoolStats {
+	return database.SqlPoolStats(c.DB)
+}
+
 func (c *mySqlConnection) IsConnected() bool {
 	return c.DB != nil
 }
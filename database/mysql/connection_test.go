@@ -0,0 +1,178 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	"github.com/hellgate75/go-services/database"
+	"strings"
+	"testing"
+)
+
+func TestPrepareCreateTable(t *testing.T) {
+	dbRef := database.DataRef{
+		Namespace:   "users",
+		Schema:      "test",
+		IfNotExists: true,
+	}
+	sqlText, err := dialect.CreateTable(dbRef, []database.Field{
+		{Name: "id", Type: "BIGINT", PrimaryKey: true, AutoIncrement: true},
+		{Name: "email", Type: "VARCHAR", Size: 255, NotNull: true, Unique: true},
+		{Name: "balance", Type: "DECIMAL", Size: 10, Precision: 2, Default: "0"},
+		{Name: "status", Type: "VARCHAR", Size: 10, Default: "'active'"},
+	})
+	if err != nil {
+		t.Fatalf("Create table statement error occured: %v", err)
+	}
+	expected := "CREATE TABLE IF NOT EXISTS test.users (id BIGINT NOT NULL AUTO_INCREMENT, " +
+		"email VARCHAR(255) NOT NULL UNIQUE, balance DECIMAL(10,2) NULL DEFAULT 0, " +
+		"status VARCHAR(10) NULL DEFAULT 'active', PRIMARY KEY (id))"
+	if sqlText != expected {
+		t.Fatalf("Wrong create table statement, expected: <%s> but was: <%s>", expected, sqlText)
+	}
+	_, err = dialect.CreateTable(dbRef, []database.Field{})
+	if err == nil {
+		t.Fatal("Create table statement without columns should fail")
+	}
+	_, err = dialect.CreateTable(dbRef, []database.Field{{Name: "id"}})
+	if err == nil {
+		t.Fatal("Create table statement with untyped columns should fail")
+	}
+}
+
+func TestPrepareWhere(t *testing.T) {
+	filter := database.Or(
+		database.And(
+			database.Leaf(database.Condition{Field: "a", Operation: database.Equals, Value: database.Value{Value: 1}}),
+			database.Leaf(database.Condition{Field: "b", Operation: database.GraterThan, Value: database.Value{Value: 2}}),
+		),
+		database.Leaf(database.Condition{Field: "c", Operation: database.Null}),
+		database.Negate(database.Leaf(database.Condition{Field: "d", Operation: database.In, Value: database.Value{Value: []string{"x", "y"}}})),
+	)
+	where, values := dialect.Where(filter, 0)
+	expected := " WHERE (a = ? AND b > ?) OR c IS NULL OR NOT (d IN (?, ?))"
+	if where != expected {
+		t.Fatalf("Wrong where clause, expected: <%s> but was: <%s>", expected, where)
+	}
+	if len(values) != 4 {
+		t.Fatalf("Wrong number of values, expected: 4 but was: %v", len(values))
+	}
+	where, values = dialect.Where(database.Or(
+		database.Leaf(database.Condition{Field: "e", Operation: database.In, Value: database.Value{Value: []int{}}}),
+		database.Negate(database.Leaf(database.Condition{Field: "f", Operation: database.In, Value: database.Value{Value: []int{}}})),
+		database.Leaf(database.Condition{Field: "g", Operation: database.Not + database.In, Value: database.Value{Value: []int{}}}),
+	), 0)
+	expected = " WHERE 1=0 OR NOT (1=0) OR 1=1"
+	if where != expected || len(values) != 0 {
+		t.Fatalf("Wrong empty list where clause, expected: <%s> but was: <%s>", expected, where)
+	}
+	where, values = dialect.Where(database.And(), 0)
+	if where != "" || len(values) != 0 {
+		t.Fatalf("Empty filter should not restrict records: <%s>", where)
+	}
+}
+
+func TestPrepareOptions(t *testing.T) {
+	clause := dialect.QueryOptions(database.QueryOptions{
+		OrderBy: []database.OrderBy{{Field: "surname"}, {Field: "age", Direction: database.Descending}},
+		Limit:   10,
+		Offset:  20,
+	})
+	expected := " ORDER BY surname ASC, age DESC LIMIT 10 OFFSET 20"
+	if clause != expected {
+		t.Fatalf("Wrong options clause, expected: <%s> but was: <%s>", expected, clause)
+	}
+	clause = dialect.QueryOptions(database.QueryOptions{Offset: 5})
+	expected = " LIMIT 18446744073709551615 OFFSET 5"
+	if clause != expected {
+		t.Fatalf("Wrong options clause, expected: <%s> but was: <%s>", expected, clause)
+	}
+}
+
+func TestPrepareInsertBatch(t *testing.T) {
+	fields := []database.Field{{Name: "code"}, {Name: "name"}}
+	rows := [][]database.Value{{{Value: "1"}, {Value: "Mario"}}, {{Value: "2"}, {Value: "Luigi"}}}
+	sqlText, values := prepareInsertBatch(database.DataRef{Namespace: "sample"}, fields, rows, database.BatchOptions{})
+	expected := "INSERT INTO sample(code, name) VALUES (?, ?), (?, ?)"
+	if sqlText != expected {
+		t.Fatalf("Wrong insert statement, expected: <%s> but was: <%s>", expected, sqlText)
+	}
+	if len(values) != 4 || values[2] != "2" {
+		t.Fatalf("Wrong insert values: %v", values)
+	}
+	sqlText, _ = prepareInsertBatch(database.DataRef{Namespace: "sample"}, fields, rows[:1], database.BatchOptions{Upsert: true, KeyFields: []string{"code"}})
+	expected = "INSERT INTO sample(code, name) VALUES (?, ?) ON DUPLICATE KEY UPDATE name = VALUES(name)"
+	if sqlText != expected {
+		t.Fatalf("Wrong upsert statement, expected: <%s> but was: <%s>", expected, sqlText)
+	}
+}
+
+func TestBatchResult(t *testing.T) {
+	// 3 records, 1 inserted and 1 updated, 1 unchanged
+	if result := batchResult(3, true); result.Affected != 3 || result.Inserted != 0 || result.Updated != 0 {
+		t.Fatalf("Wrong upsert counts: %+v", result)
+	}
+	if result := batchResult(2, false); result.Inserted != 2 || result.Updated != 0 || result.Affected != 0 {
+		t.Fatalf("Wrong insert counts: %+v", result)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	conn := &mySqlConnection{}
+	if !conn.IsTransient(&mysql.MySQLError{Number: 1213, Message: "Deadlock found"}) || !conn.IsTransient(mysql.ErrInvalidConn) {
+		t.Fatal("Deadlocks and invalid connections should be transient")
+	}
+	if conn.IsTransient(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}) || conn.IsTransient(context.Canceled) {
+		t.Fatal("Duplicate keys and canceled contexts should not be transient")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	var tests = map[uint16]error{
+		1062: database.ErrDuplicateKey,
+		1452: database.ErrConstraint,
+		1205: database.ErrTimeout,
+		1146: database.ErrNotFound,
+	}
+	for number, kind := range tests {
+		cause := &mysql.MySQLError{Number: number, Message: "server error"}
+		err := classify(cause)
+		var mysqlErr *mysql.MySQLError
+		if !errors.Is(err, kind) || !errors.As(err, &mysqlErr) || mysqlErr.Number != number {
+			t.Fatalf("Wrong error %v classification: %v", number, err)
+		}
+	}
+	var err error = &mysql.MySQLError{Number: 1064, Message: "syntax error"}
+	if classify(err) != err {
+		t.Fatal("Syntax errors should not be classified")
+	}
+	if err = (&mySqlConnection{}).Ping(context.Background()); !errors.Is(err, database.ErrClosed) {
+		t.Fatalf("Expected closed connection error: %v", err)
+	}
+}
+
+func TestWithTLS(t *testing.T) {
+	dsn, err := withTLS("root:secret@tcp(localhost:3306)/app", database.DbConfig{})
+	if err != nil || dsn != "root:secret@tcp(localhost:3306)/app" {
+		t.Fatalf("TLS should be disabled: %v %v", dsn, err)
+	}
+	config := database.DbConfig{TLS: database.TLSConfig{InsecureSkipVerify: true, MinVersion: "1.3"}}
+	dsn, err = withTLS("root:secret@tcp(localhost:3306)/app?parseTime=true", config)
+	if err != nil {
+		t.Fatalf("TLS registration error occured: %v", err)
+	}
+	parsed, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("Data source name error occured: %v", err)
+	}
+	if !strings.HasPrefix(parsed.TLSConfig, "go-services-") || !parsed.ParseTime {
+		t.Fatalf("TLS configuration not selected: %v", dsn)
+	}
+	if other, _ := withTLS("root:secret@tcp(localhost:3306)", config); other != "root:secret@tcp(localhost:3306)/?tls="+parsed.TLSConfig {
+		t.Fatalf("Same settings should share the registration: %v", other)
+	}
+	config.TLS.CAFile = "missing.pem"
+	if _, err = withTLS("root@/app", config); err == nil {
+		t.Fatal("Expected CA file error")
+	}
+}
@@ -1,16 +1,40 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/hellgate75/go-services/database"
 )
 
+func init() {
+	if err := database.RegisterType("mysql", database.MySQLDriver, nil, GetMySqlDriver); err != nil {
+		panic(err)
+	}
+}
+
 type mySQLDriver struct {
 }
 
+// Connects resolving the configuration secrets, with the credentials masked in the returned errors. The connection
+// keeps the configuration with the secret references, resolved again by each new connection.
 func (d *mySQLDriver) Connect(config database.DbConfig) (database.Connection, error) {
+	resolved, err := database.ResolveSecrets(context.Background(), config)
+	if err != nil {
+		return nil, err
+	}
+	return d.ConnectResolved(resolved, config)
+}
+
+// Connects with the configuration secrets already resolved, with the credentials masked in the returned errors
+func (d *mySQLDriver) ConnectResolved(resolved database.DbConfig, config database.DbConfig) (database.Connection, error) {
+	conn, err := d.connect(resolved, config)
+	return conn, resolved.RedactError(err)
+}
+
+// Connects with the resolved configuration, keeping the unresolved one as the connection configuration
+func (d *mySQLDriver) connect(config database.DbConfig, unresolved database.DbConfig) (database.Connection, error) {
 	connStr := config.Url
 	//db, err := sql.Open("mysql", "<username>:<pw>@tcp(<HOST>:<port>)/<dbname>")
 	if connStr == "" {
@@ -28,12 +52,21 @@ func (d *mySQLDriver) Connect(config database.DbConfig) (database.Connection, er
 			}
 		}
 	}
+	connStr, err := withTLS(connStr, config)
+	if err != nil {
+		return nil, err
+	}
 	db, err := sql.Open("mysql", connStr)
 	if err != nil {
 		return nil, err
 	}
+	config.Pool.Apply(db)
+	if err = db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
 	return &mySqlConnection{
-		Configuration: config,
+		Configuration: unresolved,
 		DB:            db,
 	}, nil
 }
@@ -0,0 +1,58 @@
+package mysql
+
+import (
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	"github.com/hellgate75/go-services/database"
+)
+
+// MySQL server error numbers of transient failures
+var transientErrors = map[uint16]bool{
+	1040: true, // Too many connections
+	1053: true, // Server shutdown in progress
+	1205: true, // Lock wait timeout exceeded
+	1213: true, // Deadlock found when trying to get lock
+	2006: true, // MySQL server has gone away
+	2013: true, // Lost connection to MySQL server during query
+}
+
+// MySQL server error numbers by error kind
+var errorKinds = map[uint16]error{
+	1022: database.ErrDuplicateKey, // Can't write; duplicate key in table
+	1062: database.ErrDuplicateKey, // Duplicate entry for key
+	1586: database.ErrDuplicateKey, // Duplicate entry for key name
+	1048: database.ErrConstraint,   // Column cannot be null
+	1216: database.ErrConstraint,   // Cannot add or update a child row
+	1217: database.ErrConstraint,   // Cannot delete or update a parent row
+	1451: database.ErrConstraint,   // Cannot delete or update a parent row: a foreign key constraint fails
+	1452: database.ErrConstraint,   // Cannot add or update a child row: a foreign key constraint fails
+	3819: database.ErrConstraint,   // Check constraint is violated
+	1205: database.ErrTimeout,      // Lock wait timeout exceeded
+	3024: database.ErrTimeout,      // Maximum statement execution time exceeded
+	1049: database.ErrNotFound,     // Unknown database
+	1051: database.ErrNotFound,     // Unknown table
+	1146: database.ErrNotFound,     // Table doesn't exist
+}
+
+// Classifies the MySQL server errors by error number
+func classify(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return database.WrapError(errorKinds[mysqlErr.Number], err)
+	}
+	return err
+}
+
+// Wraps the operation error in a database.OpError, classifying the MySQL server errors
+func opError(op string, dbRef database.DataRef, err *error) {
+	*err = database.NewOpError(op, "mysql", dbRef, classify(*err))
+}
+
+// Verifies the error is a broken connection, a server shutdown, a lock timeout or a deadlock
+func (c *mySqlConnection) IsTransient(err error) bool {
+	if errors.Is(err, mysql.ErrInvalidConn) || database.IsNetworkError(err) {
+		return true
+	}
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && transientErrors[mysqlErr.Number]
+}
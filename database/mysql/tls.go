@@ -0,0 +1,32 @@
+package mysql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/hellgate75/go-services/database"
+	"strings"
+)
+
+// Registers the configuration TLS settings with the MySQL driver and selects them in the data source name. The
+// registration key is derived from the settings, so connections sharing them share the registration.
+func withTLS(dsn string, config database.DbConfig) (string, error) {
+	tlsConfig, err := config.ClientTLS()
+	if err != nil || tlsConfig == nil {
+		return dsn, err
+	}
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%+v", config.TLSSettings())))
+	key := "go-services-" + hex.EncodeToString(hash[:8])
+	if err = mysql.RegisterTLSConfig(key, tlsConfig); err != nil {
+		return dsn, err
+	}
+	if !strings.Contains(dsn, "/") {
+		// The data source name requires the slash before the database name, even when empty
+		dsn += "/"
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&tls=" + key, nil
+	}
+	return dsn + "?tls=" + key, nil
+}
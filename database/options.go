@@ -0,0 +1,234 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// SortDirection enumeration type
+type SortDirection byte
+
+const (
+	// Ascending order SortDirection enumeration type
+	Ascending SortDirection = iota + 1
+	// Descending order SortDirection enumeration type
+	Descending
+)
+
+// Sort field descriptor structure
+type OrderBy struct {
+	// Sort field name
+	Field string
+	// Sort direction, Ascending when not provided
+	Direction SortDirection
+}
+
+// Query options descriptor structure
+type QueryOptions struct {
+	// Sort fields, in order of priority
+	OrderBy []OrderBy
+	// Maximum number of returned records, no limit when zero
+	Limit int64
+	// Number of records skipped before the first returned one
+	Offset int64
+	// Keyset cursor token, returned by NextCursor for the last record of the previous page: only records
+	// following it in the OrderBy sort order are returned
+	After string
+}
+
+// Verifies the sort direction is Descending
+func (o OrderBy) IsDescending() bool {
+	return o.Direction == Descending
+}
+
+// Verifies the query options are consistent
+func (o QueryOptions) Validate() error {
+	if o.Limit < 0 {
+		return errors.New(fmt.Sprintf("Invalid query limit: %v", o.Limit))
+	}
+	if o.Offset < 0 {
+		return errors.New(fmt.Sprintf("Invalid query offset: %v", o.Offset))
+	}
+	for _, order := range o.OrderBy {
+		if order.Field == "" {
+			return errors.New(fmt.Sprint("Order by field name is required"))
+		}
+		if order.Direction != 0 && order.Direction != Ascending && order.Direction != Descending {
+			return errors.New(fmt.Sprintf("Invalid sort direction for field %s: %v", order.Field, order.Direction))
+		}
+	}
+	if o.After != "" && len(o.OrderBy) == 0 {
+		return errors.New(fmt.Sprint("Keyset cursor requires order by fields"))
+	}
+	return nil
+}
+
+// Returns the Filter selecting the records following the keyset cursor in the OrderBy sort order, for
+// fields a and b: a > ? OR (a = ? AND b > ?). The Filter is empty when no cursor is provided.
+func (o QueryOptions) AfterFilter() (Filter, error) {
+	if o.After == "" {
+		return And(), nil
+	}
+	values, err := DecodeCursor(o.After)
+	if err != nil {
+		return And(), err
+	}
+	if len(values) != len(o.OrderBy) {
+		return And(), errors.New(fmt.Sprintf("Keyset cursor values and order by fields must have same length: %v <> %v", len(values), len(o.OrderBy)))
+	}
+	var filters = make([]Filter, 0)
+	for i, order := range o.OrderBy {
+		var group = make([]Filter, 0)
+		for j := 0; j < i; j++ {
+			group = append(group, Leaf(Condition{
+				Field:     o.OrderBy[j].Field,
+				Operation: Equals,
+				Value:     Value{Value: values[j]},
+			}))
+		}
+		operation := GraterThan
+		if order.IsDescending() {
+			operation = LessThan
+		}
+		group = append(group, Leaf(Condition{
+			Field:     order.Field,
+			Operation: operation,
+			Value:     Value{Value: values[i]},
+		}))
+		filters = append(filters, And(group...))
+	}
+	return Or(filters...), nil
+}
+
+type cursorValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v,omitempty"`
+}
+
+// Creates a keyset cursor token from the given values, supported types are numbers, strings, booleans,
+// byte slices, time.Time and nil
+func EncodeCursor(values ...interface{}) (string, error) {
+	var items = make([]cursorValue, 0)
+	for _, value := range values {
+		var item cursorValue
+		if value == nil {
+			item.Type = "n"
+			items = append(items, item)
+			continue
+		}
+		if t, ok := value.(time.Time); ok {
+			item.Type = "t"
+			item.Value = t.Format(time.RFC3339Nano)
+			items = append(items, item)
+			continue
+		}
+		v := reflect.ValueOf(value)
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			item.Type = "i"
+			item.Value = strconv.FormatInt(v.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			item.Type = "u"
+			item.Value = strconv.FormatUint(v.Uint(), 10)
+		case reflect.Float32, reflect.Float64:
+			item.Type = "f"
+			item.Value = strconv.FormatFloat(v.Float(), 'g', -1, 64)
+		case reflect.Bool:
+			item.Type = "b"
+			item.Value = strconv.FormatBool(v.Bool())
+		case reflect.String:
+			item.Type = "s"
+			item.Value = v.String()
+		case reflect.Slice:
+			if raw, ok := value.([]byte); ok {
+				item.Type = "s"
+				item.Value = string(raw)
+				break
+			}
+			return "", errors.New(fmt.Sprintf("Unsupported keyset cursor value type: %T", value))
+		default:
+			return "", errors.New(fmt.Sprintf("Unsupported keyset cursor value type: %T", value))
+		}
+		items = append(items, item)
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// Decodes the values of a keyset cursor token created with EncodeCursor
+func DecodeCursor(token string) ([]interface{}, error) {
+	var values = make([]interface{}, 0)
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return values, errors.New(fmt.Sprintf("Invalid keyset cursor: %v", err))
+	}
+	var items = make([]cursorValue, 0)
+	if err = json.Unmarshal(data, &items); err != nil {
+		return values, errors.New(fmt.Sprintf("Invalid keyset cursor: %v", err))
+	}
+	for _, item := range items {
+		var value interface{}
+		switch item.Type {
+		case "n":
+			value = nil
+		case "t":
+			value, err = time.Parse(time.RFC3339Nano, item.Value)
+		case "i":
+			value, err = strconv.ParseInt(item.Value, 10, 64)
+		case "u":
+			value, err = strconv.ParseUint(item.Value, 10, 64)
+		case "f":
+			value, err = strconv.ParseFloat(item.Value, 64)
+		case "b":
+			value, err = strconv.ParseBool(item.Value)
+		case "s":
+			value = item.Value
+		default:
+			err = errors.New(fmt.Sprintf("unknown value type %s", item.Type))
+		}
+		if err != nil {
+			return make([]interface{}, 0), errors.New(fmt.Sprintf("Invalid keyset cursor: %v", err))
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// Creates the keyset cursor token of the last result set record, reading the OrderBy fields from the result
+// columns or from the map record document. The token is empty when the result set has no records.
+func NextCursor(rs ResultSet, options QueryOptions) (string, error) {
+	if len(rs.Records) == 0 {
+		return "", nil
+	}
+	record := rs.Records[len(rs.Records)-1]
+	var values = make([]interface{}, 0)
+	for _, order := range options.OrderBy {
+		value, ok := fieldValue(rs.MetaData, record, order.Field)
+		if !ok {
+			return "", errors.New(fmt.Sprintf("Order by field %s not available in the result set", order.Field))
+		}
+		values = append(values, value)
+	}
+	return EncodeCursor(values...)
+}
+
+func fieldValue(metaData MetaData, record Result, field string) (interface{}, bool) {
+	for i, col := range metaData.Columns {
+		if col.Name == field && i < len(record.Values) {
+			return record.Values[i], true
+		}
+	}
+	if doc, ok := record.Document.(map[string]interface{}); ok {
+		value, ok := doc[field]
+		return value, ok
+	}
+	return nil, false
+}
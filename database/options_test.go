@@ -0,0 +1,102 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCursor(t *testing.T) {
+	now := time.Date(2020, 5, 1, 10, 30, 0, 500, time.UTC)
+	token, err := EncodeCursor(int32(42), uint(7), 1.5, "name", true, []byte("raw"), now, nil)
+	if err != nil {
+		t.Fatalf("Cursor encoding error occured: %v", err)
+	}
+	values, err := DecodeCursor(token)
+	if err != nil {
+		t.Fatalf("Cursor decoding error occured: %v", err)
+	}
+	expected := []interface{}{int64(42), uint64(7), 1.5, "name", true, "raw", now, nil}
+	if len(values) != len(expected) {
+		t.Fatalf("Wrong number of cursor values: %v", values)
+	}
+	for i, v := range expected {
+		if tm, ok := v.(time.Time); ok {
+			if !tm.Equal(values[i].(time.Time)) {
+				t.Fatalf("Wrong cursor value %v, expected: %v but was: %v", i, v, values[i])
+			}
+		} else if values[i] != v {
+			t.Fatalf("Wrong cursor value %v, expected: %v but was: %v", i, v, values[i])
+		}
+	}
+	if _, err = EncodeCursor(struct{}{}); err == nil {
+		t.Fatal("Unsupported cursor value type should fail")
+	}
+	if _, err = DecodeCursor("not a cursor"); err == nil {
+		t.Fatal("Invalid cursor decoding should fail")
+	}
+}
+
+func TestAfterFilter(t *testing.T) {
+	options := QueryOptions{
+		OrderBy: []OrderBy{{Field: "a"}, {Field: "b", Direction: Descending}},
+	}
+	filter, err := options.AfterFilter()
+	if err != nil || !filter.IsEmpty() {
+		t.Fatalf("Filter without cursor should be empty: %v %v", filter, err)
+	}
+	options.After, _ = EncodeCursor(1, "x")
+	filter, err = options.AfterFilter()
+	if err != nil {
+		t.Fatalf("Keyset filter error occured: %v", err)
+	}
+	if filter.Type != OrFilter || len(filter.Filters) != 2 {
+		t.Fatalf("Wrong keyset filter: %v", filter)
+	}
+	first, second := filter.Filters[0].Filters, filter.Filters[1].Filters
+	if len(first) != 1 || first[0].Condition.Field != "a" || first[0].Condition.Operation != GraterThan {
+		t.Fatalf("Wrong first field keyset filter: %v", first)
+	}
+	if len(second) != 2 || second[0].Condition.Operation != Equals || second[1].Condition.Field != "b" ||
+		second[1].Condition.Operation != LessThan || second[1].Condition.Value.Value != "x" {
+		t.Fatalf("Wrong second field keyset filter: %v", second)
+	}
+	options.After, _ = EncodeCursor(1)
+	if _, err = options.AfterFilter(); err == nil {
+		t.Fatal("Cursor and order by fields length mismatch should fail")
+	}
+	if err = (QueryOptions{After: options.After}).Validate(); err == nil {
+		t.Fatal("Cursor without order by fields should fail")
+	}
+	if err = (QueryOptions{Limit: -1}).Validate(); err == nil {
+		t.Fatal("Negative limit should fail")
+	}
+}
+
+func TestNextCursor(t *testing.T) {
+	options := QueryOptions{OrderBy: []OrderBy{{Field: "b"}}}
+	rs := ResultSet{
+		MetaData: MetaData{Columns: []Column{{Name: "a"}, {Name: "b"}}},
+		Records: []Result{
+			{Values: []interface{}{1, "x"}},
+			{Values: []interface{}{2, "y"}},
+		},
+	}
+	token, err := NextCursor(rs, options)
+	if err != nil {
+		t.Fatalf("Next cursor error occured: %v", err)
+	}
+	values, _ := DecodeCursor(token)
+	if len(values) != 1 || values[0] != "y" {
+		t.Fatalf("Wrong next cursor values: %v", values)
+	}
+	rs.MetaData.Columns = []Column{}
+	rs.Records[1].Document = map[string]interface{}{"b": "z"}
+	token, _ = NextCursor(rs, options)
+	values, _ = DecodeCursor(token)
+	if len(values) != 1 || values[0] != "z" {
+		t.Fatalf("Wrong next cursor document values: %v", values)
+	}
+	if _, err = NextCursor(rs, QueryOptions{OrderBy: []OrderBy{{Field: "c"}}}); err == nil {
+		t.Fatal("Missing order by field should fail")
+	}
+}
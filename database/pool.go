@@ -0,0 +1,139 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"time"
+)
+
+// Connection pool configuration structure, zero values keep the driver defaults
+type PoolConfig struct {
+	// Maximum number of open connections, MongoDB maximum pool size
+	MaxOpen int `json:"maxOpen,omitempty" yaml:"maxOpen,omitempty" xml:"max-open,omitempty"`
+	// Maximum number of idle connections, SQL drivers only
+	MaxIdle int `json:"maxIdle,omitempty" yaml:"maxIdle,omitempty" xml:"max-idle,omitempty"`
+	// Maximum time a connection may stay idle before being closed
+	IdleTimeout time.Duration `json:"idleTimeout,omitempty" yaml:"idleTimeout,omitempty" xml:"idle-timeout,omitempty"`
+	// Maximum time a connection may be reused, SQL drivers only
+	MaxLifetime time.Duration `json:"maxLifetime,omitempty" yaml:"maxLifetime,omitempty" xml:"max-lifetime,omitempty"`
+	// Minimum number of connections kept in the pool, MongoDB only
+	MinPoolSize int `json:"minPoolSize,omitempty" yaml:"minPoolSize,omitempty" xml:"min-pool-size,omitempty"`
+}
+
+// Pool configuration decoding structure, accepting the durations as text
+type poolConfigText struct {
+	MaxOpen     int          `json:"maxOpen" xml:"max-open"`
+	MaxIdle     int          `json:"maxIdle" xml:"max-idle"`
+	IdleTimeout textDuration `json:"idleTimeout" xml:"idle-timeout"`
+	MaxLifetime textDuration `json:"maxLifetime" xml:"max-lifetime"`
+	MinPoolSize int          `json:"minPoolSize" xml:"min-pool-size"`
+}
+
+func (t poolConfigText) config() PoolConfig {
+	return PoolConfig{
+		MaxOpen:     t.MaxOpen,
+		MaxIdle:     t.MaxIdle,
+		IdleTimeout: time.Duration(t.IdleTimeout),
+		MaxLifetime: time.Duration(t.MaxLifetime),
+		MinPoolSize: t.MinPoolSize,
+	}
+}
+
+// Duration decoded from a Go duration text, e.g. 30s, or from a number of nanoseconds
+type textDuration time.Duration
+
+func (d *textDuration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		var ns int64
+		if err = json.Unmarshal(data, &ns); err != nil {
+			return err
+		}
+		*d = textDuration(ns)
+		return nil
+	}
+	return d.UnmarshalText([]byte(text))
+}
+
+func (d *textDuration) UnmarshalText(text []byte) error {
+	duration, err := parseDuration(string(text))
+	*d = textDuration(duration)
+	return err
+}
+
+// Decodes the JSON pool configuration, accepting the durations as Go duration text or nanoseconds
+func (p *PoolConfig) UnmarshalJSON(data []byte) error {
+	var text poolConfigText
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	*p = text.config()
+	return nil
+}
+
+// Decodes the XML pool configuration, accepting the durations as Go duration text or nanoseconds
+func (p *PoolConfig) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var text poolConfigText
+	if err := d.DecodeElement(&text, &start); err != nil {
+		return err
+	}
+	*p = text.config()
+	return nil
+}
+
+// Applies the pool configuration to the database/sql connections pool
+func (p PoolConfig) Apply(db *sql.DB) {
+	if p.MaxOpen > 0 {
+		db.SetMaxOpenConns(p.MaxOpen)
+	}
+	if p.MaxIdle > 0 {
+		db.SetMaxIdleConns(p.MaxIdle)
+	}
+	if p.IdleTimeout > 0 {
+		db.SetConnMaxIdleTime(p.IdleTimeout)
+	}
+	if p.MaxLifetime > 0 {
+		db.SetConnMaxLifetime(p.MaxLifetime)
+	}
+}
+
+// Connection pool statistics structure
+type PoolStats struct {
+	// Maximum number of open connections, zero when unlimited or left to the driver default
+	MaxOpen int
+	// Number of open connections, in use or idle
+	Open int
+	// Number of connections in use
+	InUse int
+	// Number of idle connections
+	Idle int
+	// Number of operations currently waiting for a connection, MongoDB only
+	Waiting int
+	// Total number of operations waited for a connection, SQL drivers only
+	WaitCount int64
+	// Total time waited for a connection, SQL drivers only
+	WaitDuration time.Duration
+}
+
+// Connection pool statistics aware Connection interface
+type StatsConnection interface {
+	// Returns the connection pool statistics
+	Stats() PoolStats
+}
+
+// Returns the database/sql connections pool statistics
+func SqlPoolStats(db *sql.DB) PoolStats {
+	if db == nil {
+		return PoolStats{}
+	}
+	stats := db.Stats()
+	return PoolStats{
+		MaxOpen:      stats.MaxOpenConnections,
+		Open:         stats.OpenConnections,
+		InUse:        stats.InUse,
+		Idle:         stats.Idle,
+		WaitCount:    stats.WaitCount,
+		WaitDuration: stats.WaitDuration,
+	}
+}
@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+	"strings"
+)
+
+// Builds the multi-row INSERT statement and its values, updating the non key fields ON CONFLICT on upsert and
+// returning whether each written record has been inserted
+func prepareInsertBatch(dbRef database.DataRef, fields []database.Field, rows [][]database.Value, options database.BatchOptions) (string, []interface{}) {
+	var cols = make([]string, 0)
+	var updates = make([]string, 0)
+	for _, f := range fields {
+		cols = append(cols, f.Name)
+		if !options.IsKey(f.Name) {
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", f.Name, f.Name))
+		}
+	}
+	var tuples = make([]string, 0)
+	var sqlValues = make([]interface{}, 0)
+	for _, row := range rows {
+		var colValues = make([]string, 0)
+		for _, val := range row {
+			sqlValues = append(sqlValues, val.Value)
+			colValues = append(colValues, dialect.Placeholder(len(sqlValues)))
+		}
+		tuples = append(tuples, "("+strings.Join(colValues, ", ")+")")
+	}
+	sqlText := fmt.Sprintf("INSERT INTO %s(%s) VALUES %s", database.TableName(dbRef), strings.Join(cols, ", "), strings.Join(tuples, ", "))
+	if options.Upsert {
+		sqlText += fmt.Sprintf(" ON CONFLICT (%s)", strings.Join(options.KeyFields, ", "))
+		if len(updates) > 0 {
+			sqlText += " DO UPDATE SET " + strings.Join(updates, ", ")
+		} else {
+			sqlText += " DO NOTHING"
+		}
+		sqlText += " RETURNING (xmax = 0)"
+	}
+	return sqlText, sqlValues
+}
+
+func (c *postgresConnection) InsertBatch(dbRef database.DataRef, fields []database.Field, rows [][]database.Value, options database.BatchOptions) ([]database.BatchResult, error) {
+	return c.InsertBatchContext(context.Background(), dbRef, fields, rows, options)
+}
+
+// Inserts the rows with multi-row INSERT statements, the upsert requires the options KeyFields to match a table
+// unique constraint
+func (c *postgresConnection) InsertBatchContext(ctx context.Context, dbRef database.DataRef, fields []database.Field, rows [][]database.Value, options database.BatchOptions) (_ []database.BatchResult, err error) {
+	defer opError("InsertBatch", dbRef, &err)
+	var results = make([]database.BatchResult, 0)
+	if c.DB == nil {
+		return results, database.ErrClosed
+	}
+	if options.Upsert && len(options.KeyFields) == 0 {
+		return results, errors.New(fmt.Sprint("Upsert needs the list of key fields"))
+	}
+	batches, err := database.Batches(fields, rows, options)
+	if err != nil {
+		return results, err
+	}
+	for _, batch := range batches {
+		result, err := c.insertBatch(ctx, dbRef, fields, batch, options)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (c *postgresConnection) insertBatch(ctx context.Context, dbRef database.DataRef, fields []database.Field, batch [][]database.Value, options database.BatchOptions) (database.BatchResult, error) {
+	var result database.BatchResult
+	sqlText, sqlValues := prepareInsertBatch(dbRef, fields, batch, options)
+	if !options.Upsert {
+		r, err := c.executor().ExecContext(ctx, sqlText, sqlValues...)
+		if err != nil {
+			return result, err
+		}
+		result.Inserted, err = r.RowsAffected()
+		return result, err
+	}
+	rows, err := c.executor().QueryContext(ctx, sqlText, sqlValues...)
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	for rows.Next() {
+		var inserted bool
+		if err = rows.Scan(&inserted); err != nil {
+			return result, err
+		}
+		if inserted {
+			result.Inserted++
+		} else {
+			result.Updated++
+		}
+	}
+	return result, rows.Err()
+}
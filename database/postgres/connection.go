@@ -0,0 +1,434 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type postgresConnection struct {
+	Configuration database.DbConfig
+	DB            *sql.DB
+	tx            *sql.Tx
+	err           error
+}
+
+// PostgreSQL statements dialect
+var dialect = database.SqlDialect{
+	Constraints: func(f database.Field, _ bool) (string, error) {
+		var constraints = ""
+		if f.AutoIncrement {
+			constraints += " GENERATED BY DEFAULT AS IDENTITY"
+		}
+		if f.NotNull || f.PrimaryKey {
+			constraints += " NOT NULL"
+		}
+		return constraints, nil
+	},
+	Placeholder: func(position int) string {
+		return fmt.Sprintf("$%v", position)
+	},
+}
+
+func toPostgresTypeInstance(typeName string) (reflect.Type, interface{}) {
+	switch strings.ToLower(typeName) {
+	case "bool", "boolean":
+		v := false
+		return reflect.TypeOf(v), v
+	case "int2", "smallint":
+		v := int16(0)
+		return reflect.TypeOf(v), v
+	case "int4", "integer", "int", "serial":
+		v := int32(0)
+		return reflect.TypeOf(v), v
+	case "int8", "bigint", "bigserial":
+		v := int64(0)
+		return reflect.TypeOf(v), v
+	case "float4", "real":
+		v := float32(0)
+		return reflect.TypeOf(v), v
+	case "float8", "double precision":
+		v := float64(0)
+		return reflect.TypeOf(v), v
+	case "char", "bpchar", "varchar", "text", "name", "uuid", "json", "jsonb", "xml", "money", "numeric", "decimal":
+		// Money values are formatted with the currency symbol and the locale separators, e.g. $1,234.50, and the
+		// arbitrary precision numbers are returned as text by the driver, e.g. 12.50
+		v := ""
+		return reflect.TypeOf(v), v
+	case "date", "time", "timetz", "timestamp", "timestamptz":
+		v := time.Now()
+		return reflect.TypeOf(v), v
+	case "bytea":
+		v := make([]byte, 0)
+		return reflect.TypeOf(v), v
+	default:
+		return reflect.TypeOf(""), sql.RawBytes{}
+	}
+}
+
+func (c *postgresConnection) Query(dbRef database.DataRef, fields []string, conditions []database.Condition, withAnd bool) (database.ResultSet, error) {
+	return c.QueryContext(context.Background(), dbRef, fields, conditions, withAnd)
+}
+
+func (c *postgresConnection) QueryContext(ctx context.Context, dbRef database.DataRef, fields []string, conditions []database.Condition, withAnd bool) (database.ResultSet, error) {
+	return c.QueryFilter(ctx, dbRef, fields, database.FromConditions(conditions, withAnd))
+}
+
+func (c *postgresConnection) QueryFilter(ctx context.Context, dbRef database.DataRef, fields []string, filter database.Filter) (database.ResultSet, error) {
+	return c.QueryPage(ctx, dbRef, fields, filter, database.QueryOptions{})
+}
+
+// Executes the query applying the options, reading all the records in the result set
+func (c *postgresConnection) QueryPage(ctx context.Context, dbRef database.DataRef, fields []string, filter database.Filter, options database.QueryOptions) (_ database.ResultSet, err error) {
+	defer opError("Query", dbRef, &err)
+	rows, err := c.Stream(ctx, dbRef, fields, filter, options)
+	if err != nil {
+		return database.ResultSet{
+			Records: make([]database.Result, 0),
+			Lines:   0,
+			MetaData: database.MetaData{
+				EntityRef: dbRef,
+				Columns:   make([]database.Column, 0),
+			},
+		}, err
+	}
+	return database.Collect(rows)
+}
+
+// Executes the query applying the options, which are ignored for SQL statements queries, the returned rows
+// hold a database connection until closed
+func (c *postgresConnection) Stream(ctx context.Context, dbRef database.DataRef, fields []string, filter database.Filter, options database.QueryOptions) (_ database.Rows, err error) {
+	defer opError("Stream", dbRef, &err)
+	if c.DB == nil {
+		return nil, database.ErrClosed
+	}
+	var rows *sql.Rows
+	if err = options.Validate(); err != nil {
+		return nil, err
+	}
+	var after database.Filter
+	if after, err = options.AfterFilter(); err != nil {
+		return nil, err
+	}
+	filter = database.And(filter, after)
+	if dbRef.SQL != "" {
+		rows, err = c.executor().QueryContext(ctx, dbRef.SQL)
+	} else {
+		var selCols = strings.Join(fields, ", ")
+		if selCols == "" {
+			selCols = "*"
+		}
+		where, values := dialect.Where(filter, 0)
+		rows, err = c.executor().QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s%s%s", selCols, database.TableName(dbRef), where, dialect.QueryOptions(options)), values...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return database.NewSqlRows(rows, dbRef, toPostgresTypeInstance, "BYTEA")
+}
+
+func (c *postgresConnection) Insert(dbRef database.DataRef, fields []database.Field, values []database.Value) error {
+	return c.InsertContext(context.Background(), dbRef, fields, values)
+}
+
+func (c *postgresConnection) InsertContext(ctx context.Context, dbRef database.DataRef, fields []database.Field, values []database.Value) (err error) {
+	defer opError("Insert", dbRef, &err)
+	if c.DB == nil {
+		return database.ErrClosed
+	}
+	if len(fields) != len(values) {
+		return errors.New(fmt.Sprintf("Columns and values must have same length: %v <> %v", len(fields), len(values)))
+	}
+	if len(fields) == 0 || len(values) == 0 {
+		return errors.New(fmt.Sprint("Insert statement needs list of Columns and Values of same length"))
+	}
+	var cols = make([]string, 0)
+	var colValues = make([]string, 0)
+	var sqlValues = make([]interface{}, 0)
+	for i, f := range fields {
+		cols = append(cols, f.Name)
+		colValues = append(colValues, dialect.Placeholder(i+1))
+		sqlValues = append(sqlValues, values[i].Value)
+	}
+	sqlText := fmt.Sprintf("INSERT INTO %s(%s) VALUES(%s)", database.TableName(dbRef), strings.Join(cols, ", "), strings.Join(colValues, ", "))
+	_, err = c.executor().ExecContext(ctx, sqlText, sqlValues...)
+	return err
+}
+
+func (c *postgresConnection) Update(dbRef database.DataRef, conditions []database.Condition, fields []database.Field, values []database.Value, withAnd bool) (int64, error) {
+	return c.UpdateContext(context.Background(), dbRef, conditions, fields, values, withAnd)
+}
+
+func (c *postgresConnection) UpdateContext(ctx context.Context, dbRef database.DataRef, conditions []database.Condition, fields []database.Field, values []database.Value, withAnd bool) (int64, error) {
+	return c.UpdateFilter(ctx, dbRef, database.FromConditions(conditions, withAnd), fields, values)
+}
+
+func (c *postgresConnection) UpdateFilter(ctx context.Context, dbRef database.DataRef, filter database.Filter, fields []database.Field, values []database.Value) (_ int64, err error) {
+	defer opError("Update", dbRef, &err)
+	var records int64
+	if c.DB == nil {
+		return records, database.ErrClosed
+	}
+	if len(fields) != len(values) {
+		return records, errors.New(fmt.Sprintf("Columns and values must have same length: %v <> %v", len(fields), len(values)))
+	}
+	if len(fields) == 0 || len(values) == 0 {
+		return records, errors.New(fmt.Sprint("Update statement needs list of Columns and Values of same length"))
+	}
+	var cols = make([]string, 0)
+	var sqlValues = make([]interface{}, 0)
+	for i, f := range fields {
+		cols = append(cols, f.Name+" = "+dialect.Placeholder(i+1))
+		sqlValues = append(sqlValues, values[i].Value)
+	}
+	where, whereValues := dialect.Where(filter, len(sqlValues))
+	sqlValues = append(sqlValues, whereValues...)
+	sqlText := fmt.Sprintf("UPDATE %s SET %s%s", database.TableName(dbRef), strings.Join(cols, ", "), where)
+	r, err := c.executor().ExecContext(ctx, sqlText, sqlValues...)
+	if err != nil {
+		return records, err
+	}
+	records, err = r.RowsAffected()
+	return records, err
+}
+
+func (c *postgresConnection) Delete(dbRef database.DataRef, conditions []database.Condition, withAnd bool) (int64, error) {
+	return c.DeleteContext(context.Background(), dbRef, conditions, withAnd)
+}
+
+func (c *postgresConnection) DeleteContext(ctx context.Context, dbRef database.DataRef, conditions []database.Condition, withAnd bool) (int64, error) {
+	return c.DeleteFilter(ctx, dbRef, database.FromConditions(conditions, withAnd))
+}
+
+func (c *postgresConnection) DeleteFilter(ctx context.Context, dbRef database.DataRef, filter database.Filter) (_ int64, err error) {
+	defer opError("Delete", dbRef, &err)
+	var records int64
+	if c.DB == nil {
+		return records, database.ErrClosed
+	}
+	where, whereValues := dialect.Where(filter, 0)
+	sqlText := fmt.Sprintf("DELETE FROM %s%s", database.TableName(dbRef), where)
+	r, err := c.executor().ExecContext(ctx, sqlText, whereValues...)
+	if err != nil {
+		return records, err
+	}
+	records, err = r.RowsAffected()
+	return records, err
+}
+
+// Counts the matching records, or the records of the SQL statement query
+func (c *postgresConnection) Count(ctx context.Context, dbRef database.DataRef, filter database.Filter) (_ int64, err error) {
+	defer opError("Count", dbRef, &err)
+	if c.DB == nil {
+		return 0, database.ErrClosed
+	}
+	var count int64
+	if dbRef.SQL != "" {
+		err = c.executor().QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM (%s) AS counted", dbRef.SQL)).Scan(&count)
+		return count, err
+	}
+	where, values := dialect.Where(filter, 0)
+	err = c.executor().QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s%s", database.TableName(dbRef), where), values...).Scan(&count)
+	return count, err
+}
+
+func (c *postgresConnection) Purge(dbRef database.DataRef) (int64, error) {
+	return c.PurgeContext(context.Background(), dbRef)
+}
+
+func (c *postgresConnection) PurgeContext(ctx context.Context, dbRef database.DataRef) (_ int64, err error) {
+	defer opError("Purge", dbRef, &err)
+	var count int64
+	if c.DB == nil {
+		return count, database.ErrClosed
+	}
+	if dbRef.Namespace != "" {
+		_, err := c.DB.ExecContext(ctx, fmt.Sprintf("TRUNCATE TABLE %s", database.TableName(dbRef)))
+		if err != nil {
+			return count, err
+		}
+		return 1, nil
+	} else if dbRef.Database != "" || dbRef.Schema != "" {
+		var schema = dbRef.Schema
+		if schema == "" {
+			schema = "public"
+		}
+		rows, err := c.DB.QueryContext(ctx, "SELECT table_name FROM information_schema.tables WHERE table_schema = $1 AND table_type = 'BASE TABLE'", schema)
+		if err != nil {
+			return count, err
+		}
+		var tables = make([]string, 0)
+		var table string
+		for rows.Next() {
+			if err = rows.Scan(&table); err != nil {
+				_ = rows.Close()
+				return count, err
+			}
+			tables = append(tables, table)
+		}
+		_ = rows.Close()
+		for _, table := range tables {
+			_, err = c.DB.ExecContext(ctx, fmt.Sprintf("TRUNCATE TABLE %s.%s CASCADE", schema, table))
+			if err != nil {
+				return count, err
+			}
+			count++
+		}
+		return count, nil
+	}
+	return count, errors.New(fmt.Sprint("Please choose truncate entity between Namespace for Table and Database or Schema for all Tables"))
+}
+
+func (c *postgresConnection) Create(dbRef database.DataRef, fields []database.Field) error {
+	return c.CreateContext(context.Background(), dbRef, fields)
+}
+
+func (c *postgresConnection) CreateContext(ctx context.Context, dbRef database.DataRef, fields []database.Field) (err error) {
+	defer opError("Create", dbRef, &err)
+	if c.DB == nil {
+		return database.ErrClosed
+	}
+	if dbRef.Namespace != "" {
+		var sqlText string
+		sqlText, err = dialect.CreateTable(dbRef, fields)
+		if err != nil {
+			return err
+		}
+		_, err = c.DB.ExecContext(ctx, sqlText)
+	} else if dbRef.Database != "" {
+		err = c.CreateDbContext(ctx, dbRef)
+	} else if dbRef.Schema != "" {
+		err = c.CreateDbContext(ctx, dbRef)
+	}
+	return err
+}
+
+func (c *postgresConnection) CreateDb(dbRef database.DataRef) error {
+	return c.CreateDbContext(context.Background(), dbRef)
+}
+
+func (c *postgresConnection) CreateDbContext(ctx context.Context, dbRef database.DataRef) (err error) {
+	defer opError("CreateDb", dbRef, &err)
+	if c.DB == nil {
+		return database.ErrClosed
+	}
+	if dbRef.Database != "" {
+		_, err = c.DB.ExecContext(ctx, fmt.Sprintf("CREATE DATABASE %s", dbRef.Database))
+	} else if dbRef.Schema != "" {
+		var ifNotExists string
+		if dbRef.IfNotExists {
+			ifNotExists = "IF NOT EXISTS "
+		}
+		_, err = c.DB.ExecContext(ctx, fmt.Sprintf("CREATE SCHEMA %s%s", ifNotExists, dbRef.Schema))
+	}
+	return err
+}
+
+func (c *postgresConnection) Drop(dbRef database.DataRef) error {
+	return c.DropContext(context.Background(), dbRef)
+}
+
+func (c *postgresConnection) DropContext(ctx context.Context, dbRef database.DataRef) (err error) {
+	defer opError("Drop", dbRef, &err)
+	if c.DB == nil {
+		return database.ErrClosed
+	}
+	if dbRef.Namespace != "" {
+		_, err = c.DB.ExecContext(ctx, fmt.Sprintf("DROP TABLE %s CASCADE", database.TableName(dbRef)))
+	} else if dbRef.Database != "" {
+		return c.DropDbContext(ctx, dbRef)
+	} else if dbRef.FieldSetRef != "" {
+		_, err = c.DB.ExecContext(ctx, fmt.Sprintf("DROP TABLESPACE %s", dbRef.FieldSetRef))
+	} else if dbRef.Schema != "" {
+		_, err = c.DB.ExecContext(ctx, fmt.Sprintf("DROP SCHEMA %s CASCADE", dbRef.Schema))
+	} else {
+		return errors.New(fmt.Sprint("Please choose drop entity between Namespace for Table, FieldSet for Tablespace, Schema and Database"))
+	}
+	return err
+}
+
+func (c *postgresConnection) DropDb(dbRef database.DataRef) error {
+	return c.DropDbContext(context.Background(), dbRef)
+}
+
+func (c *postgresConnection) DropDbContext(ctx context.Context, dbRef database.DataRef) (err error) {
+	defer opError("DropDb", dbRef, &err)
+	if c.DB == nil {
+		return database.ErrClosed
+	}
+	_, err = c.DB.ExecContext(ctx, fmt.Sprintf("DROP DATABASE %s", dbRef.Database))
+	return err
+}
+
+func (c *postgresConnection) Close() (err error) {
+	defer opError("Close", database.DataRef{}, &err)
+	if c.DB == nil {
+		return database.ErrClosed
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New(fmt.Sprintf("%v", r))
+		}
+		c.DB = nil
+	}()
+	err = c.DB.Close()
+	return err
+}
+
+// Executes the script statements in order, stopping at the first failing one
+func (c *postgresConnection) ExecScript(ctx context.Context, dbRef database.DataRef, script string) (err error) {
+	defer opError("ExecScript", dbRef, &err)
+	if c.DB == nil {
+		return database.ErrClosed
+	}
+	for i, statement := range database.SplitStatements(script) {
+		if _, err := c.executor().ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("Statement %v failed: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (c *postgresConnection) Ping(ctx context.Context) (err error) {
+	defer opError("Ping", database.DataRef{}, &err)
+	if c.DB == nil {
+		return database.ErrClosed
+	}
+	return c.DB.PingContext(ctx)
+}
+
+func (c *postgresConnection) ServerVersion(ctx context.Context) (_ string, err error) {
+	defer opError("ServerVersion", database.DataRef{}, &err)
+	var version string
+	if c.DB == nil {
+		return version, database.ErrClosed
+	}
+	err = c.DB.QueryRowContext(ctx, "SHOW server_version").Scan(&version)
+	return version, err
+}
+
+// Returns the connection configuration, with the Driver name
+func (c *postgresConnection) Config() database.DbConfig {
+	config := c.Configuration
+	if config.Driver == "" {
+		config.Driver = "postgres"
+	}
+	return config
+}
+
+func (c *postgresConnection) Stats() database.PoolStats {
+	return database.SqlPoolStats(c.DB)
+}
+
+func (c *postgresConnection) IsConnected() bool {
+	return c.DB != nil
+}
+
+func (c *postgresConnection) GetLastError() error {
+	return c.err
+}
@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"errors"
+	"github.com/hellgate75/go-services/database"
+	"github.com/lib/pq"
+	"reflect"
+	"testing"
+)
+
+func TestPrepareWhere(t *testing.T) {
+	conditions := []database.Condition{
+		{
+			Field:     "name",
+			Operation: database.Equals,
+			Value:     database.Value{Type: "string", Value: "Fabrizio"},
+		},
+		{
+			Field:     "role",
+			Operation: database.Null,
+		},
+		{
+			Field:     "age",
+			Operation: database.Not + database.LessThan,
+			Value:     database.Value{Type: "int", Value: 40},
+		},
+		{
+			Field:     "code",
+			Operation: database.In,
+			Value:     database.Value{Type: "array", Value: []string{"a", "b"}},
+		},
+	}
+	where, values := dialect.Where(database.FromConditions(conditions, true), 2)
+	expected := " WHERE name = $3 AND role IS NULL AND age > $4 AND code IN ($5, $6)"
+	if where != expected {
+		t.Fatalf("Wrong where clause, expected: <%s> but was: <%s>", expected, where)
+	}
+	if len(values) != 4 {
+		t.Fatalf("Wrong number of values, expected: 4 but was: %v", len(values))
+	}
+	_, values = dialect.Where(database.Leaf(database.Condition{Field: "code", Operation: database.In, Value: database.Value{Value: "a"}}), 0)
+	if len(values) != 1 || values[0] != "a" {
+		t.Fatalf("Scalar In values should be bound as single values: %v", values)
+	}
+	where, _ = dialect.Where(database.FromConditions(conditions[:2], false), 0)
+	expected = " WHERE name = $1 OR role IS NULL"
+	if where != expected {
+		t.Fatalf("Wrong where clause, expected: <%s> but was: <%s>", expected, where)
+	}
+}
+
+func TestPrepareFilter(t *testing.T) {
+	filter := database.Or(
+		database.And(
+			database.Leaf(database.Condition{Field: "a", Operation: database.Equals, Value: database.Value{Value: 1}}),
+			database.Leaf(database.Condition{Field: "b", Operation: database.GraterThan, Value: database.Value{Value: 2}}),
+		),
+		database.Leaf(database.Condition{Field: "c", Operation: database.Null}),
+		database.Negate(database.Leaf(database.Condition{Field: "d", Operation: database.Like, Value: database.Value{Value: "x%"}})),
+		database.And(),
+	)
+	where, values := dialect.Where(filter, 0)
+	expected := " WHERE (a = $1 AND b > $2) OR c IS NULL OR NOT (d LIKE $3)"
+	if where != expected {
+		t.Fatalf("Wrong where clause, expected: <%s> but was: <%s>", expected, where)
+	}
+	if len(values) != 3 {
+		t.Fatalf("Wrong number of values, expected: 3 but was: %v", len(values))
+	}
+	where, _ = dialect.Where(database.And(database.Or()), 0)
+	if where != "" {
+		t.Fatalf("Empty filter groups should not restrict records: <%s>", where)
+	}
+}
+
+func TestDataSourceName(t *testing.T) {
+	dsn := dataSourceName(database.DbConfig{
+		Host:     "localhost",
+		Port:     5432,
+		Name:     "root",
+		Password: "sec'ret",
+		Database: database.DataRef{
+			Database: "test",
+			Schema:   "sample",
+		},
+	})
+	expected := `host='localhost' port=5432 user='root' password='sec\'ret' dbname='test' search_path='sample'`
+	if dsn != expected {
+		t.Fatalf("Wrong data source name, expected: <%s> but was: <%s>", expected, dsn)
+	}
+	dsn = dataSourceName(database.DbConfig{
+		Host:        "db",
+		Certificate: "/etc/ssl/client.pem",
+		PrivateKey:  "/etc/ssl/client.key",
+		TLS:         database.TLSConfig{CAFile: "/etc/ssl/ca.pem"},
+	})
+	expected = `host='db' sslmode=verify-full sslrootcert='/etc/ssl/ca.pem' sslcert='/etc/ssl/client.pem' sslkey='/etc/ssl/client.key'`
+	if dsn != expected {
+		t.Fatalf("Wrong TLS data source name, expected: <%s> but was: <%s>", expected, dsn)
+	}
+	dsn = dataSourceName(database.DbConfig{Host: "db", TLS: database.TLSConfig{InsecureSkipVerify: true}})
+	if dsn != `host='db' sslmode=require` {
+		t.Fatalf("Wrong insecure TLS data source name: <%s>", dsn)
+	}
+	if database.TableName(database.DataRef{Namespace: "users", Schema: "sample"}) != "sample.users" {
+		t.Fatal("Table name should be qualified with the schema")
+	}
+}
+
+func TestPrepareInsertBatch(t *testing.T) {
+	fields := []database.Field{{Name: "code"}, {Name: "name"}}
+	rows := [][]database.Value{{{Value: "1"}, {Value: "Mario"}}, {{Value: "2"}, {Value: "Luigi"}}}
+	sqlText, values := prepareInsertBatch(database.DataRef{Namespace: "sample"}, fields, rows, database.BatchOptions{})
+	expected := "INSERT INTO sample(code, name) VALUES ($1, $2), ($3, $4)"
+	if sqlText != expected {
+		t.Fatalf("Wrong insert statement, expected: <%s> but was: <%s>", expected, sqlText)
+	}
+	if len(values) != 4 || values[3] != "Luigi" {
+		t.Fatalf("Wrong insert values: %v", values)
+	}
+	sqlText, _ = prepareInsertBatch(database.DataRef{Namespace: "sample"}, fields, rows[:1], database.BatchOptions{Upsert: true, KeyFields: []string{"code"}})
+	expected = "INSERT INTO sample(code, name) VALUES ($1, $2) ON CONFLICT (code) DO UPDATE SET name = EXCLUDED.name RETURNING (xmax = 0)"
+	if sqlText != expected {
+		t.Fatalf("Wrong upsert statement, expected: <%s> but was: <%s>", expected, sqlText)
+	}
+}
+
+func TestToPostgresTypeInstance(t *testing.T) {
+	var tests = map[string]reflect.Type{
+		"int4":    reflect.TypeOf(int32(0)),
+		"numeric": reflect.TypeOf(""),
+		"money":   reflect.TypeOf(""),
+		"bytea":   reflect.TypeOf([]byte{}),
+	}
+	for typeName, expected := range tests {
+		if goType, _ := toPostgresTypeInstance(typeName); goType != expected {
+			t.Fatalf("Wrong %s type: %v, expected %v", typeName, goType, expected)
+		}
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	conn := &postgresConnection{}
+	if !conn.IsTransient(&pq.Error{Code: "08006"}) || !conn.IsTransient(&pq.Error{Code: "40001"}) {
+		t.Fatal("Connection failures and serialization failures should be transient")
+	}
+	if conn.IsTransient(&pq.Error{Code: "23505"}) {
+		t.Fatal("Unique violations should not be transient")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	var tests = map[pq.ErrorCode]error{
+		"23505": database.ErrDuplicateKey,
+		"23503": database.ErrConstraint,
+		"23502": database.ErrConstraint,
+		"57014": database.ErrTimeout,
+		"42P01": database.ErrNotFound,
+	}
+	for code, kind := range tests {
+		err := classify(&pq.Error{Code: code})
+		var pqErr *pq.Error
+		if !errors.Is(err, kind) || !errors.As(err, &pqErr) || pqErr.Code != code {
+			t.Fatalf("Wrong error %v classification: %v", code, err)
+		}
+	}
+	if err := classify(&pq.Error{Code: "42601"}); errors.Is(err, database.ErrConstraint) {
+		t.Fatalf("Syntax errors should not be classified: %v", err)
+	}
+}
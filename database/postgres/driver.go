@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+	_ "github.com/lib/pq"
+	"strings"
+)
+
+func init() {
+	if err := database.RegisterType("postgres", database.PostgreSQLDriver, []string{"postgresql", "pg"}, GetPostgresDriver); err != nil {
+		panic(err)
+	}
+}
+
+type postgresDriver struct {
+}
+
+func quoteDsnValue(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, `'`, `\'`, -1)
+	return "'" + value + "'"
+}
+
+func dataSourceName(config database.DbConfig) string {
+	//db, err := sql.Open("postgres", "host=<HOST> port=<port> user=<username> password=<pw> dbname=<dbname>")
+	var params = make([]string, 0)
+	if config.Host != "" {
+		params = append(params, "host="+quoteDsnValue(config.Host))
+	}
+	if config.Port > 0 {
+		params = append(params, fmt.Sprintf("port=%v", config.Port))
+	}
+	if config.Name != "" {
+		params = append(params, "user="+quoteDsnValue(config.Name))
+	}
+	if config.Password != "" {
+		params = append(params, "password="+quoteDsnValue(config.Password))
+	}
+	if config.Database.Database != "" {
+		params = append(params, "dbname="+quoteDsnValue(config.Database.Database))
+	}
+	if config.Database.Schema != "" {
+		params = append(params, "search_path="+quoteDsnValue(config.Database.Schema))
+	}
+	params = append(params, sslParams(config.TLSSettings())...)
+	return strings.Join(params, " ")
+}
+
+// Maps the TLS settings to the lib/pq ssl parameters: the server certificate and host name are verified unless
+// InsecureSkipVerify is set, when TLS is not enabled the lib/pq default sslmode applies
+func sslParams(settings database.TLSConfig) []string {
+	var params = make([]string, 0)
+	if !settings.Enabled {
+		return params
+	}
+	if settings.InsecureSkipVerify {
+		params = append(params, "sslmode=require")
+	} else {
+		params = append(params, "sslmode=verify-full")
+	}
+	if settings.CAFile != "" {
+		params = append(params, "sslrootcert="+quoteDsnValue(settings.CAFile))
+	}
+	if settings.CertFile != "" {
+		keyFile := settings.KeyFile
+		if keyFile == "" {
+			keyFile = settings.CertFile
+		}
+		params = append(params, "sslcert="+quoteDsnValue(settings.CertFile), "sslkey="+quoteDsnValue(keyFile))
+	}
+	return params
+}
+
+// Connects resolving the configuration secrets, with the credentials masked in the returned errors. The connection
+// keeps the configuration with the secret references, resolved again by each new connection.
+func (d *postgresDriver) Connect(config database.DbConfig) (database.Connection, error) {
+	resolved, err := database.ResolveSecrets(context.Background(), config)
+	if err != nil {
+		return nil, err
+	}
+	return d.ConnectResolved(resolved, config)
+}
+
+// Connects with the configuration secrets already resolved, with the credentials masked in the returned errors
+func (d *postgresDriver) ConnectResolved(resolved database.DbConfig, config database.DbConfig) (database.Connection, error) {
+	conn, err := d.connect(resolved, config)
+	return conn, resolved.RedactError(err)
+}
+
+// Connects with the resolved configuration, keeping the unresolved one as the connection configuration
+func (d *postgresDriver) connect(config database.DbConfig, unresolved database.DbConfig) (database.Connection, error) {
+	connStr := config.Url
+	if connStr == "" {
+		connStr = dataSourceName(config)
+	}
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		return nil, err
+	}
+	config.Pool.Apply(db)
+	if err = db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &postgresConnection{
+		Configuration: unresolved,
+		DB:            db,
+	}, nil
+}
+
+// Retrieves the PostgreSQL database driver
+func GetPostgresDriver() database.Driver {
+	return &postgresDriver{}
+}
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"errors"
+	"github.com/hellgate75/go-services/database"
+	"github.com/lib/pq"
+)
+
+// PostgreSQL error codes by error kind, the other integrity constraint violations of class 23 are ErrConstraint
+var errorKinds = map[pq.ErrorCode]error{
+	"23505": database.ErrDuplicateKey, // unique_violation
+	"57014": database.ErrTimeout,      // query_canceled, e.g. by statement_timeout
+	"55P03": database.ErrTimeout,      // lock_not_available, e.g. by lock_timeout
+	"3D000": database.ErrNotFound,     // invalid_catalog_name
+	"3F000": database.ErrNotFound,     // invalid_schema_name
+	"42P01": database.ErrNotFound,     // undefined_table
+}
+
+// Classifies the PostgreSQL errors by error code
+func classify(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	if kind, ok := errorKinds[pqErr.Code]; ok {
+		return database.WrapError(kind, err)
+	}
+	if pqErr.Code.Class() == "23" {
+		return database.WrapError(database.ErrConstraint, err)
+	}
+	return err
+}
+
+// Wraps the operation error in a database.OpError, classifying the PostgreSQL errors
+func opError(op string, dbRef database.DataRef, err *error) {
+	*err = database.NewOpError(op, "postgres", dbRef, classify(*err))
+}
+
+// Verifies the error is a connection exception, a server shutdown, a lack of resources, a serialization failure
+// or a deadlock
+func (c *postgresConnection) IsTransient(err error) bool {
+	if database.IsNetworkError(err) {
+		return true
+	}
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	switch pqErr.Code.Class() {
+	// Connection exception, insufficient resources
+	case "08", "53":
+		return true
+	}
+	switch pqErr.Code {
+	// Serialization failure, deadlock detected, admin shutdown, crash shutdown, cannot connect now
+	case "40001", "40P01", "57P01", "57P02", "57P03":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRedactString(t *testing.T) {
+	var tests = map[string]string{
+		"mongodb://root:s3cr@t@h1:27017,h2:27017/admin":         "mongodb://root:*****@h1:27017,h2:27017/admin",
+		"dial root:pa:ss@tcp(localhost:3306)/app?tls=true fail": "dial root:*****@tcp(localhost:3306)/app?tls=true fail",
+		"root:secret@/app":                                 "root:*****@/app",
+		"postgres://app:secret@/app":                       "postgres://app:*****@/app",
+		"host=db user=app password='a b' dbname=app":       "host=db user=app password=***** dbname=app",
+		"postgres://db/app?user=app&password=secret&ssl=1": "postgres://db/app?user=app&password=*****&ssl=1",
+		"connection refused on db:5432":                    "connection refused on db:5432",
+	}
+	for text, expected := range tests {
+		if redacted := RedactString(text); redacted != expected {
+			t.Fatalf("Wrong redaction of %s: %s", text, redacted)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	if Redact(nil) != nil {
+		t.Fatal("Nil error should stay nil")
+	}
+	plain := errors.New("syntax error")
+	if Redact(plain, "secret") != plain {
+		t.Fatal("Errors without credentials should be returned unchanged")
+	}
+	err := DbConfig{Password: "hunter2"}.RedactError(fmt.Errorf("login with hunter2 failed: %w", driver.ErrBadConn))
+	if err.Error() != "login with ***** failed: driver: bad connection" || !errors.Is(err, driver.ErrBadConn) {
+		t.Fatalf("Wrong redacted error: %v", err)
+	}
+	short := errors.New("cannot connect to database")
+	if Redact(short, "a") != short {
+		t.Fatal("Short secrets should not be masked within the words")
+	}
+	if err = Redact(errors.New("user:pwd@host pwdx"), "pwd"); err.Error() != "user:*****@host pwdx" {
+		t.Fatalf("Wrong redacted secret: %v", err)
+	}
+}
+
+func TestDbConfigRedactedViews(t *testing.T) {
+	config := DbConfig{
+		Driver:     "mysql",
+		Url:        "app:hunter2@tcp(db:3306)/app",
+		Name:       "app",
+		Password:   "hunter2",
+		DbPassword: "hunter3",
+		PrivateKey: "/etc/ssl/client.key",
+	}
+	for _, text := range []string{config.String(), fmt.Sprint(config), fmt.Sprintf("%+v", config), fmt.Sprintf("%#v", config)} {
+		if strings.Contains(text, "hunter") || strings.Contains(text, "client.key") || !strings.Contains(text, "app:*****@tcp(db:3306)/app") {
+			t.Fatalf("Credentials not redacted: %s", text)
+		}
+	}
+	if text := fmt.Sprintf("%#v", config); !strings.HasPrefix(text, "database.DbConfig{") {
+		t.Fatalf("Wrong Go syntax: %s", text)
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("Encoding error occured: %v", err)
+	}
+	if strings.Contains(string(data), "hunter") || !strings.Contains(string(data), `"userPassword":"*****"`) {
+		t.Fatalf("Credentials not redacted: %s", data)
+	}
+	if config.Password != "hunter2" {
+		t.Fatal("Redacted views should not change the configuration")
+	}
+	yamlData, _ := yaml.Marshal(config)
+	xmlData, _ := xml.Marshal(config)
+	for _, text := range []string{string(yamlData), string(xmlData)} {
+		if strings.Contains(text, "hunter") || !strings.Contains(text, "*****") {
+			t.Fatalf("Credentials not redacted: %s", text)
+		}
+	}
+	if data, err = json.Marshal(PlainDbConfig(config)); err != nil {
+		t.Fatalf("Encoding error occured: %v", err)
+	}
+	var decoded DbConfig
+	if err = json.Unmarshal(data, &decoded); err != nil || !reflect.DeepEqual(decoded, config) {
+		t.Fatalf("Wrong plain configuration encoding: %s %v", data, err)
+	}
+}
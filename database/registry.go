@@ -0,0 +1,152 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Driver factory function type
+type DriverFactory func() Driver
+
+type driverEntry struct {
+	name    string
+	aliases []string
+	dType   DriverType
+	factory DriverFactory
+}
+
+var (
+	registryMutex sync.RWMutex
+	// Registered drivers by canonical name
+	registryDrivers = make(map[string]*driverEntry)
+	// Canonical driver name by name or alias
+	registryNames = make(map[string]string)
+)
+
+// Register a driver factory with a canonical name and an optional list of aliases.
+// Names and aliases are case insensitive and must be unique across all registered drivers.
+// Drivers are expected to register themselves from the package init() function.
+func Register(name string, aliases []string, factory func() Driver) error {
+	return RegisterType(name, DriverType(0), aliases, factory)
+}
+
+// Register a driver factory as Register, with the DriverType resolved by DriverToType and TypeToDriver. The types
+// different from zero must be unique across all registered drivers, the in-house drivers may use the values not
+// defined by this package.
+func RegisterType(name string, dType DriverType, aliases []string, factory func() Driver) error {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if key == "" {
+		return errors.New(fmt.Sprint("Driver name cannot be empty"))
+	}
+	if factory == nil {
+		return errors.New(fmt.Sprintf("Driver factory for %s cannot be nil", name))
+	}
+	var keys = []string{key}
+	for _, alias := range aliases {
+		aliasKey := strings.ToLower(strings.TrimSpace(alias))
+		if aliasKey == "" || aliasKey == key {
+			continue
+		}
+		keys = append(keys, aliasKey)
+	}
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	for _, k := range keys {
+		if owner, ok := registryNames[k]; ok {
+			return errors.New(fmt.Sprintf("Driver name %s already registered by driver: %s", k, owner))
+		}
+	}
+	for _, entry := range registryDrivers {
+		if dType != DriverType(0) && entry.dType == dType {
+			return errors.New(fmt.Sprintf("Driver type %v already registered by driver: %s", dType, entry.name))
+		}
+	}
+	for _, k := range keys {
+		registryNames[k] = key
+	}
+	registryDrivers[key] = &driverEntry{
+		name:    key,
+		aliases: keys[1:],
+		dType:   dType,
+		factory: factory,
+	}
+	return nil
+}
+
+// Unregister a driver and all its aliases, by name or alias
+func Unregister(name string) error {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	key, ok := registryNames[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return errors.New(fmt.Sprintf("Unknown Database Driver: %s", name))
+	}
+	entry := registryDrivers[key]
+	delete(registryDrivers, key)
+	delete(registryNames, key)
+	for _, alias := range entry.aliases {
+		delete(registryNames, alias)
+	}
+	return nil
+}
+
+// Retrieves the canonical name of a registered driver, by name or alias
+func DriverName(name string) (string, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	key, ok := registryNames[strings.ToLower(strings.TrimSpace(name))]
+	return key, ok
+}
+
+// Converts a registered driver name or alias to the DriverType of the driver registration, zero for the unknown
+// drivers and the drivers registered without type
+func DriverToType(driver string) DriverType {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	if entry := registryDrivers[registryNames[strings.ToLower(strings.TrimSpace(driver))]]; entry != nil {
+		return entry.dType
+	}
+	return DriverType(0)
+}
+
+// Converts a DriverType to the canonical name of the driver registered with the type, empty when not registered
+func TypeToDriver(dType DriverType) string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	for name, entry := range registryDrivers {
+		if dType != DriverType(0) && entry.dType == dType {
+			return name
+		}
+	}
+	return ""
+}
+
+// Creates a new instance of a registered driver, by name or alias
+func GetDriver(name string) (Driver, error) {
+	registryMutex.RLock()
+	key, ok := registryNames[strings.ToLower(strings.TrimSpace(name))]
+	var entry *driverEntry
+	if ok {
+		entry = registryDrivers[key]
+	}
+	registryMutex.RUnlock()
+	if entry == nil {
+		return nil, errors.New(fmt.Sprintf("Unknown Database Driver: %s", name))
+	}
+	return entry.factory(), nil
+}
+
+// Lists the canonical names of all registered drivers, in alphabetical order
+func Drivers() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	var names = make([]string, 0, len(registryDrivers))
+	for name := range registryDrivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"os"
+	"testing"
+)
+
+type testDriver struct{}
+
+func (d *testDriver) Connect(config DbConfig) (Connection, error) {
+	return nil, nil
+}
+
+func TestMain(m *testing.M) {
+	// The configuration tests validate the built-in driver names, registered by the driver packages
+	var builtIn = map[string]DriverType{"mongodb": MongoDbDriver, "mysql": MySQLDriver, "postgres": PostgreSQLDriver, "sqlite": SQLiteDriver}
+	for name, dType := range builtIn {
+		if err := RegisterType(name, dType, nil, func() Driver { return &testDriver{} }); err != nil {
+			panic(err)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+func TestRegisterType(t *testing.T) {
+	const customDriver = DriverType(100)
+	err := RegisterType("custom", customDriver, []string{"cst"}, func() Driver { return &testDriver{} })
+	if err != nil {
+		t.Fatalf("Driver registration error occured: %v", err)
+	}
+	defer func() {
+		_ = Unregister("custom")
+	}()
+	if DriverToType("CST") != customDriver || TypeToDriver(customDriver) != "custom" {
+		t.Fatalf("Wrong registered driver type: %v %s", DriverToType("cst"), TypeToDriver(customDriver))
+	}
+	if DriverToType("unknown") != DriverType(0) || TypeToDriver(MemoryDriver) != "" {
+		t.Fatal("Unregistered drivers should have no type")
+	}
+	if err = RegisterType("other", customDriver, nil, func() Driver { return &testDriver{} }); err == nil {
+		t.Fatal("Duplicate type registration should fail")
+	}
+	if err = Unregister("custom"); err != nil || TypeToDriver(customDriver) != "" {
+		t.Fatalf("Unregistered driver type should not be available: %v", err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	err := Register("Test-Driver", []string{"test", "tst"}, func() Driver { return &testDriver{} })
+	if err != nil {
+		t.Fatalf("Driver registration error occured: %v", err)
+	}
+	defer func() {
+		_ = Unregister("test-driver")
+	}()
+	for _, name := range []string{"test-driver", "TEST", "tst"} {
+		driver, err := GetDriver(name)
+		if err != nil {
+			t.Fatalf("Driver %s lookup error occured: %v", name, err)
+		}
+		if _, ok := driver.(*testDriver); !ok {
+			t.Fatalf("Wrong driver type for name %s: %T", name, driver)
+		}
+	}
+	var found bool
+	for _, name := range Drivers() {
+		if name == "test-driver" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("Driver test-driver not listed in drivers: %v", Drivers())
+	}
+	err = Register("other", []string{"tst"}, func() Driver { return &testDriver{} })
+	if err == nil {
+		t.Fatal("Duplicate alias registration should fail")
+	}
+	if _, ok := DriverName("other"); ok {
+		t.Fatal("Failed registration should not register the driver name")
+	}
+	err = Register("test-driver", nil, func() Driver { return &testDriver{} })
+	if err == nil {
+		t.Fatal("Duplicate name registration should fail")
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	err := Register("removable", []string{"rm"}, func() Driver { return &testDriver{} })
+	if err != nil {
+		t.Fatalf("Driver registration error occured: %v", err)
+	}
+	err = Unregister("rm")
+	if err != nil {
+		t.Fatalf("Driver unregistration error occured: %v", err)
+	}
+	if _, err = GetDriver("removable"); err == nil {
+		t.Fatal("Unregistered driver should not be available")
+	}
+	if _, err = GetDriver("rm"); err == nil {
+		t.Fatal("Unregistered driver alias should not be available")
+	}
+}
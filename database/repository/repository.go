@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+	"reflect"
+	"strings"
+)
+
+// Entity not found error, the database ErrNotFound
+var ErrNotFound = database.ErrNotFound
+
+// Records operations executor interface, implemented by database.Connection and database.Tx
+type Executor interface {
+	Stream(ctx context.Context, dbRef database.DataRef, fields []string, filter database.Filter, options database.QueryOptions) (database.Rows, error)
+	InsertContext(ctx context.Context, dbRef database.DataRef, fields []database.Field, values []database.Value) error
+	InsertBatchContext(ctx context.Context, dbRef database.DataRef, fields []database.Field, rows [][]database.Value, options database.BatchOptions) ([]database.BatchResult, error)
+	UpdateFilter(ctx context.Context, dbRef database.DataRef, filter database.Filter, fields []database.Field, values []database.Value) (int64, error)
+	DeleteFilter(ctx context.Context, dbRef database.DataRef, filter database.Filter) (int64, error)
+	Count(ctx context.Context, dbRef database.DataRef, filter database.Filter) (int64, error)
+}
+
+// Typed repository of the T struct entities. Entity fields are mapped as database.ScanInto does, the table or
+// collection name is read from the table tag of a blank field, falling back to the lowercase struct name, and the
+// primary key is the field with the pk tag option, falling back to the id or _id field:
+//
+//	type User struct {
+//		_     struct{} `table:"users"`
+//		ID    int64    `db:"id,pk,omitempty"`
+//		Email string   `db:"email"`
+//	}
+type Repository[T any] struct {
+	executor Executor
+	dbRef    database.DataRef
+	key      database.FieldMapping
+}
+
+// Returns the table tag of a blank field or the lowercase struct name
+func tableName(t reflect.Type) string {
+	for i := 0; i < t.NumField(); i++ {
+		if name, ok := t.Field(i).Tag.Lookup("table"); ok && name != "" {
+			return name
+		}
+	}
+	return strings.ToLower(t.Name())
+}
+
+// Returns the field with the pk tag option or the id or _id field
+func primaryKey(t reflect.Type) (database.FieldMapping, bool) {
+	mapping := database.StructMapping(t)
+	for _, m := range mapping {
+		if m.HasOption("pk") {
+			return m, true
+		}
+	}
+	for _, m := range mapping {
+		if strings.EqualFold(m.Name, "id") || m.Name == "_id" {
+			return m, true
+		}
+	}
+	return database.FieldMapping{}, false
+}
+
+// Creates the repository of the T struct entities, the data reference Namespace overrides the entity table tag
+func New[T any](executor Executor, dbRef database.DataRef) (*Repository[T], error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return nil, errors.New(fmt.Sprintf("Repository entity must be a struct: %s", t))
+	}
+	key, ok := primaryKey(t)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("Repository entity %s has no primary key, please tag a field with the pk option", t))
+	}
+	if dbRef.Namespace == "" {
+		dbRef.Namespace = tableName(t)
+	}
+	return &Repository[T]{
+		executor: executor,
+		dbRef:    dbRef,
+		key:      key,
+	}, nil
+}
+
+// Returns a repository of the same entities executing the operations with the given executor, e.g. a transaction
+func (r *Repository[T]) With(executor Executor) *Repository[T] {
+	return &Repository[T]{
+		executor: executor,
+		dbRef:    r.dbRef,
+		key:      r.key,
+	}
+}
+
+// Returns the entities data reference
+func (r *Repository[T]) DataRef() database.DataRef {
+	return r.dbRef
+}
+
+func (r *Repository[T]) keyFilter(id interface{}) database.Filter {
+	return database.Leaf(database.Condition{
+		Field:     r.key.Name,
+		Operation: database.Equals,
+		Value:     database.Value{Value: id},
+	})
+}
+
+// Returns the entity primary key value, dereferencing pointers, and false when holding the zero value
+func (r *Repository[T]) keyValue(entity *T) (interface{}, bool) {
+	v := reflect.ValueOf(entity).Elem().FieldByIndex(r.key.Index)
+	if v.IsZero() {
+		return nil, false
+	}
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	return v.Interface(), true
+}
+
+func (r *Repository[T]) find(ctx context.Context, fields []string, filter database.Filter, options database.QueryOptions) ([]T, error) {
+	rows, err := r.executor.Stream(ctx, r.dbRef, fields, filter, options)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var entities = make([]T, 0)
+	for rows.Next() {
+		var entity T
+		if err = rows.Scan(&entity); err != nil {
+			return nil, err
+		}
+		entities = append(entities, entity)
+	}
+	return entities, rows.Err()
+}
+
+// Returns the entity with the given primary key, or ErrNotFound
+func (r *Repository[T]) FindByID(ctx context.Context, id interface{}) (*T, error) {
+	entities, err := r.find(ctx, []string{}, r.keyFilter(id), database.QueryOptions{Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(entities) == 0 {
+		return nil, ErrNotFound
+	}
+	return &entities[0], nil
+}
+
+// Returns the entities matching the filter, sorted and paged following the query options
+func (r *Repository[T]) FindAll(ctx context.Context, filter database.Filter, options database.QueryOptions) ([]T, error) {
+	return r.find(ctx, []string{}, filter, options)
+}
+
+// Inserts the entity when the primary key holds the zero value, otherwise upserts the entity on the primary key in a
+// single statement: the entity having the same primary key is updated, MongoDB replacing the whole document. Generated
+// primary keys are not read back in the entity.
+func (r *Repository[T]) Save(ctx context.Context, entity *T) error {
+	if entity == nil {
+		return errors.New(fmt.Sprint("Repository entity is nil"))
+	}
+	fields, values, err := database.FieldValues(entity)
+	if err != nil {
+		return err
+	}
+	if _, ok := r.keyValue(entity); !ok {
+		return r.executor.InsertContext(ctx, r.dbRef, fields, values)
+	}
+	_, err = r.executor.InsertBatchContext(ctx, r.dbRef, fields, [][]database.Value{values}, database.BatchOptions{
+		Upsert:    true,
+		KeyFields: []string{r.key.Name},
+	})
+	if len(fields) == 1 && errors.Is(err, database.ErrDuplicateKey) {
+		// Entities holding the primary key only have no field to update, MySQL inserts them without upsert
+		return nil
+	}
+	return err
+}
+
+// Deletes the entity with the given primary key, or returns ErrNotFound
+func (r *Repository[T]) Delete(ctx context.Context, id interface{}) error {
+	count, err := r.executor.DeleteFilter(ctx, r.dbRef, r.keyFilter(id))
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Counts the entities matching the filter
+func (r *Repository[T]) Count(ctx context.Context, filter database.Filter) (int64, error) {
+	return r.executor.Count(ctx, r.dbRef, filter)
+}
+
+// Verifies an entity with the given primary key exists
+func (r *Repository[T]) Exists(ctx context.Context, id interface{}) (bool, error) {
+	rows, err := r.executor.Stream(ctx, r.dbRef, []string{r.key.Name}, r.keyFilter(id), database.QueryOptions{Limit: 1})
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	found := rows.Next()
+	return found, rows.Err()
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"github.com/hellgate75/go-services/database"
+	"github.com/hellgate75/go-services/database/memory"
+	"github.com/hellgate75/go-services/database/sqlite"
+	"testing"
+)
+
+type User struct {
+	_     struct{} `table:"users"`
+	ID    int64    `db:"id,pk,omitempty"`
+	Name  string   `db:"name"`
+	Email string   `db:"email"`
+	Age   int      `db:"age"`
+}
+
+func exercise(t *testing.T, conn database.Connection) {
+	ctx := context.Background()
+	repo, err := New[User](conn, database.DataRef{})
+	if err != nil {
+		t.Fatalf("Repository creation error occured: %v", err)
+	}
+	if repo.DataRef().Namespace != "users" {
+		t.Fatalf("Wrong repository namespace: %v", repo.DataRef().Namespace)
+	}
+	for _, u := range []User{
+		{ID: 1, Name: "Fabrizio", Email: "fabrizio@example.com", Age: 45},
+		{ID: 2, Name: "Francesco", Email: "francesco@example.com", Age: 42},
+		{ID: 3, Name: "Mario", Email: "mario@example.com", Age: 30},
+	} {
+		user := u
+		if err = repo.Save(ctx, &user); err != nil {
+			t.Fatalf("Repository save error occured: %v", err)
+		}
+	}
+	user, err := repo.FindByID(ctx, int64(2))
+	if err != nil {
+		t.Fatalf("Repository find error occured: %v", err)
+	}
+	if user.Name != "Francesco" || user.Age != 42 {
+		t.Fatalf("Wrong entity: %+v", *user)
+	}
+	user.Age = 43
+	if err = repo.Save(ctx, user); err != nil {
+		t.Fatalf("Repository save error occured: %v", err)
+	}
+	count, err := repo.Count(ctx, database.Filter{})
+	if err != nil {
+		t.Fatalf("Repository count error occured: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("Wrong number of entities: %v", count)
+	}
+	users, err := repo.FindAll(ctx, database.Leaf(database.Condition{
+		Field:     "age",
+		Operation: database.GraterThan,
+		Value:     database.Value{Value: 40},
+	}), database.QueryOptions{OrderBy: []database.OrderBy{{Field: "age", Direction: database.Descending}}})
+	if err != nil {
+		t.Fatalf("Repository find error occured: %v", err)
+	}
+	if len(users) != 2 || users[0].Name != "Fabrizio" || users[1].Age != 43 {
+		t.Fatalf("Wrong entities: %+v", users)
+	}
+	if err = repo.Delete(ctx, int64(3)); err != nil {
+		t.Fatalf("Repository delete error occured: %v", err)
+	}
+	if err = repo.Delete(ctx, int64(3)); err != ErrNotFound {
+		t.Fatalf("Expected not found error but was: %v", err)
+	}
+	if _, err = repo.FindByID(ctx, int64(3)); err != ErrNotFound {
+		t.Fatalf("Expected not found error but was: %v", err)
+	}
+	exists, err := repo.Exists(ctx, int64(1))
+	if err != nil {
+		t.Fatalf("Repository exists error occured: %v", err)
+	}
+	if !exists {
+		t.Fatal("Entity 1 should exist")
+	}
+}
+
+func TestMemoryRepository(t *testing.T) {
+	conn, err := memory.GetMemoryDriver().Connect(database.DbConfig{})
+	if err != nil {
+		t.Fatalf("Connection error occured: %v", err)
+	}
+	if err = conn.Create(database.DataRef{Namespace: "users"}, []database.Field{}); err != nil {
+		t.Fatalf("Database collection creation error occured: %v", err)
+	}
+	exercise(t, conn)
+}
+
+func TestSqliteRepository(t *testing.T) {
+	conn, err := sqlite.GetSqliteDriver().Connect(database.DbConfig{Url: sqlite.MemoryDatabase})
+	if err != nil {
+		t.Fatalf("Connection error occured: %v", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	err = conn.Create(database.DataRef{Namespace: "users"}, []database.Field{
+		{Name: "id", Type: "integer", PrimaryKey: true},
+		{Name: "name", Type: "varchar", Size: 50},
+		{Name: "email", Type: "varchar", Size: 100},
+		{Name: "age", Type: "integer"},
+	})
+	if err != nil {
+		t.Fatalf("Database table creation error occured: %v", err)
+	}
+	exercise(t, conn)
+}
+
+func TestNewWithoutPrimaryKey(t *testing.T) {
+	type Note struct {
+		Text string `db:"text"`
+	}
+	if _, err := New[Note](nil, database.DataRef{}); err == nil {
+		t.Fatal("Expected missing primary key error")
+	}
+}
@@ -0,0 +1,605 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Driver specific transient errors classifier, implemented by the driver connections
+type TransientErrorClassifier interface {
+	// Verifies the error is transient, the operation may succeed when retried, possibly on a new connection
+	IsTransient(err error) bool
+}
+
+// Configuration aware Connection interface, implemented by the driver connections
+type ConfigConnection interface {
+	// Returns the configuration used to connect, with the Driver name
+	Config() DbConfig
+}
+
+// Verifies the error is caused by a broken network connection
+func IsNetworkError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.As(err, &netErr)
+}
+
+// Reconnection event structure
+type ReconnectEvent struct {
+	// Transient error breaking the connection
+	Cause error
+	// Connection configuration Driver name, when available
+	Driver string
+	// Reconnection error, nil when the connection has been replaced
+	Error error
+	// Reconnection time
+	Time time.Time
+}
+
+// Retry policy structure, zero values take the defaults
+type RetryPolicy struct {
+	// Maximum number of attempts of the idempotent operations, 3 when not positive
+	MaxAttempts int
+	// Delay before the second attempt, 100ms when not positive
+	InitialBackoff time.Duration
+	// Maximum delay between attempts, 5s when not positive
+	MaxBackoff time.Duration
+	// Delay multiplier applied at each attempt, 2 when lower than 1
+	Multiplier float64
+	// Random fraction of the delay added or removed, 0.2 when not in the (0, 1] range
+	Jitter float64
+	// Transient errors classifier, the driver connection one or IsNetworkError when not provided
+	Retryable func(err error) bool
+	// Creates the replacement connection, the original Driver.Connect with the connection DbConfig when not provided
+	Dial func() (Connection, error)
+	// Receives the reconnection events
+	OnReconnect func(event ReconnectEvent)
+}
+
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts <= 0 {
+		return 3
+	}
+	return p.MaxAttempts
+}
+
+// Returns the jittered exponential delay after the given failed attempt
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	initial, max, multiplier, jitter := p.InitialBackoff, p.MaxBackoff, p.Multiplier, p.Jitter
+	if initial <= 0 {
+		initial = 100 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 5 * time.Second
+	}
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	if jitter <= 0 || jitter > 1 {
+		jitter = 0.2
+	}
+	delay := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if delay > float64(max) {
+		delay = float64(max)
+	}
+	delay += delay * jitter * (2*rand.Float64() - 1)
+	return time.Duration(delay)
+}
+
+// Waits the delay after the given failed attempt, or until the context is done
+func (p RetryPolicy) wait(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(p.Backoff(attempt))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type resilientConnection struct {
+	sync.RWMutex
+	conn   Connection
+	policy RetryPolicy
+	closed bool
+	err    error
+	// Set while a caller dials the replacement connection
+	dialing bool
+	// Incremented at each connection replacement
+	generation uint64
+	// Running operations, open Rows and open transactions by connection, replaced connections are closed when
+	// their count drops to zero
+	inflight map[Connection]int
+	// Closed when the connection is closed, stopping the secrets watcher
+	stop chan struct{}
+	// Secrets resolved by the last connection of ConnectRotating
+	resolved DbConfig
+}
+
+// Wraps the connection to replace it when broken by transient errors, retrying the idempotent operations with
+// jittered exponential backoff. Queries, updates, deletes, purges, upserts and Begin are retried, while inserts
+// and schema operations are not but still replace the broken connection for the following operations.
+func Resilient(conn Connection, policy RetryPolicy) Connection {
+	return newResilient(conn, policy)
+}
+
+func newResilient(conn Connection, policy RetryPolicy) *resilientConnection {
+	return &resilientConnection{
+		conn:     conn,
+		policy:   policy,
+		inflight: make(map[Connection]int),
+	}
+}
+
+func (r *resilientConnection) current() (Connection, error) {
+	r.RLock()
+	defer r.RUnlock()
+	if r.closed || r.conn == nil {
+		return nil, ErrClosed
+	}
+	return r.conn, nil
+}
+
+// Returns the current connection, counted as in use until released
+func (r *resilientConnection) acquire() (Connection, error) {
+	r.Lock()
+	defer r.Unlock()
+	if r.closed || r.conn == nil {
+		return nil, ErrClosed
+	}
+	r.inflight[r.conn]++
+	return r.conn, nil
+}
+
+// Counts the acquired connection as in use once more, e.g. by the Rows or the transaction opened by the operation,
+// returning the function releasing it
+func (r *resilientConnection) retain(conn Connection) func() {
+	r.Lock()
+	r.inflight[conn]++
+	r.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.release(conn)
+		})
+	}
+}
+
+// Releases the acquired connection, closing it when it has been replaced and is no longer in use
+func (r *resilientConnection) release(conn Connection) {
+	r.Lock()
+	r.inflight[conn]--
+	drained := r.inflight[conn] <= 0
+	if drained {
+		delete(r.inflight, conn)
+	}
+	retired := drained && conn != r.conn
+	r.Unlock()
+	if retired {
+		_ = conn.Close()
+	}
+}
+
+func (r *resilientConnection) transient(conn Connection, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if r.policy.Retryable != nil {
+		return r.policy.Retryable(err)
+	}
+	if classifier, ok := conn.(TransientErrorClassifier); ok {
+		return classifier.IsTransient(err)
+	}
+	return IsNetworkError(err)
+}
+
+func (r *resilientConnection) dial(broken Connection) (Connection, error) {
+	if r.policy.Dial != nil {
+		return r.policy.Dial()
+	}
+	configurable, ok := broken.(ConfigConnection)
+	if !ok {
+		return nil, errors.New(fmt.Sprint("Unable to reconnect, please provide the retry policy Dial function"))
+	}
+	config := configurable.Config()
+	d, err := GetDriver(config.Driver)
+	if err != nil {
+		return nil, err
+	}
+	return d.Connect(config)
+}
+
+// Replaces the broken connection, unless already replaced, being replaced by another caller or closed. The
+// replacement is dialed without holding the lock, so the other operations are not blocked by the dial.
+func (r *resilientConnection) reconnect(broken Connection, cause error) {
+	r.Lock()
+	if r.closed || r.dialing || r.conn != broken {
+		r.Unlock()
+		return
+	}
+	r.dialing = true
+	generation := r.generation
+	r.Unlock()
+	event := ReconnectEvent{
+		Cause: cause,
+		Time:  time.Now(),
+	}
+	if configurable, ok := broken.(ConfigConnection); ok {
+		event.Driver = configurable.Config().Driver
+	}
+	conn, err := r.dial(broken)
+	r.Lock()
+	r.dialing = false
+	replaced := err == nil && !r.closed && r.generation == generation
+	drained := false
+	if replaced {
+		r.conn = conn
+		r.generation++
+		// In use connections are closed by the last release
+		drained = r.inflight[broken] == 0
+	}
+	r.Unlock()
+	if drained {
+		_ = broken.Close()
+	} else if err == nil && !replaced {
+		// Closed while dialing
+		_ = conn.Close()
+		return
+	}
+	event.Error = err
+	if r.policy.OnReconnect != nil {
+		r.policy.OnReconnect(event)
+	}
+}
+
+// Runs the operation, replacing the connection on transient errors and retrying the idempotent operations
+func (r *resilientConnection) do(ctx context.Context, idempotent bool, op func(conn Connection) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for attempt := 1; ; attempt++ {
+		conn, err := r.acquire()
+		if err != nil {
+			return err
+		}
+		err = op(conn)
+		r.Lock()
+		r.err = err
+		r.Unlock()
+		r.release(conn)
+		if err == nil || !r.transient(conn, err) {
+			return err
+		}
+		r.reconnect(conn, err)
+		if !idempotent || attempt >= r.policy.attempts() {
+			return err
+		}
+		if r.policy.wait(ctx, attempt) != nil {
+			return err
+		}
+	}
+}
+
+func (r *resilientConnection) QueryContext(ctx context.Context, dbRef DataRef, fields []string, conditions []Condition, withAnd bool) (ResultSet, error) {
+	var rs ResultSet
+	err := r.do(ctx, true, func(conn Connection) error {
+		var err error
+		rs, err = conn.QueryContext(ctx, dbRef, fields, conditions, withAnd)
+		return err
+	})
+	return rs, err
+}
+
+func (r *resilientConnection) InsertContext(ctx context.Context, dbRef DataRef, fields []Field, values []Value) error {
+	return r.do(ctx, false, func(conn Connection) error {
+		return conn.InsertContext(ctx, dbRef, fields, values)
+	})
+}
+
+func (r *resilientConnection) UpdateContext(ctx context.Context, dbRef DataRef, conditions []Condition, fields []Field, values []Value, withAnd bool) (int64, error) {
+	var count int64
+	err := r.do(ctx, true, func(conn Connection) error {
+		var err error
+		count, err = conn.UpdateContext(ctx, dbRef, conditions, fields, values, withAnd)
+		return err
+	})
+	return count, err
+}
+
+func (r *resilientConnection) DeleteContext(ctx context.Context, dbRef DataRef, conditions []Condition, withAnd bool) (int64, error) {
+	var count int64
+	err := r.do(ctx, true, func(conn Connection) error {
+		var err error
+		count, err = conn.DeleteContext(ctx, dbRef, conditions, withAnd)
+		return err
+	})
+	return count, err
+}
+
+func (r *resilientConnection) PurgeContext(ctx context.Context, dbRef DataRef) (int64, error) {
+	var count int64
+	err := r.do(ctx, true, func(conn Connection) error {
+		var err error
+		count, err = conn.PurgeContext(ctx, dbRef)
+		return err
+	})
+	return count, err
+}
+
+func (r *resilientConnection) CreateContext(ctx context.Context, dbRef DataRef, fields []Field) error {
+	return r.do(ctx, false, func(conn Connection) error {
+		return conn.CreateContext(ctx, dbRef, fields)
+	})
+}
+
+func (r *resilientConnection) CreateDbContext(ctx context.Context, dbRef DataRef) error {
+	return r.do(ctx, false, func(conn Connection) error {
+		return conn.CreateDbContext(ctx, dbRef)
+	})
+}
+
+func (r *resilientConnection) DropContext(ctx context.Context, dbRef DataRef) error {
+	return r.do(ctx, false, func(conn Connection) error {
+		return conn.DropContext(ctx, dbRef)
+	})
+}
+
+func (r *resilientConnection) DropDbContext(ctx context.Context, dbRef DataRef) error {
+	return r.do(ctx, false, func(conn Connection) error {
+		return conn.DropDbContext(ctx, dbRef)
+	})
+}
+
+func (r *resilientConnection) QueryFilter(ctx context.Context, dbRef DataRef, fields []string, filter Filter) (ResultSet, error) {
+	var rs ResultSet
+	err := r.do(ctx, true, func(conn Connection) error {
+		var err error
+		rs, err = conn.QueryFilter(ctx, dbRef, fields, filter)
+		return err
+	})
+	return rs, err
+}
+
+func (r *resilientConnection) QueryPage(ctx context.Context, dbRef DataRef, fields []string, filter Filter, options QueryOptions) (ResultSet, error) {
+	var rs ResultSet
+	err := r.do(ctx, true, func(conn Connection) error {
+		var err error
+		rs, err = conn.QueryPage(ctx, dbRef, fields, filter, options)
+		return err
+	})
+	return rs, err
+}
+
+func (r *resilientConnection) UpdateFilter(ctx context.Context, dbRef DataRef, filter Filter, fields []Field, values []Value) (int64, error) {
+	var count int64
+	err := r.do(ctx, true, func(conn Connection) error {
+		var err error
+		count, err = conn.UpdateFilter(ctx, dbRef, filter, fields, values)
+		return err
+	})
+	return count, err
+}
+
+func (r *resilientConnection) DeleteFilter(ctx context.Context, dbRef DataRef, filter Filter) (int64, error) {
+	var count int64
+	err := r.do(ctx, true, func(conn Connection) error {
+		var err error
+		count, err = conn.DeleteFilter(ctx, dbRef, filter)
+		return err
+	})
+	return count, err
+}
+
+func (r *resilientConnection) Count(ctx context.Context, dbRef DataRef, filter Filter) (int64, error) {
+	var count int64
+	err := r.do(ctx, true, func(conn Connection) error {
+		var err error
+		count, err = conn.Count(ctx, dbRef, filter)
+		return err
+	})
+	return count, err
+}
+
+// Opens the records iterator, retrying on transient errors, while iteration errors are returned by the Rows
+func (r *resilientConnection) Stream(ctx context.Context, dbRef DataRef, fields []string, filter Filter, options QueryOptions) (Rows, error) {
+	var rows Rows
+	err := r.do(ctx, true, func(conn Connection) error {
+		var err error
+		rows, err = conn.Stream(ctx, dbRef, fields, filter, options)
+		if err == nil {
+			rows = &releasingRows{Rows: rows, release: r.retain(conn)}
+		}
+		return err
+	})
+	return rows, err
+}
+
+// Starts a transaction, retrying on transient errors, while the transaction operations are not retried
+func (r *resilientConnection) Begin(ctx context.Context, options TxOptions) (Tx, error) {
+	var tx Tx
+	err := r.do(ctx, true, func(conn Connection) error {
+		var err error
+		tx, err = conn.Begin(ctx, options)
+		if err == nil {
+			tx = &releasingTx{Tx: tx, release: r.retain(conn)}
+		}
+		return err
+	})
+	return tx, err
+}
+
+// Rows keeping the connection in use until closed
+type releasingRows struct {
+	Rows
+	release func()
+}
+
+func (rows *releasingRows) Close() error {
+	defer rows.release()
+	return rows.Rows.Close()
+}
+
+// Transaction keeping the connection in use until committed or rolled back
+type releasingTx struct {
+	Tx
+	release func()
+}
+
+func (tx *releasingTx) Commit() error {
+	defer tx.release()
+	return tx.Tx.Commit()
+}
+
+func (tx *releasingTx) Rollback() error {
+	defer tx.release()
+	return tx.Tx.Rollback()
+}
+
+// Runs the function in a transaction of the current connection, replacing it on transient errors without
+// running the function again
+func (r *resilientConnection) RunInTx(ctx context.Context, options TxOptions, fn func(tx Tx) error) error {
+	return r.do(ctx, false, func(conn Connection) error {
+		return RunInTxContext(ctx, conn, options, fn)
+	})
+}
+
+func (r *resilientConnection) InsertBatch(dbRef DataRef, fields []Field, rows [][]Value, options BatchOptions) ([]BatchResult, error) {
+	return r.InsertBatchContext(context.Background(), dbRef, fields, rows, options)
+}
+
+// Inserts the rows in batches, retrying on transient errors only on upsert
+func (r *resilientConnection) InsertBatchContext(ctx context.Context, dbRef DataRef, fields []Field, rows [][]Value, options BatchOptions) ([]BatchResult, error) {
+	var results []BatchResult
+	err := r.do(ctx, options.Upsert, func(conn Connection) error {
+		var err error
+		results, err = conn.InsertBatchContext(ctx, dbRef, fields, rows, options)
+		return err
+	})
+	return results, err
+}
+
+// Executes the script without retrying, as scripts may be partially applied
+func (r *resilientConnection) ExecScript(ctx context.Context, dbRef DataRef, script string) error {
+	return r.do(ctx, false, func(conn Connection) error {
+		return conn.ExecScript(ctx, dbRef, script)
+	})
+}
+
+// Returns the current connection configuration, when available
+func (r *resilientConnection) Config() DbConfig {
+	conn, err := r.current()
+	if err != nil {
+		return DbConfig{}
+	}
+	if configurable, ok := conn.(ConfigConnection); ok {
+		return configurable.Config()
+	}
+	return DbConfig{}
+}
+
+func (r *resilientConnection) Stats() PoolStats {
+	conn, err := r.current()
+	if err != nil {
+		return PoolStats{}
+	}
+	return conn.Stats()
+}
+
+// Pings the database server, replacing the connection on transient errors without retrying
+func (r *resilientConnection) Ping(ctx context.Context) error {
+	return r.do(ctx, false, func(conn Connection) error {
+		return conn.Ping(ctx)
+	})
+}
+
+func (r *resilientConnection) ServerVersion(ctx context.Context) (string, error) {
+	var version string
+	err := r.do(ctx, true, func(conn Connection) error {
+		var err error
+		version, err = conn.ServerVersion(ctx)
+		return err
+	})
+	return version, err
+}
+
+func (r *resilientConnection) Query(dbRef DataRef, fields []string, conditions []Condition, withAnd bool) (ResultSet, error) {
+	return r.QueryContext(context.Background(), dbRef, fields, conditions, withAnd)
+}
+
+func (r *resilientConnection) Insert(dbRef DataRef, fields []Field, values []Value) error {
+	return r.InsertContext(context.Background(), dbRef, fields, values)
+}
+
+func (r *resilientConnection) Update(dbRef DataRef, conditions []Condition, fields []Field, values []Value, withAnd bool) (int64, error) {
+	return r.UpdateContext(context.Background(), dbRef, conditions, fields, values, withAnd)
+}
+
+func (r *resilientConnection) Delete(dbRef DataRef, conditions []Condition, withAnd bool) (int64, error) {
+	return r.DeleteContext(context.Background(), dbRef, conditions, withAnd)
+}
+
+func (r *resilientConnection) Purge(dbRef DataRef) (int64, error) {
+	return r.PurgeContext(context.Background(), dbRef)
+}
+
+func (r *resilientConnection) Create(dbRef DataRef, fields []Field) error {
+	return r.CreateContext(context.Background(), dbRef, fields)
+}
+
+func (r *resilientConnection) CreateDb(dbRef DataRef) error {
+	return r.CreateDbContext(context.Background(), dbRef)
+}
+
+func (r *resilientConnection) Drop(dbRef DataRef) error {
+	return r.DropContext(context.Background(), dbRef)
+}
+
+func (r *resilientConnection) DropDb(dbRef DataRef) error {
+	return r.DropDbContext(context.Background(), dbRef)
+}
+
+// Closes the current connection, the closed resilient connection is not reconnected
+func (r *resilientConnection) Close() error {
+	r.Lock()
+	defer r.Unlock()
+	if r.closed {
+		return ErrClosed
+	}
+	r.closed = true
+	if r.stop != nil {
+		close(r.stop)
+	}
+	return r.conn.Close()
+}
+
+func (r *resilientConnection) IsConnected() bool {
+	conn, err := r.current()
+	return err == nil && conn.IsConnected()
+}
+
+func (r *resilientConnection) GetLastError() error {
+	r.RLock()
+	defer r.RUnlock()
+	return r.err
+}
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type flakyConnection struct {
+	Connection
+	name     string
+	failures int
+	calls    int
+	closed   bool
+}
+
+func (c *flakyConnection) fail() error {
+	c.calls++
+	if c.calls <= c.failures {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func (c *flakyConnection) QueryContext(ctx context.Context, dbRef DataRef, fields []string, conditions []Condition, withAnd bool) (ResultSet, error) {
+	if err := c.fail(); err != nil {
+		return ResultSet{}, err
+	}
+	return ResultSet{Lines: 1, MetaData: MetaData{EntityRef: DataRef{Namespace: c.name}}}, nil
+}
+
+func (c *flakyConnection) Stream(ctx context.Context, dbRef DataRef, fields []string, filter Filter, options QueryOptions) (Rows, error) {
+	rs, err := c.QueryContext(ctx, dbRef, fields, []Condition{}, true)
+	if err != nil {
+		return nil, err
+	}
+	return NewResultRows(rs), nil
+}
+
+func (c *flakyConnection) InsertContext(ctx context.Context, dbRef DataRef, fields []Field, values []Value) error {
+	return c.fail()
+}
+
+func (c *flakyConnection) DeleteContext(ctx context.Context, dbRef DataRef, conditions []Condition, withAnd bool) (int64, error) {
+	return 0, errors.New("syntax error")
+}
+
+func (c *flakyConnection) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestResilient(t *testing.T) {
+	broken := &flakyConnection{name: "broken", failures: 100}
+	var dialed = make([]*flakyConnection, 0)
+	var events = make([]ReconnectEvent, 0)
+	conn := Resilient(broken, RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		Dial: func() (Connection, error) {
+			c := &flakyConnection{name: "replacement"}
+			if len(dialed) == 0 {
+				c.failures = 1
+			}
+			dialed = append(dialed, c)
+			return c, nil
+		},
+		OnReconnect: func(event ReconnectEvent) {
+			events = append(events, event)
+		},
+	})
+	rs, err := conn.Query(DataRef{}, []string{}, []Condition{}, true)
+	if err != nil {
+		t.Fatalf("Query error occured: %v", err)
+	}
+	if rs.MetaData.EntityRef.Namespace != "replacement" || broken.calls != 1 || !broken.closed {
+		t.Fatalf("Query should be retried on the replacement connection: %v %v", rs.MetaData.EntityRef.Namespace, broken.calls)
+	}
+	if len(dialed) != 2 || len(events) != 2 || events[0].Cause != driver.ErrBadConn || events[0].Error != nil {
+		t.Fatalf("Wrong reconnection events: %v", events)
+	}
+	dialed[1].failures = 2
+	if err = conn.Insert(DataRef{}, []Field{}, []Value{}); err != driver.ErrBadConn {
+		t.Fatalf("Insert should not be retried: %v", err)
+	}
+	if len(dialed) != 3 || dialed[1].calls != 2 {
+		t.Fatalf("Broken connection should be replaced after the insert: %v", len(dialed))
+	}
+	if _, err = conn.Delete(DataRef{}, []Condition{}, true); err == nil || len(dialed) != 3 {
+		t.Fatalf("Non transient errors should not be retried: %v", err)
+	}
+	if err = conn.Close(); err != nil {
+		t.Fatalf("Close error occured: %v", err)
+	}
+	if _, err = conn.Query(DataRef{}, []string{}, []Condition{}, true); err == nil || conn.IsConnected() {
+		t.Fatal("Closed connection should not be reconnected")
+	}
+}
+
+func TestResilientDialOutsideLock(t *testing.T) {
+	broken := &flakyConnection{name: "broken", failures: 100}
+	var dials int32
+	started, release := make(chan struct{}), make(chan struct{})
+	conn := Resilient(broken, RetryPolicy{
+		MaxAttempts: 1,
+		Dial: func() (Connection, error) {
+			if atomic.AddInt32(&dials, 1) == 1 {
+				close(started)
+			}
+			<-release
+			return &flakyConnection{name: "replacement"}, nil
+		},
+	}).(*resilientConnection)
+	done := make(chan error)
+	go func() {
+		_, err := conn.Query(DataRef{}, []string{}, []Condition{}, true)
+		done <- err
+	}()
+	<-started
+	lastError := make(chan error)
+	go func() {
+		lastError <- conn.GetLastError()
+	}()
+	select {
+	case <-lastError:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Operations should not be blocked while dialing")
+	}
+	conn.reconnect(broken, driver.ErrBadConn)
+	close(release)
+	if err := <-done; err != driver.ErrBadConn {
+		t.Fatalf("Wrong query error: %v", err)
+	}
+	current, _ := conn.current()
+	if atomic.LoadInt32(&dials) != 1 || current.(*flakyConnection).name != "replacement" || !broken.closed {
+		t.Fatalf("Connection should be replaced once: %v dials", dials)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Jitter: 0.1}
+	for attempt, expected := range map[int]time.Duration{1: 100 * time.Millisecond, 3: 400 * time.Millisecond, 10: time.Second} {
+		delay := policy.Backoff(attempt)
+		if delay < expected*9/10 || delay > expected*11/10 {
+			t.Fatalf("Wrong delay after attempt %v, expected about %v but was: %v", attempt, expected, delay)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// Streaming records iterator interface, Rows must be closed after use
+type Rows interface {
+	// Advances to the next record, returns false when no more records are available or an error occurred
+	Next() bool
+	// Copies the current record values in the given destinations or, when a single pointer to a struct is given,
+	// maps the record in the struct as ScanResult does
+	Scan(dest ...interface{}) error
+	// Returns the current record
+	Current() Result
+	// Returns the records metadata
+	MetaData() MetaData
+	// Returns the error occurred during the iteration, if any
+	Err() error
+	// Releases the iterator resources
+	Close() error
+}
+
+// Reads all the records of the Rows iterator in a ResultSet, closing the iterator
+func Collect(rows Rows) (ResultSet, error) {
+	defer func() {
+		_ = rows.Close()
+	}()
+	resultSet := ResultSet{
+		Records:  make([]Result, 0),
+		Lines:    0,
+		MetaData: rows.MetaData(),
+	}
+	for rows.Next() {
+		resultSet.Lines++
+		resultSet.Records = append(resultSet.Records, rows.Current())
+	}
+	return resultSet, rows.Err()
+}
+
+// Copies the values in the given destinations, which must be pointers. Destinations implementing sql.Scanner
+// receive the value, other destinations must have a type the value is assignable or can be coerced to.
+func ScanValues(values []interface{}, dest ...interface{}) error {
+	if len(values) != len(dest) {
+		return errors.New(fmt.Sprintf("Scan expects %v destinations but was: %v", len(values), len(dest)))
+	}
+	for i, d := range dest {
+		if err := scanValue(values[i], d); err != nil {
+			return errors.New(fmt.Sprintf("Scan error on value %v: %v", i, err))
+		}
+	}
+	return nil
+}
+
+func scanValue(value interface{}, dest interface{}) error {
+	if scanner, ok := dest.(sql.Scanner); ok {
+		return scanner.Scan(value)
+	}
+	d := reflect.ValueOf(dest)
+	if d.Kind() != reflect.Ptr || d.IsNil() {
+		return errors.New(fmt.Sprintf("destination not a pointer: %T", dest))
+	}
+	return assign(value, d.Elem())
+}
+
+// Assigns the value to the target, allocating pointers and coercing the value to the target type
+func assign(value interface{}, target reflect.Value) error {
+	if target.CanAddr() {
+		if scanner, ok := target.Addr().Interface().(sql.Scanner); ok {
+			return scanner.Scan(value)
+		}
+	}
+	if value == nil {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(target.Type()) {
+		target.Set(v)
+		return nil
+	}
+	if target.Kind() == reflect.Ptr {
+		elem := reflect.New(target.Type().Elem())
+		if err := assign(value, elem.Elem()); err != nil {
+			return err
+		}
+		target.Set(elem)
+		return nil
+	}
+	if coerced, ok := coerce(value, target.Type()); ok {
+		target.Set(reflect.ValueOf(coerced))
+		return nil
+	}
+	return errors.New(fmt.Sprintf("cannot assign %T value to %s", value, target.Type()))
+}
+
+func isNumber(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func isBytes(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// Verifies the conversion keeps the value meaning, excluding numbers to string conversions
+func convertible(from reflect.Type, to reflect.Type) bool {
+	if !from.ConvertibleTo(to) {
+		return false
+	}
+	switch {
+	case isNumber(from.Kind()):
+		return isNumber(to.Kind())
+	case from.Kind() == reflect.String || isBytes(from):
+		return to.Kind() == reflect.String || isBytes(to)
+	default:
+		return from.Kind() == to.Kind()
+	}
+}
+
+// Verifies the number doesn't fit the numeric type: out of range values, negative values converted to unsigned
+// integers and floats with a fractional part converted to integers
+func overflows(v reflect.Value, to reflect.Type) bool {
+	target := reflect.New(to).Elem()
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := v.Int()
+		switch to.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return target.OverflowInt(n)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return n < 0 || target.OverflowUint(uint64(n))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n := v.Uint()
+		switch to.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return n > math.MaxInt64 || target.OverflowInt(int64(n))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return target.OverflowUint(n)
+		}
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		switch to.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			// NaN values differ from their integer part too
+			return f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 || target.OverflowInt(int64(f))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 || target.OverflowUint(uint64(f))
+		case reflect.Float32, reflect.Float64:
+			return target.OverflowFloat(f)
+		}
+	}
+	return false
+}
+
+type resultRows struct {
+	resultSet ResultSet
+	index     int
+	closed    bool
+}
+
+func (r *resultRows) Next() bool {
+	if r.closed || r.index >= len(r.resultSet.Records) {
+		return false
+	}
+	r.index++
+	return true
+}
+
+func (r *resultRows) Scan(dest ...interface{}) error {
+	if r.closed || r.index == 0 {
+		return errors.New(fmt.Sprint("Scan called without calling Next"))
+	}
+	if len(dest) == 1 && isStructDest(dest[0]) {
+		return ScanResult(r.resultSet.MetaData, r.Current(), dest[0])
+	}
+	return ScanValues(r.Current().Values, dest...)
+}
+
+func (r *resultRows) Current() Result {
+	if r.closed || r.index == 0 {
+		return Result{}
+	}
+	return r.resultSet.Records[r.index-1]
+}
+
+func (r *resultRows) MetaData() MetaData {
+	return r.resultSet.MetaData
+}
+
+func (r *resultRows) Err() error {
+	return nil
+}
+
+func (r *resultRows) Close() error {
+	r.closed = true
+	return nil
+}
+
+// Creates a Rows iterator over the records of a ResultSet
+func NewResultRows(resultSet ResultSet) Rows {
+	return &resultRows{
+		resultSet: resultSet,
+	}
+}
+
+type sqlRows struct {
+	rows      *sql.Rows
+	metaData  MetaData
+	binary    []bool
+	values    []interface{}
+	queryArgs []interface{}
+	current   Result
+	err       error
+}
+
+func (r *sqlRows) Next() bool {
+	r.current = Result{}
+	if r.err != nil || !r.rows.Next() {
+		return false
+	}
+	if r.err = r.rows.Scan(r.queryArgs...); r.err != nil {
+		return false
+	}
+	var resultValues = make([]interface{}, len(r.values))
+	for i := range r.values {
+		resultValues[i] = r.values[i]
+		if raw, ok := r.values[i].([]byte); ok && !r.binary[i] {
+			resultValues[i] = string(raw)
+		}
+	}
+	r.current = Result{
+		Columns:  int64(len(r.metaData.Columns)),
+		Document: nil,
+		Values:   resultValues,
+	}
+	return true
+}
+
+func (r *sqlRows) Scan(dest ...interface{}) error {
+	if len(dest) == 1 && isStructDest(dest[0]) {
+		if r.current.Values == nil {
+			return errors.New(fmt.Sprint("Scan called without calling Next"))
+		}
+		return ScanResult(r.metaData, r.current, dest[0])
+	}
+	return r.rows.Scan(dest...)
+}
+
+func (r *sqlRows) Current() Result {
+	return r.current
+}
+
+func (r *sqlRows) MetaData() MetaData {
+	return r.metaData
+}
+
+func (r *sqlRows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.rows.Err()
+}
+
+func (r *sqlRows) Close() error {
+	return r.rows.Close()
+}
+
+// Creates a Rows iterator over database/sql rows, used by the SQL drivers. The typeInstance function maps the
+// column database types to Go types and default values, raw bytes values are converted to strings except for
+// the given binary database types.
+func NewSqlRows(rows *sql.Rows, entityRef DataRef, typeInstance func(string) (reflect.Type, interface{}), binaryTypes ...string) (Rows, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+	r := &sqlRows{
+		rows: rows,
+		metaData: MetaData{
+			EntityRef: entityRef,
+			Columns:   make([]Column, 0),
+		},
+		binary:    make([]bool, len(cols)),
+		values:    make([]interface{}, len(cols)),
+		queryArgs: make([]interface{}, len(cols)),
+	}
+	for i, colName := range cols {
+		cType := colTypes[i]
+		length, _ := cType.Length()
+		precision, scale, _ := cType.DecimalSize()
+		dataType := cType.DatabaseTypeName()
+		goType, defValue := typeInstance(dataType)
+		r.values[i] = defValue
+		r.queryArgs[i] = &r.values[i]
+		for _, binaryType := range binaryTypes {
+			if strings.EqualFold(dataType, binaryType) {
+				r.binary[i] = true
+			}
+		}
+		r.metaData.Columns = append(r.metaData.Columns, Column{
+			Name:      colName,
+			Length:    length,
+			Precision: precision,
+			Scale:     scale,
+			Type:      DataType(dataType),
+			GoType:    goType,
+		})
+	}
+	return r, nil
+}
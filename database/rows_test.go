@@ -0,0 +1,77 @@
+package database
+
+import (
+	"database/sql"
+	"math"
+	"testing"
+)
+
+func TestScanValues(t *testing.T) {
+	var name string
+	var age int
+	var score float32
+	var any interface{}
+	var role sql.NullString
+	var raw []byte
+	err := ScanValues([]interface{}{"Fabrizio", int64(45), 7.5, true, nil, "data"}, &name, &age, &score, &any, &role, &raw)
+	if err != nil {
+		t.Fatalf("Scan error occured: %v", err)
+	}
+	if name != "Fabrizio" || age != 45 || score != 7.5 || any != true || role.Valid || string(raw) != "data" {
+		t.Fatalf("Wrong scanned values: %v %v %v %v %v %v", name, age, score, any, role, raw)
+	}
+	if err = ScanValues([]interface{}{int64(65)}, &name); err == nil {
+		t.Fatal("Number to string scan should fail")
+	}
+	var small int8
+	var count uint
+	for _, value := range []interface{}{int64(300), 2.5, math.NaN(), 1e20} {
+		if err = ScanValues([]interface{}{value}, &small); err == nil {
+			t.Fatalf("Scan of %v in int8 should fail, scanned: %v", value, small)
+		}
+	}
+	if err = ScanValues([]interface{}{int64(-1)}, &count); err == nil {
+		t.Fatalf("Negative number scan in uint should fail, scanned: %v", count)
+	}
+	if err = ScanValues([]interface{}{float64(-128), uint64(7)}, &small, &count); err != nil || small != -128 || count != 7 {
+		t.Fatalf("Numbers in range should be scanned: %v %v %v", small, count, err)
+	}
+	if err = ScanValues([]interface{}{1e300}, &score); err == nil {
+		t.Fatalf("Scan of out of range float32 should fail, scanned: %v", score)
+	}
+	if err = ScanValues([]interface{}{"x"}, name); err == nil {
+		t.Fatal("Not pointer destination scan should fail")
+	}
+	if err = ScanValues([]interface{}{"x", "y"}, &name); err == nil {
+		t.Fatal("Wrong number of destinations scan should fail")
+	}
+}
+
+func TestResultRows(t *testing.T) {
+	rows := NewResultRows(ResultSet{
+		MetaData: MetaData{Columns: []Column{{Name: "a"}}},
+		Records: []Result{
+			{Columns: 1, Values: []interface{}{int64(1)}},
+			{Columns: 1, Values: []interface{}{int64(2)}},
+		},
+		Lines: 2,
+	})
+	var value int64
+	if err := rows.Scan(&value); err == nil {
+		t.Fatal("Scan before Next should fail")
+	}
+	var sum int64
+	for rows.Next() {
+		if err := rows.Scan(&value); err != nil {
+			t.Fatalf("Scan error occured: %v", err)
+		}
+		sum += value
+	}
+	if sum != 3 || rows.Err() != nil {
+		t.Fatalf("Wrong iterated values sum: %v", sum)
+	}
+	rs, err := Collect(NewResultRows(ResultSet{Records: []Result{{}, {}}}))
+	if err != nil || rs.Lines != 2 {
+		t.Fatalf("Wrong collected result set: %v %v", rs.Lines, err)
+	}
+}
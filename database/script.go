@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"strings"
+)
+
+// Scripts executor Connection interface
+type ScriptConnection interface {
+	// Executes a script on the database instance: SQL statements separated by semicolons for the SQL drivers, a JSON
+	// command document, or an array of them, for MongoDB
+	ExecScript(ctx context.Context, dbRef DataRef, script string) error
+}
+
+// Splits the SQL script in statements separated by semicolons, ignoring the semicolons in quoted text, quoted
+// identifiers, dollar quoted strings and -- or /* */ comments. Comments are preserved and empty statements are
+// skipped.
+func SplitStatements(script string) []string {
+	var statements = make([]string, 0)
+	var current strings.Builder
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" && !onlyComments(statement) {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := i + 1
+			for end < len(script) {
+				if script[end] == '\\' && c != '`' {
+					end += 2
+					continue
+				}
+				if script[end] == c {
+					// Doubled quotes escape the quote
+					if end+1 < len(script) && script[end+1] == c {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			if end >= len(script) {
+				end = len(script) - 1
+			}
+			current.WriteString(script[i : end+1])
+			i = end
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			current.WriteString(script[i : i+end])
+			i += end - 1
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				end = len(script) - i - 4
+			}
+			current.WriteString(script[i : i+end+4])
+			i += end + 3
+		case c == '$':
+			tagEnd := strings.IndexByte(script[i+1:], '$')
+			tag := ""
+			if tagEnd >= 0 {
+				tag = script[i : i+tagEnd+2]
+			}
+			if tag == "" || strings.ContainsAny(tag[1:len(tag)-1], " \t\r\n;$'\",()") ||
+				(len(tag) > 2 && tag[1] >= '0' && tag[1] <= '9') {
+				current.WriteByte(c)
+				continue
+			}
+			end := strings.Index(script[i+len(tag):], tag)
+			if end < 0 {
+				end = len(script) - i - 2*len(tag)
+			}
+			current.WriteString(script[i : i+end+2*len(tag)])
+			i += end + 2*len(tag) - 1
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+	return statements
+}
+
+// Verifies the statement is made of comments only
+func onlyComments(statement string) bool {
+	for _, line := range strings.Split(statement, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") &&
+			!(strings.HasPrefix(line, "/*") && strings.HasSuffix(line, "*/")) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- users table
+CREATE TABLE users (id INT, name VARCHAR(64) DEFAULT 'a;b');
+/* ; */ INSERT INTO users VALUES (1, "it""s;");
+CREATE FUNCTION one() RETURNS INT AS $body$ SELECT 1; $body$ LANGUAGE SQL;
+SELECT $1 FROM users;
+-- trailing comment;
+`
+	statements := SplitStatements(script)
+	if len(statements) != 4 {
+		t.Fatalf("Wrong statements: %q", statements)
+	}
+	if statements[0] != "-- users table\nCREATE TABLE users (id INT, name VARCHAR(64) DEFAULT 'a;b')" {
+		t.Fatalf("Wrong first statement: %q", statements[0])
+	}
+	if statements[1] != `/* ; */ INSERT INTO users VALUES (1, "it""s;")` {
+		t.Fatalf("Wrong second statement: %q", statements[1])
+	}
+	if statements[2] != "CREATE FUNCTION one() RETURNS INT AS $body$ SELECT 1; $body$ LANGUAGE SQL" {
+		t.Fatalf("Wrong third statement: %q", statements[2])
+	}
+	if statements[3] != "SELECT $1 FROM users" {
+		t.Fatalf("Wrong fourth statement: %q", statements[3])
+	}
+	if len(SplitStatements(" ;; -- nothing\n")) != 0 {
+		t.Fatal("Empty statements should be skipped")
+	}
+}
@@ -0,0 +1,222 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Secret reference prefix, followed by the provider name and the provider specific path, e.g.
+// secret://file/run/secrets/db
+const SecretScheme = "secret://"
+
+// Secret values resolver interface
+type SecretResolver interface {
+	// Returns the secret value of the provider specific path
+	Resolve(ctx context.Context, path string) (string, error)
+}
+
+// Secret resolver function type
+type SecretResolverFunc func(ctx context.Context, path string) (string, error)
+
+func (f SecretResolverFunc) Resolve(ctx context.Context, path string) (string, error) {
+	return f(ctx, path)
+}
+
+// Secret rotation cause of the ReconnectEvent
+var ErrSecretRotated = errors.New("Database credentials have been rotated")
+
+var (
+	secretMutex sync.RWMutex
+	// Secret providers by name
+	secretProviders = map[string]SecretResolver{
+		"file": SecretResolverFunc(fileSecret),
+		"env":  SecretResolverFunc(envSecret),
+		"exec": SecretResolverFunc(execSecret),
+	}
+)
+
+// Registers a secret provider, replacing the provider with the same name. The built-in providers are file, reading
+// the absolute file path, env, reading the environment variable, and exec, running the command line.
+func RegisterSecretProvider(name string, resolver SecretResolver) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || strings.Contains(name, "/") {
+		return errors.New(fmt.Sprintf("Invalid secret provider name: %s", name))
+	}
+	if resolver == nil {
+		return errors.New(fmt.Sprintf("Secret provider %s resolver cannot be nil", name))
+	}
+	secretMutex.Lock()
+	defer secretMutex.Unlock()
+	secretProviders[name] = resolver
+	return nil
+}
+
+// Verifies the value is a secret reference
+func IsSecretRef(value string) bool {
+	return strings.HasPrefix(value, SecretScheme)
+}
+
+// Resolves the value when it is a secret reference, otherwise returns it unchanged
+func ResolveSecret(ctx context.Context, value string) (string, error) {
+	if !IsSecretRef(value) {
+		return value, nil
+	}
+	ref := strings.TrimPrefix(value, SecretScheme)
+	index := strings.IndexByte(ref, '/')
+	if index <= 0 {
+		return "", errors.New(fmt.Sprintf("Invalid secret reference, expected %s<provider>/<path>: %s", SecretScheme, value))
+	}
+	name, path := strings.ToLower(ref[:index]), ref[index+1:]
+	secretMutex.RLock()
+	resolver, ok := secretProviders[name]
+	secretMutex.RUnlock()
+	if !ok {
+		return "", errors.New(fmt.Sprintf("Unknown secret provider: %s", name))
+	}
+	secret, err := resolver.Resolve(ctx, path)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("Unable to resolve secret %s: %v", value, err))
+	}
+	return secret, nil
+}
+
+// Returns the configuration with the secret references of the connection url, user name and passwords replaced
+// by the resolved values
+func ResolveSecrets(ctx context.Context, config DbConfig) (DbConfig, error) {
+	for _, field := range []*string{&config.Url, &config.Name, &config.Password, &config.DbPassword} {
+		secret, err := ResolveSecret(ctx, *field)
+		if err != nil {
+			return config, err
+		}
+		*field = secret
+	}
+	return config, nil
+}
+
+// Removes the trailing line break of files and command outputs
+func trimSecret(secret string) string {
+	return strings.TrimRight(secret, "\r\n")
+}
+
+// Reads the secret file, the path is absolute
+func fileSecret(_ context.Context, path string) (string, error) {
+	data, err := os.ReadFile(filepath.Join("/", path))
+	if err != nil {
+		return "", err
+	}
+	return trimSecret(string(data)), nil
+}
+
+// Reads the secret environment variable
+func envSecret(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", errors.New(fmt.Sprintf("Environment variable %s is not set", name))
+	}
+	return value, nil
+}
+
+// Runs the command line, split on white spaces, returning the standard output. The command is searched in the PATH
+// unless it holds a slash, e.g. secret://exec//usr/local/bin/vault for an absolute path. The failures report the exit
+// status only, the standard error could hold the secret or the credentials of the secret store.
+func execSecret(ctx context.Context, commandLine string) (string, error) {
+	args := strings.Fields(commandLine)
+	if len(args) == 0 {
+		return "", errors.New(fmt.Sprint("Secret command is empty"))
+	}
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("Command %s failed: %v", args[0], err))
+	}
+	return trimSecret(string(out)), nil
+}
+
+// Driver connecting with the configuration secrets already resolved, keeping the configuration with the secret
+// references as the connection configuration. ConnectRotating uses it to resolve the secrets once per connection.
+type ResolvedConnector interface {
+	// Connect to a database instance with the resolved configuration, masking the credentials in the returned errors
+	ConnectResolved(resolved DbConfig, config DbConfig) (Connection, error)
+}
+
+// Connects with the configuration driver and watches the secrets: when a resolved value changes the connection is
+// replaced by a new one, with the rotated credentials, and the previous connection is closed after completing the
+// running operations, once the Rows and transactions opened on it are closed. The returned connection recovers from
+// the transient errors as the Resilient one with the given policy, whose Dial function is replaced; rotations are
+// reported to OnReconnect with the ErrSecretRotated cause. The secrets are resolved again every interval, 1 minute
+// when not positive, and compared with the ones of the last connection: drivers implementing ResolvedConnector
+// connect with those secrets, the other ones resolve the secrets again.
+func ConnectRotating(config DbConfig, interval time.Duration, policy RetryPolicy) (Connection, error) {
+	driver, err := GetDriver(config.Driver)
+	if err != nil {
+		return nil, err
+	}
+	conn, resolved, err := connectResolved(driver, config)
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	r := newResilient(conn, policy)
+	r.resolved = resolved
+	r.policy.Dial = func() (Connection, error) {
+		conn, resolved, err := connectResolved(driver, config)
+		if err == nil {
+			r.Lock()
+			r.resolved = resolved
+			r.Unlock()
+		}
+		return conn, err
+	}
+	r.stop = make(chan struct{})
+	go r.watchSecrets(config, interval)
+	return r, nil
+}
+
+// Connects resolving the configuration secrets, returning the resolved configuration
+func connectResolved(driver Driver, config DbConfig) (Connection, DbConfig, error) {
+	resolved, err := ResolveSecrets(context.Background(), config)
+	if err != nil {
+		return nil, resolved, err
+	}
+	if connector, ok := driver.(ResolvedConnector); ok {
+		conn, err := connector.ConnectResolved(resolved, config)
+		return conn, resolved, err
+	}
+	conn, err := driver.Connect(config)
+	return conn, resolved, err
+}
+
+// Resolves the secrets every interval, replacing the connection when they change, until the connection is closed
+func (r *resilientConnection) watchSecrets(config DbConfig, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+		current, err := ResolveSecrets(context.Background(), config)
+		r.RLock()
+		resolved := r.resolved
+		r.RUnlock()
+		if err != nil || (current.Url == resolved.Url && current.Name == resolved.Name &&
+			current.Password == resolved.Password && current.DbPassword == resolved.DbPassword) {
+			// Unavailable secrets are checked again at the next interval
+			continue
+		}
+		conn, err := r.current()
+		if err != nil {
+			return
+		}
+		r.reconnect(conn, ErrSecretRotated)
+	}
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type secretDriver struct{}
+
+func (d *secretDriver) Connect(config DbConfig) (Connection, error) {
+	resolved, err := ResolveSecrets(context.Background(), config)
+	if err != nil {
+		return nil, err
+	}
+	return d.ConnectResolved(resolved, config)
+}
+
+func (d *secretDriver) ConnectResolved(resolved DbConfig, config DbConfig) (Connection, error) {
+	return &flakyConnection{name: resolved.Password}, nil
+}
+
+func TestResolveSecrets(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0600); err != nil {
+		t.Fatalf("Secret file error occured: %v", err)
+	}
+	t.Setenv("SECRET_TEST_USER", "app")
+	config, err := ResolveSecrets(context.Background(), DbConfig{
+		Host:       "secret://env/SECRET_TEST_HOST",
+		Name:       "secret://env/SECRET_TEST_USER",
+		Password:   "secret://file" + file,
+		DbPassword: "secret://exec/echo from-exec",
+		Url:        "plain",
+	})
+	if err != nil {
+		t.Fatalf("Secrets error occured: %v", err)
+	}
+	if config.Name != "app" || config.Password != "from-file" || config.DbPassword != "from-exec" || config.Url != "plain" {
+		t.Fatalf("Wrong resolved secrets: %+v", config)
+	}
+	if config.Host != "secret://env/SECRET_TEST_HOST" {
+		t.Fatalf("Host should not be resolved: %v", config.Host)
+	}
+	for _, ref := range []string{"secret://env/SECRET_TEST_MISSING", "secret://vault/db", "secret://file", "secret://exec/false"} {
+		if _, err = ResolveSecret(context.Background(), ref); err == nil {
+			t.Fatalf("Expected secret %s error", ref)
+		}
+	}
+	_, err = ResolveSecret(context.Background(), "secret://exec/cat /missing-secret")
+	if err == nil || strings.Contains(err.Error(), "directory") {
+		t.Fatalf("Command standard error should not be reported: %v", err)
+	}
+	err = RegisterSecretProvider("static", SecretResolverFunc(func(ctx context.Context, path string) (string, error) {
+		return "static-" + path, nil
+	}))
+	if err != nil {
+		t.Fatalf("Secret provider registration error occured: %v", err)
+	}
+	if secret, _ := ResolveSecret(context.Background(), "secret://static/db"); secret != "static-db" {
+		t.Fatalf("Wrong registered provider secret: %v", secret)
+	}
+}
+
+func TestConnectRotating(t *testing.T) {
+	if err := Register("secret-test", nil, func() Driver { return &secretDriver{} }); err != nil {
+		t.Fatalf("Driver registration error occured: %v", err)
+	}
+	t.Setenv("SECRET_TEST_PASSWORD", "first")
+	var events = make(chan ReconnectEvent, 1)
+	conn, err := ConnectRotating(DbConfig{Driver: "secret-test", Password: "secret://env/SECRET_TEST_PASSWORD"}, 10*time.Millisecond, RetryPolicy{
+		OnReconnect: func(event ReconnectEvent) {
+			events <- event
+		},
+	})
+	if err != nil {
+		t.Fatalf("Connection error occured: %v", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	rs, _ := conn.Query(DataRef{}, []string{}, []Condition{}, true)
+	if rs.MetaData.EntityRef.Namespace != "first" {
+		t.Fatalf("Wrong initial credentials: %v", rs.MetaData.EntityRef.Namespace)
+	}
+	first, _ := conn.(*resilientConnection).current()
+	rows, err := conn.Stream(context.Background(), DataRef{}, []string{}, Filter{}, QueryOptions{})
+	if err != nil {
+		t.Fatalf("Stream error occured: %v", err)
+	}
+	_ = os.Setenv("SECRET_TEST_PASSWORD", "second")
+	select {
+	case event := <-events:
+		if event.Cause != ErrSecretRotated || event.Error != nil {
+			t.Fatalf("Wrong rotation event: %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Connection not replaced after the secret rotation")
+	}
+	rs, _ = conn.Query(DataRef{}, []string{}, []Condition{}, true)
+	if rs.MetaData.EntityRef.Namespace != "second" {
+		t.Fatalf("Wrong rotated credentials: %v", rs.MetaData.EntityRef.Namespace)
+	}
+	if first.(*flakyConnection).closed {
+		t.Fatal("Replaced connection should be open until the rows are closed")
+	}
+	_ = rows.Close()
+	if !first.(*flakyConnection).closed {
+		t.Fatal("Replaced connection should be closed with the last rows")
+	}
+}
+
+func TestConnectRotatingResolvesOnce(t *testing.T) {
+	var resolutions int32
+	err := RegisterSecretProvider("counted", SecretResolverFunc(func(ctx context.Context, path string) (string, error) {
+		return fmt.Sprintf("%s-%v", path, atomic.AddInt32(&resolutions, 1)), nil
+	}))
+	if err != nil {
+		t.Fatalf("Secret provider registration error occured: %v", err)
+	}
+	if err = Register("secret-once-test", nil, func() Driver { return &secretDriver{} }); err != nil {
+		t.Fatalf("Driver registration error occured: %v", err)
+	}
+	conn, err := ConnectRotating(DbConfig{Driver: "secret-once-test", Password: "secret://counted/db"}, time.Hour, RetryPolicy{})
+	if err != nil {
+		t.Fatalf("Connection error occured: %v", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	if count := atomic.LoadInt32(&resolutions); count != 1 {
+		t.Fatalf("Secrets should be resolved once by connection, resolved %v times", count)
+	}
+	r := conn.(*resilientConnection)
+	rs, _ := conn.Query(DataRef{}, []string{}, []Condition{}, true)
+	if r.resolved.Password != "db-1" || rs.MetaData.EntityRef.Namespace != "db-1" {
+		t.Fatalf("Watched secrets should be the connection ones: %v %v", r.resolved.Password, rs.MetaData.EntityRef.Namespace)
+	}
+}
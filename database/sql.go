@@ -0,0 +1,256 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SQL dialect structure, holding the driver specific parts of the statements prepared by the SQL drivers
+type SqlDialect struct {
+	// Limit clause value selecting all the remaining rows, used with an offset without limit, e.g. -1, empty when the
+	// offset needs no limit clause
+	NoLimit string
+	// Declares the single primary key column inline, e.g. INTEGER PRIMARY KEY, instead of the PRIMARY KEY constraint
+	InlineKey bool
+	// Returns the column constraints following the column type and preceding the default value, each one with a
+	// leading space, e.g. " NOT NULL", or an error for the unsupported columns; inlineKey reports the primary key
+	// column declared inline
+	Constraints func(f Field, inlineKey bool) (string, error)
+	// Returns the statement value placeholder at the position, starting from 1, e.g. $1, the ? placeholder when not
+	// provided
+	Placeholder func(position int) string
+}
+
+// Returns the table name qualified with the schema, when provided
+func TableName(dbRef DataRef) string {
+	if dbRef.Schema != "" {
+		return dbRef.Schema + "." + dbRef.Namespace
+	}
+	return dbRef.Namespace
+}
+
+// Expands slice and array values in the single values list used by the In operation, byte slices excluded
+func ExpandValues(value interface{}) []interface{} {
+	var values = make([]interface{}, 0)
+	if value == nil {
+		return values
+	}
+	v := reflect.ValueOf(value)
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8 {
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, v.Index(i).Interface())
+		}
+		return values
+	}
+	return append(values, value)
+}
+
+// Returns the statement value placeholder at the position
+func (d SqlDialect) placeholder(position int) string {
+	if d.Placeholder == nil {
+		return "?"
+	}
+	return d.Placeholder(position)
+}
+
+// Prepares the condition expression, numbering the placeholders after the given offset
+func (d SqlDialect) condition(cond Condition, offset int) (string, []interface{}) {
+	fld := cond.Field
+	operationSymbol := byte(cond.Operation)
+	not := false
+	if operationSymbol > byte(Not) {
+		operationSymbol = operationSymbol - byte(Not)
+		not = true
+	}
+	var value = []interface{}{cond.Value.Value}
+	next := d.placeholder(offset + 1)
+	switch operationSymbol {
+	case byte(LessThan):
+		if not {
+			return fld + " > " + next, value
+		} else {
+			return fld + " < " + next, value
+		}
+	case byte(LessThanEquals):
+		if not {
+			return fld + " >= " + next, value
+		} else {
+			return fld + " <= " + next, value
+		}
+	case byte(GraterThan):
+		if not {
+			return fld + " < " + next, value
+		} else {
+			return fld + " > " + next, value
+		}
+	case byte(GraterThanEquals):
+		if not {
+			return fld + " <= " + next, value
+		} else {
+			return fld + " >= " + next, value
+		}
+	case byte(Like):
+		if not {
+			return fld + " NOT LIKE " + next, value
+		} else {
+			return fld + " LIKE " + next, value
+		}
+	case byte(In):
+		values := ExpandValues(cond.Value.Value)
+		if len(values) == 0 {
+			// No value matches the empty list
+			if not {
+				return "1=1", nil
+			}
+			return "1=0", nil
+		}
+		var placeholders = make([]string, 0)
+		for i := range values {
+			placeholders = append(placeholders, d.placeholder(offset+i+1))
+		}
+		if not {
+			return fld + " NOT IN (" + strings.Join(placeholders, ", ") + ")", values
+		} else {
+			return fld + " IN (" + strings.Join(placeholders, ", ") + ")", values
+		}
+	case byte(Null):
+		if not {
+			return fld + " IS NOT NULL", nil
+		} else {
+			return fld + " IS NULL", nil
+		}
+	default:
+		if not {
+			return fld + " <> " + next, value
+		} else {
+			return fld + " = " + next, value
+		}
+	}
+}
+
+// Prepares the filter expression, numbering the placeholders after the given offset.
+// Nested And and Or groups are enclosed in parentheses.
+func (d SqlDialect) filter(filter Filter, nested bool, offset int) (string, []interface{}) {
+	if filter.Type == LeafFilter {
+		return d.condition(filter.Condition, offset)
+	}
+	var parts = make([]string, 0)
+	var values = make([]interface{}, 0)
+	for _, sub := range filter.Children() {
+		expr, subValues := d.filter(sub, true, offset+len(values))
+		parts = append(parts, expr)
+		values = append(values, subValues...)
+	}
+	if len(parts) == 0 {
+		return "", values
+	}
+	switch filter.Type {
+	case OrFilter:
+		if nested && len(parts) > 1 {
+			return "(" + strings.Join(parts, " OR ") + ")", values
+		}
+		return strings.Join(parts, " OR "), values
+	case NotFilter:
+		return "NOT (" + strings.Join(parts, " AND ") + ")", values
+	default:
+		if nested && len(parts) > 1 {
+			return "(" + strings.Join(parts, " AND ") + ")", values
+		}
+		return strings.Join(parts, " AND "), values
+	}
+}
+
+// Prepares the where clause and its values, numbering the placeholders after the given offset, empty when the filter
+// doesn't restrict the records
+func (d SqlDialect) Where(filter Filter, offset int) (string, []interface{}) {
+	where, values := d.filter(filter, false, offset)
+	if where != "" {
+		where = " WHERE " + where
+	}
+	return where, values
+}
+
+// Prepares the order by, limit and offset clauses
+func (d SqlDialect) QueryOptions(options QueryOptions) string {
+	var clause = ""
+	var orders = make([]string, 0)
+	for _, order := range options.OrderBy {
+		if order.IsDescending() {
+			orders = append(orders, order.Field+" DESC")
+		} else {
+			orders = append(orders, order.Field+" ASC")
+		}
+	}
+	if len(orders) > 0 {
+		clause += " ORDER BY " + strings.Join(orders, ", ")
+	}
+	if options.Limit > 0 {
+		clause += fmt.Sprintf(" LIMIT %d", options.Limit)
+	} else if options.Offset > 0 && d.NoLimit != "" {
+		clause += " LIMIT " + d.NoLimit
+	}
+	if options.Offset > 0 {
+		clause += fmt.Sprintf(" OFFSET %d", options.Offset)
+	}
+	return clause
+}
+
+// Prepares the column definition, with the size, the precision and the dialect constraints
+func (d SqlDialect) columnDefinition(f Field, inlineKey bool) (string, error) {
+	def := f.Name + " " + f.Type
+	if f.Size > 0 && f.Precision > 0 {
+		def += fmt.Sprintf("(%v,%v)", f.Size, f.Precision)
+	} else if f.Size > 0 {
+		def += fmt.Sprintf("(%v)", f.Size)
+	}
+	if d.Constraints != nil {
+		constraints, err := d.Constraints(f, inlineKey)
+		if err != nil {
+			return "", err
+		}
+		def += constraints
+	}
+	if f.Default != "" {
+		def += " DEFAULT " + f.Default
+	}
+	if f.Unique {
+		def += " UNIQUE"
+	}
+	return def, nil
+}
+
+// Prepares the create table statement, with the primary key constraint for the primary key fields, unless the dialect
+// declares the single primary key inline
+func (d SqlDialect) CreateTable(dbRef DataRef, fields []Field) (string, error) {
+	if len(fields) == 0 {
+		return "", errors.New(fmt.Sprint("Create table statement needs list of Columns"))
+	}
+	var keys = make([]string, 0)
+	for _, f := range fields {
+		if f.Name == "" || f.Type == "" {
+			return "", errors.New(fmt.Sprintf("Create table columns need name and type: <%s> <%s>", f.Name, f.Type))
+		}
+		if f.PrimaryKey {
+			keys = append(keys, f.Name)
+		}
+	}
+	inline := d.InlineKey && len(keys) == 1
+	var cols = make([]string, 0)
+	for _, f := range fields {
+		def, err := d.columnDefinition(f, inline && f.PrimaryKey)
+		if err != nil {
+			return "", err
+		}
+		cols = append(cols, def)
+	}
+	if len(keys) > 0 && !inline {
+		cols = append(cols, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(keys, ", ")))
+	}
+	var ifNotExists string
+	if dbRef.IfNotExists {
+		ifNotExists = "IF NOT EXISTS "
+	}
+	return fmt.Sprintf("CREATE TABLE %s%s (%s)", ifNotExists, TableName(dbRef), strings.Join(cols, ", ")), nil
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSqlDialect(t *testing.T) {
+	dialect := SqlDialect{
+		InlineKey: true,
+		Constraints: func(f Field, inlineKey bool) (string, error) {
+			if inlineKey {
+				return " PRIMARY KEY", nil
+			}
+			return "", nil
+		},
+	}
+	sqlText, err := dialect.CreateTable(DataRef{Namespace: "users"}, []Field{
+		{Name: "id", Type: "INTEGER", PrimaryKey: true},
+		{Name: "email", Type: "VARCHAR", Size: 50, Unique: true, Default: "''"},
+	})
+	if err != nil {
+		t.Fatalf("Create table statement error occured: %v", err)
+	}
+	expected := "CREATE TABLE users (id INTEGER PRIMARY KEY, email VARCHAR(50) DEFAULT '' UNIQUE)"
+	if sqlText != expected {
+		t.Fatalf("Wrong create table statement, expected: <%s> but was: <%s>", expected, sqlText)
+	}
+	sqlText, err = dialect.CreateTable(DataRef{Namespace: "roles", Schema: "app"}, []Field{
+		{Name: "user_id", Type: "INTEGER", PrimaryKey: true},
+		{Name: "role", Type: "TEXT", PrimaryKey: true},
+	})
+	expected = "CREATE TABLE app.roles (user_id INTEGER, role TEXT, PRIMARY KEY (user_id, role))"
+	if err != nil || sqlText != expected {
+		t.Fatalf("Wrong composite key statement, expected: <%s> but was: <%s> %v", expected, sqlText, err)
+	}
+	if clause := dialect.QueryOptions(QueryOptions{Offset: 5}); clause != " OFFSET 5" {
+		t.Fatalf("Wrong options clause without limit value: <%s>", clause)
+	}
+	dialect.NoLimit = "ALL"
+	if clause := dialect.QueryOptions(QueryOptions{Offset: 5}); clause != " LIMIT ALL OFFSET 5" {
+		t.Fatalf("Wrong options clause with limit value: <%s>", clause)
+	}
+}
+
+func TestSqlDialectWhere(t *testing.T) {
+	filter := And(
+		Leaf(Condition{Field: "a", Operation: Not + Equals, Value: Value{Value: 1}}),
+		Or(
+			Leaf(Condition{Field: "b", Operation: In, Value: Value{Value: []string{"x", "y"}}}),
+			Leaf(Condition{Field: "c", Operation: Null}),
+		),
+		Leaf(Condition{Field: "d", Operation: Like, Value: Value{Value: "z%"}}),
+	)
+	where, values := SqlDialect{}.Where(filter, 0)
+	expected := " WHERE a <> ? AND (b IN (?, ?) OR c IS NULL) AND d LIKE ?"
+	if where != expected {
+		t.Fatalf("Wrong where clause, expected: <%s> but was: <%s>", expected, where)
+	}
+	if len(values) != 4 || values[3] != "z%" {
+		t.Fatalf("Wrong where values: %v", values)
+	}
+	numbered := SqlDialect{Placeholder: func(position int) string {
+		return fmt.Sprintf("$%v", position)
+	}}
+	where, _ = numbered.Where(filter, 1)
+	expected = " WHERE a <> $2 AND (b IN ($3, $4) OR c IS NULL) AND d LIKE $5"
+	if where != expected {
+		t.Fatalf("Wrong numbered where clause, expected: <%s> but was: <%s>", expected, where)
+	}
+}
+
+func TestExpandValues(t *testing.T) {
+	if values := ExpandValues([]int{1, 2}); len(values) != 2 || values[1] != 2 {
+		t.Fatalf("Wrong expanded slice: %v", values)
+	}
+	if values := ExpandValues([]byte("ab")); len(values) != 1 {
+		t.Fatalf("Byte slices should not be expanded: %v", values)
+	}
+	if values := ExpandValues(nil); len(values) != 0 {
+		t.Fatalf("Nil value should expand to no values: %v", values)
+	}
+}
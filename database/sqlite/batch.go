@@ -0,0 +1,108 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+	"strings"
+)
+
+// Builds the multi-row INSERT statement and its values, updating the non key fields ON CONFLICT on upsert
+func prepareInsertBatch(dbRef database.DataRef, fields []database.Field, rows [][]database.Value, options database.BatchOptions) (string, []interface{}) {
+	var cols = make([]string, 0)
+	var updates = make([]string, 0)
+	for _, f := range fields {
+		cols = append(cols, f.Name)
+		if !options.IsKey(f.Name) {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", f.Name, f.Name))
+		}
+	}
+	tuple := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(fields)), ", ") + ")"
+	var tuples = make([]string, 0)
+	var sqlValues = make([]interface{}, 0)
+	for _, row := range rows {
+		tuples = append(tuples, tuple)
+		for _, val := range row {
+			sqlValues = append(sqlValues, val.Value)
+		}
+	}
+	sqlText := fmt.Sprintf("INSERT INTO %s(%s) VALUES %s", database.TableName(dbRef), strings.Join(cols, ", "), strings.Join(tuples, ", "))
+	if options.Upsert {
+		sqlText += fmt.Sprintf(" ON CONFLICT (%s)", strings.Join(options.KeyFields, ", "))
+		if len(updates) > 0 {
+			sqlText += " DO UPDATE SET " + strings.Join(updates, ", ")
+		} else {
+			sqlText += " DO NOTHING"
+		}
+	}
+	return sqlText, sqlValues
+}
+
+func (c *sqliteConnection) InsertBatch(dbRef database.DataRef, fields []database.Field, rows [][]database.Value, options database.BatchOptions) ([]database.BatchResult, error) {
+	return c.InsertBatchContext(context.Background(), dbRef, fields, rows, options)
+}
+
+// Inserts the rows with multi-row INSERT statements, the upsert requires the options KeyFields to match a table
+// unique constraint. SQLite doesn't tell inserted and updated records apart, so on upsert the records already
+// having the batch keys are counted before writing the batch.
+func (c *sqliteConnection) InsertBatchContext(ctx context.Context, dbRef database.DataRef, fields []database.Field, rows [][]database.Value, options database.BatchOptions) (_ []database.BatchResult, err error) {
+	defer opError("InsertBatch", dbRef, &err)
+	var results = make([]database.BatchResult, 0)
+	if c.DB == nil {
+		return results, database.ErrClosed
+	}
+	if options.Upsert && len(options.KeyFields) == 0 {
+		return results, errors.New(fmt.Sprint("Upsert needs the list of key fields"))
+	}
+	batches, err := database.Batches(fields, rows, options)
+	if err != nil {
+		return results, err
+	}
+	for _, batch := range batches {
+		var existing int64
+		if options.Upsert {
+			if existing, err = c.countExisting(ctx, dbRef, fields, batch, options); err != nil {
+				return results, err
+			}
+		}
+		sqlText, sqlValues := prepareInsertBatch(dbRef, fields, batch, options)
+		r, err := c.executor().ExecContext(ctx, sqlText, sqlValues...)
+		if err != nil {
+			return results, err
+		}
+		affected, err := r.RowsAffected()
+		if err != nil {
+			return results, err
+		}
+		if existing > affected {
+			existing = affected
+		}
+		results = append(results, database.BatchResult{
+			Inserted: affected - existing,
+			Updated:  existing,
+		})
+	}
+	return results, nil
+}
+
+// Counts the records having the keys of the batch rows
+func (c *sqliteConnection) countExisting(ctx context.Context, dbRef database.DataRef, fields []database.Field, batch [][]database.Value, options database.BatchOptions) (int64, error) {
+	var keys = make([]database.Filter, 0)
+	for _, row := range batch {
+		keys = append(keys, options.KeyFilter(fields, row))
+	}
+	where, whereValues := dialect.Where(database.Or(keys...), 0)
+	var count int64
+	rows, err := c.executor().QueryContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s%s", database.TableName(dbRef), where), whereValues...)
+	if err != nil {
+		return count, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	if rows.Next() {
+		err = rows.Scan(&count)
+	}
+	return count, err
+}
@@ -0,0 +1,436 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type sqliteConnection struct {
+	Configuration database.DbConfig
+	DB            *sql.DB
+	tx            *sql.Tx
+	err           error
+	// Resolved database file path
+	path string
+}
+
+// SQLite statements dialect, auto increment fields must be the only primary key INTEGER field
+var dialect = database.SqlDialect{
+	NoLimit:   "-1",
+	InlineKey: true,
+	Constraints: func(f database.Field, inlineKey bool) (string, error) {
+		var constraints = ""
+		if f.AutoIncrement && (!inlineKey || strings.ToUpper(f.Type) != "INTEGER") {
+			return "", errors.New(fmt.Sprintf("Auto increment column %s must be the only INTEGER primary key column", f.Name))
+		}
+		if inlineKey {
+			constraints += " PRIMARY KEY"
+			if f.AutoIncrement {
+				constraints += " AUTOINCREMENT"
+			}
+		}
+		if f.NotNull {
+			constraints += " NOT NULL"
+		}
+		return constraints, nil
+	},
+}
+
+func toSqliteTypeInstance(typeName string) (reflect.Type, interface{}) {
+	if idx := strings.Index(typeName, "("); idx > 0 {
+		typeName = typeName[:idx]
+	}
+	switch strings.TrimSpace(strings.ToLower(typeName)) {
+	case "integer", "int", "tinyint", "smallint", "mediumint", "bigint":
+		v := int64(0)
+		return reflect.TypeOf(v), v
+	case "real", "double", "float", "numeric", "decimal":
+		v := float64(0)
+		return reflect.TypeOf(v), v
+	case "boolean", "bool":
+		v := false
+		return reflect.TypeOf(v), v
+	case "text", "char", "varchar", "clob":
+		v := ""
+		return reflect.TypeOf(v), v
+	case "date", "datetime", "timestamp":
+		v := time.Now()
+		return reflect.TypeOf(v), v
+	case "blob":
+		v := make([]byte, 0)
+		return reflect.TypeOf(v), v
+	default:
+		return reflect.TypeOf(""), sql.RawBytes{}
+	}
+}
+
+func (c *sqliteConnection) Query(dbRef database.DataRef, fields []string, conditions []database.Condition, withAnd bool) (database.ResultSet, error) {
+	return c.QueryContext(context.Background(), dbRef, fields, conditions, withAnd)
+}
+
+func (c *sqliteConnection) QueryContext(ctx context.Context, dbRef database.DataRef, fields []string, conditions []database.Condition, withAnd bool) (database.ResultSet, error) {
+	return c.QueryFilter(ctx, dbRef, fields, database.FromConditions(conditions, withAnd))
+}
+
+func (c *sqliteConnection) QueryFilter(ctx context.Context, dbRef database.DataRef, fields []string, filter database.Filter) (database.ResultSet, error) {
+	return c.QueryPage(ctx, dbRef, fields, filter, database.QueryOptions{})
+}
+
+// Executes the query applying the options, reading all the records in the result set
+func (c *sqliteConnection) QueryPage(ctx context.Context, dbRef database.DataRef, fields []string, filter database.Filter, options database.QueryOptions) (_ database.ResultSet, err error) {
+	defer opError("Query", dbRef, &err)
+	rows, err := c.Stream(ctx, dbRef, fields, filter, options)
+	if err != nil {
+		return database.ResultSet{
+			Records: make([]database.Result, 0),
+			Lines:   0,
+			MetaData: database.MetaData{
+				EntityRef: dbRef,
+				Columns:   make([]database.Column, 0),
+			},
+		}, err
+	}
+	return database.Collect(rows)
+}
+
+// Executes the query applying the options, which are ignored for SQL statements queries, the returned rows
+// hold a database connection until closed, the only one of the in memory databases
+func (c *sqliteConnection) Stream(ctx context.Context, dbRef database.DataRef, fields []string, filter database.Filter, options database.QueryOptions) (_ database.Rows, err error) {
+	defer opError("Stream", dbRef, &err)
+	if c.DB == nil {
+		return nil, database.ErrClosed
+	}
+	var rows *sql.Rows
+	if err = options.Validate(); err != nil {
+		return nil, err
+	}
+	var after database.Filter
+	if after, err = options.AfterFilter(); err != nil {
+		return nil, err
+	}
+	filter = database.And(filter, after)
+	if dbRef.SQL != "" {
+		rows, err = c.executor().QueryContext(ctx, dbRef.SQL)
+	} else {
+		var selCols = strings.Join(fields, ", ")
+		if selCols == "" {
+			selCols = "*"
+		}
+		where, values := dialect.Where(filter, 0)
+		rows, err = c.executor().QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s%s%s", selCols, database.TableName(dbRef), where, dialect.QueryOptions(options)), values...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return database.NewSqlRows(rows, dbRef, toSqliteTypeInstance, "BLOB")
+}
+
+func (c *sqliteConnection) Insert(dbRef database.DataRef, fields []database.Field, values []database.Value) error {
+	return c.InsertContext(context.Background(), dbRef, fields, values)
+}
+
+func (c *sqliteConnection) InsertContext(ctx context.Context, dbRef database.DataRef, fields []database.Field, values []database.Value) (err error) {
+	defer opError("Insert", dbRef, &err)
+	if c.DB == nil {
+		return database.ErrClosed
+	}
+	if len(fields) != len(values) {
+		return errors.New(fmt.Sprintf("Columns and values must have same length: %v <> %v", len(fields), len(values)))
+	}
+	if len(fields) == 0 || len(values) == 0 {
+		return errors.New(fmt.Sprint("Insert statement needs list of Columns and Values of same length"))
+	}
+	var cols = make([]string, 0)
+	var sqlValues = make([]interface{}, 0)
+	for i, f := range fields {
+		cols = append(cols, f.Name)
+		sqlValues = append(sqlValues, values[i].Value)
+	}
+	colValues := strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ")
+	sqlText := fmt.Sprintf("INSERT INTO %s(%s) VALUES(%s)", database.TableName(dbRef), strings.Join(cols, ", "), colValues)
+	_, err = c.executor().ExecContext(ctx, sqlText, sqlValues...)
+	return err
+}
+
+func (c *sqliteConnection) Update(dbRef database.DataRef, conditions []database.Condition, fields []database.Field, values []database.Value, withAnd bool) (int64, error) {
+	return c.UpdateContext(context.Background(), dbRef, conditions, fields, values, withAnd)
+}
+
+func (c *sqliteConnection) UpdateContext(ctx context.Context, dbRef database.DataRef, conditions []database.Condition, fields []database.Field, values []database.Value, withAnd bool) (int64, error) {
+	return c.UpdateFilter(ctx, dbRef, database.FromConditions(conditions, withAnd), fields, values)
+}
+
+func (c *sqliteConnection) UpdateFilter(ctx context.Context, dbRef database.DataRef, filter database.Filter, fields []database.Field, values []database.Value) (_ int64, err error) {
+	defer opError("Update", dbRef, &err)
+	var records int64
+	if c.DB == nil {
+		return records, database.ErrClosed
+	}
+	if len(fields) != len(values) {
+		return records, errors.New(fmt.Sprintf("Columns and values must have same length: %v <> %v", len(fields), len(values)))
+	}
+	if len(fields) == 0 || len(values) == 0 {
+		return records, errors.New(fmt.Sprint("Update statement needs list of Columns and Values of same length"))
+	}
+	var cols = make([]string, 0)
+	var sqlValues = make([]interface{}, 0)
+	for i, f := range fields {
+		cols = append(cols, f.Name+" = ?")
+		sqlValues = append(sqlValues, values[i].Value)
+	}
+	where, whereValues := dialect.Where(filter, 0)
+	sqlValues = append(sqlValues, whereValues...)
+	sqlText := fmt.Sprintf("UPDATE %s SET %s%s", database.TableName(dbRef), strings.Join(cols, ", "), where)
+	r, err := c.executor().ExecContext(ctx, sqlText, sqlValues...)
+	if err != nil {
+		return records, err
+	}
+	records, err = r.RowsAffected()
+	return records, err
+}
+
+func (c *sqliteConnection) Delete(dbRef database.DataRef, conditions []database.Condition, withAnd bool) (int64, error) {
+	return c.DeleteContext(context.Background(), dbRef, conditions, withAnd)
+}
+
+func (c *sqliteConnection) DeleteContext(ctx context.Context, dbRef database.DataRef, conditions []database.Condition, withAnd bool) (int64, error) {
+	return c.DeleteFilter(ctx, dbRef, database.FromConditions(conditions, withAnd))
+}
+
+func (c *sqliteConnection) DeleteFilter(ctx context.Context, dbRef database.DataRef, filter database.Filter) (_ int64, err error) {
+	defer opError("Delete", dbRef, &err)
+	var records int64
+	if c.DB == nil {
+		return records, database.ErrClosed
+	}
+	where, whereValues := dialect.Where(filter, 0)
+	sqlText := fmt.Sprintf("DELETE FROM %s%s", database.TableName(dbRef), where)
+	r, err := c.executor().ExecContext(ctx, sqlText, whereValues...)
+	if err != nil {
+		return records, err
+	}
+	records, err = r.RowsAffected()
+	return records, err
+}
+
+// Counts the matching records, or the records of the SQL statement query
+func (c *sqliteConnection) Count(ctx context.Context, dbRef database.DataRef, filter database.Filter) (_ int64, err error) {
+	defer opError("Count", dbRef, &err)
+	if c.DB == nil {
+		return 0, database.ErrClosed
+	}
+	var count int64
+	if dbRef.SQL != "" {
+		err = c.executor().QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM (%s) AS counted", dbRef.SQL)).Scan(&count)
+		return count, err
+	}
+	where, values := dialect.Where(filter, 0)
+	err = c.executor().QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s%s", database.TableName(dbRef), where), values...).Scan(&count)
+	return count, err
+}
+
+func (c *sqliteConnection) Purge(dbRef database.DataRef) (int64, error) {
+	return c.PurgeContext(context.Background(), dbRef)
+}
+
+func (c *sqliteConnection) PurgeContext(ctx context.Context, dbRef database.DataRef) (_ int64, err error) {
+	defer opError("Purge", dbRef, &err)
+	var count int64
+	if c.DB == nil {
+		return count, database.ErrClosed
+	}
+	if dbRef.Namespace != "" {
+		_, err := c.DB.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", database.TableName(dbRef)))
+		if err != nil {
+			return count, err
+		}
+		return 1, nil
+	} else if dbRef.Database != "" || dbRef.Schema != "" {
+		var schema = dbRef.Schema
+		if schema == "" {
+			schema = "main"
+		}
+		rows, err := c.DB.QueryContext(ctx, fmt.Sprintf("SELECT name FROM %s.sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%%'", schema))
+		if err != nil {
+			return count, err
+		}
+		var tables = make([]string, 0)
+		var table string
+		for rows.Next() {
+			if err = rows.Scan(&table); err != nil {
+				_ = rows.Close()
+				return count, err
+			}
+			tables = append(tables, table)
+		}
+		_ = rows.Close()
+		for _, table := range tables {
+			_, err = c.DB.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s.%s", schema, table))
+			if err != nil {
+				return count, err
+			}
+			count++
+		}
+		return count, nil
+	}
+	return count, errors.New(fmt.Sprint("Please choose truncate entity between Namespace for Table and Database or Schema for all Tables"))
+}
+
+func (c *sqliteConnection) Create(dbRef database.DataRef, fields []database.Field) error {
+	return c.CreateContext(context.Background(), dbRef, fields)
+}
+
+func (c *sqliteConnection) CreateContext(ctx context.Context, dbRef database.DataRef, fields []database.Field) (err error) {
+	defer opError("Create", dbRef, &err)
+	if c.DB == nil {
+		return database.ErrClosed
+	}
+	if dbRef.Namespace != "" {
+		var sqlText string
+		sqlText, err = dialect.CreateTable(dbRef, fields)
+		if err != nil {
+			return err
+		}
+		_, err = c.DB.ExecContext(ctx, sqlText)
+	} else if dbRef.Database != "" || dbRef.Schema != "" {
+		err = c.CreateDbContext(ctx, dbRef)
+	}
+	return err
+}
+
+func (c *sqliteConnection) CreateDb(dbRef database.DataRef) error {
+	return c.CreateDbContext(context.Background(), dbRef)
+}
+
+// Creates a database attaching a new database file, next to the main database file, or a new in memory database
+func (c *sqliteConnection) CreateDbContext(ctx context.Context, dbRef database.DataRef) (err error) {
+	defer opError("CreateDb", dbRef, &err)
+	if c.DB == nil {
+		return database.ErrClosed
+	}
+	var name = dbRef.Schema
+	if name == "" {
+		name = dbRef.Database
+	}
+	if name == "" {
+		return errors.New(fmt.Sprint("Please provide the Database or Schema name to attach"))
+	}
+	var path = MemoryDatabase
+	if !isMemory(c.path) {
+		path = filepath.Join(filepath.Dir(c.path), name+".db")
+	}
+	_, err = c.DB.ExecContext(ctx, fmt.Sprintf("ATTACH DATABASE '%s' AS %s", strings.Replace(path, "'", "''", -1), name))
+	return err
+}
+
+func (c *sqliteConnection) Drop(dbRef database.DataRef) error {
+	return c.DropContext(context.Background(), dbRef)
+}
+
+func (c *sqliteConnection) DropContext(ctx context.Context, dbRef database.DataRef) (err error) {
+	defer opError("Drop", dbRef, &err)
+	if c.DB == nil {
+		return database.ErrClosed
+	}
+	if dbRef.Namespace != "" {
+		_, err = c.DB.ExecContext(ctx, fmt.Sprintf("DROP TABLE %s", database.TableName(dbRef)))
+	} else if dbRef.Database != "" || dbRef.Schema != "" {
+		return c.DropDbContext(ctx, dbRef)
+	} else {
+		return errors.New(fmt.Sprint("Please choose drop entity between Namespace for Table and Database or Schema for attached databases"))
+	}
+	return err
+}
+
+func (c *sqliteConnection) DropDb(dbRef database.DataRef) error {
+	return c.DropDbContext(context.Background(), dbRef)
+}
+
+// Drops a database detaching a previously attached database
+func (c *sqliteConnection) DropDbContext(ctx context.Context, dbRef database.DataRef) (err error) {
+	defer opError("DropDb", dbRef, &err)
+	if c.DB == nil {
+		return database.ErrClosed
+	}
+	var name = dbRef.Schema
+	if name == "" {
+		name = dbRef.Database
+	}
+	_, err = c.DB.ExecContext(ctx, fmt.Sprintf("DETACH DATABASE %s", name))
+	return err
+}
+
+func (c *sqliteConnection) Close() (err error) {
+	defer opError("Close", database.DataRef{}, &err)
+	if c.DB == nil {
+		return database.ErrClosed
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New(fmt.Sprintf("%v", r))
+		}
+		c.DB = nil
+	}()
+	err = c.DB.Close()
+	return err
+}
+
+// Executes the script statements in order, stopping at the first failing one
+func (c *sqliteConnection) ExecScript(ctx context.Context, dbRef database.DataRef, script string) (err error) {
+	defer opError("ExecScript", dbRef, &err)
+	if c.DB == nil {
+		return database.ErrClosed
+	}
+	for i, statement := range database.SplitStatements(script) {
+		if _, err := c.executor().ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("Statement %v failed: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (c *sqliteConnection) Ping(ctx context.Context) (err error) {
+	defer opError("Ping", database.DataRef{}, &err)
+	if c.DB == nil {
+		return database.ErrClosed
+	}
+	return c.DB.PingContext(ctx)
+}
+
+func (c *sqliteConnection) ServerVersion(ctx context.Context) (_ string, err error) {
+	defer opError("ServerVersion", database.DataRef{}, &err)
+	var version string
+	if c.DB == nil {
+		return version, database.ErrClosed
+	}
+	err = c.DB.QueryRowContext(ctx, "SELECT sqlite_version()").Scan(&version)
+	return version, err
+}
+
+// Returns the connection configuration, with the Driver name
+func (c *sqliteConnection) Config() database.DbConfig {
+	config := c.Configuration
+	if config.Driver == "" {
+		config.Driver = "sqlite"
+	}
+	return config
+}
+
+func (c *sqliteConnection) Stats() database.PoolStats {
+	return database.SqlPoolStats(c.DB)
+}
+
+func (c *sqliteConnection) IsConnected() bool {
+	return c.DB != nil
+}
+
+func (c *sqliteConnection) GetLastError() error {
+	return c.err
+}
@@ -0,0 +1,678 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func connect(t *testing.T) database.Connection {
+	driver := GetSqliteDriver()
+	conn, err := driver.Connect(database.DbConfig{
+		Url: MemoryDatabase,
+	})
+	if err != nil {
+		t.Fatalf("Connection error occured: %v", err)
+	}
+	return conn
+}
+
+func TestSqliteConnection(t *testing.T) {
+	conn := connect(t)
+	defer func() {
+		_ = conn.Close()
+	}()
+	config := database.DataRef{
+		Namespace: "sample",
+	}
+	err := conn.Create(config, []database.Field{
+		{Name: "code", Type: "varchar", Size: 36},
+		{Name: "name", Type: "varchar", Size: 50},
+		{Name: "surname", Type: "varchar", Size: 50},
+		{Name: "age", Type: "integer"},
+		{Name: "role", Type: "varchar", Size: 50},
+	})
+	if err != nil {
+		t.Fatalf("Database table creation error occured: %v", err)
+	}
+	fields := []database.Field{{Name: "code"}, {Name: "name"}, {Name: "surname"}, {Name: "age"}, {Name: "role"}}
+	rows := [][]interface{}{
+		{"1", "Fabrizio", "Torelli", 45, "System Architect"},
+		{"2", "Francesco", "Torelli", 42, "Software Developer"},
+		{"3", "Mario", "Rossi", 30, nil},
+	}
+	for _, row := range rows {
+		var values = make([]database.Value, 0)
+		for _, v := range row {
+			values = append(values, database.Value{Value: v})
+		}
+		err = conn.Insert(config, fields, values)
+		if err != nil {
+			t.Fatalf("Database table insert error occured: %v", err)
+		}
+	}
+	conditions := []database.Condition{
+		{
+			Field:     "name",
+			Operation: database.Equals,
+			Value:     database.Value{Type: "string", Value: "Francesco"},
+		},
+		{
+			Field:     "surname",
+			Operation: database.Equals,
+			Value:     database.Value{Type: "string", Value: "Torelli"},
+		},
+	}
+	rs, err := conn.Query(config, []string{"name", "age"}, conditions, true)
+	if err != nil {
+		t.Fatalf("Database table querying error occured: %v", err)
+	}
+	if rs.Lines != 1 || len(rs.Records) != 1 {
+		t.Fatalf("Wrong number of results: %v", rs.Lines)
+	}
+	if len(rs.MetaData.Columns) != 2 || rs.MetaData.Columns[0].Name != "name" {
+		t.Fatalf("Wrong result set metadata: %v", rs.MetaData.Columns)
+	}
+	if rs.Records[0].Values[0] != "Francesco" || rs.Records[0].Values[1] != int64(42) {
+		t.Fatalf("Wrong record values: %v", rs.Records[0].Values)
+	}
+	rs, err = conn.Query(config, []string{}, conditions, false)
+	if err != nil {
+		t.Fatalf("Database table querying error occured: %v", err)
+	}
+	if rs.Lines != 2 {
+		t.Fatalf("Wrong number of results for or conditions: %v", rs.Lines)
+	}
+	rs, err = conn.Query(config, []string{"code"}, []database.Condition{
+		{
+			Field:     "age",
+			Operation: database.In,
+			Value:     database.Value{Type: "array", Value: []int{30, 45}},
+		},
+		{
+			Field:     "role",
+			Operation: database.Not + database.Null,
+		},
+	}, true)
+	if err != nil {
+		t.Fatalf("Database table querying error occured: %v", err)
+	}
+	if rs.Lines != 1 || rs.Records[0].Values[0] != "1" {
+		t.Fatalf("Wrong results for in and not null conditions: %v", rs.Records)
+	}
+	count, err := conn.Update(config, conditions, []database.Field{{Name: "role"}}, []database.Value{{Value: "Hardware Specialist"}}, true)
+	if err != nil {
+		t.Fatalf("Database table update error occured: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Wrong number of updated records %v", count)
+	}
+	count, err = conn.Delete(config, []database.Condition{
+		{
+			Field:     "age",
+			Operation: database.LessThan,
+			Value:     database.Value{Type: "int", Value: 40},
+		},
+	}, true)
+	if err != nil {
+		t.Fatalf("Database table delete error occured: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Wrong number of deleted records %v", count)
+	}
+	_, err = conn.Purge(config)
+	if err != nil {
+		t.Fatalf("Database table purge error occured: %v", err)
+	}
+	rs, err = conn.Query(config, []string{}, []database.Condition{}, true)
+	if err != nil {
+		t.Fatalf("Database table querying error occured: %v", err)
+	}
+	if rs.Lines != 0 {
+		t.Fatalf("Wrong number of results after purge: %v", rs.Lines)
+	}
+	err = conn.Drop(config)
+	if err != nil {
+		t.Fatalf("Database table drop error occured: %v", err)
+	}
+}
+
+func TestSqliteDatabase(t *testing.T) {
+	conn := connect(t)
+	defer func() {
+		_ = conn.Close()
+	}()
+	config := database.DataRef{
+		Schema: "test",
+	}
+	err := conn.CreateDb(config)
+	if err != nil {
+		t.Fatalf("Database creation error occured: %v", err)
+	}
+	config.Namespace = "sample"
+	err = conn.Create(config, []database.Field{{Name: "code", Type: "text"}})
+	if err != nil {
+		t.Fatalf("Database table creation error occured: %v", err)
+	}
+	err = conn.Insert(config, []database.Field{{Name: "code"}}, []database.Value{{Value: "1"}})
+	if err != nil {
+		t.Fatalf("Database table insert error occured: %v", err)
+	}
+	count, err := conn.Purge(database.DataRef{Schema: "test"})
+	if err != nil {
+		t.Fatalf("Database purge error occured: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Wrong number of purged tables %v", count)
+	}
+	err = conn.DropDb(database.DataRef{Schema: "test"})
+	if err != nil {
+		t.Fatalf("Database drop error occured: %v", err)
+	}
+}
+
+func TestSqliteSecretConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SQLITE_TEST_PATH", filepath.Join(dir, "app.db"))
+	conn, err := GetSqliteDriver().Connect(database.DbConfig{Url: "secret://env/SQLITE_TEST_PATH"})
+	if err != nil {
+		t.Fatalf("Connection error occured: %v", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	if url := conn.(database.ConfigConnection).Config().Url; url != "secret://env/SQLITE_TEST_PATH" {
+		t.Fatalf("Connection should keep the secret reference: %v", url)
+	}
+	if err = conn.CreateDb(database.DataRef{Schema: "other"}); err != nil {
+		t.Fatalf("Database creation error occured: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "other.db")); err != nil {
+		t.Fatalf("Attached database should be next to the resolved path: %v", err)
+	}
+}
+
+func TestSqliteCreateTable(t *testing.T) {
+	conn := connect(t)
+	defer func() {
+		_ = conn.Close()
+	}()
+	config := database.DataRef{
+		Namespace:   "users",
+		IfNotExists: true,
+	}
+	fields := []database.Field{
+		{Name: "id", Type: "INTEGER", PrimaryKey: true, AutoIncrement: true},
+		{Name: "email", Type: "VARCHAR", Size: 255, NotNull: true, Unique: true},
+		{Name: "status", Type: "VARCHAR", Size: 10, Default: "'active'"},
+	}
+	err := conn.Create(config, fields)
+	if err != nil {
+		t.Fatalf("Database table creation error occured: %v", err)
+	}
+	err = conn.Create(config, fields)
+	if err != nil {
+		t.Fatalf("Database table creation if not exists error occured: %v", err)
+	}
+	for _, email := range []string{"a@b.c", "d@e.f"} {
+		err = conn.Insert(config, []database.Field{{Name: "email"}}, []database.Value{{Value: email}})
+		if err != nil {
+			t.Fatalf("Database table insert error occured: %v", err)
+		}
+	}
+	err = conn.Insert(config, []database.Field{{Name: "email"}}, []database.Value{{Value: "a@b.c"}})
+	if err == nil {
+		t.Fatal("Duplicate unique value insert should fail")
+	}
+	rs, err := conn.Query(config, []string{"id", "status"}, []database.Condition{}, true)
+	if err != nil {
+		t.Fatalf("Database table querying error occured: %v", err)
+	}
+	if rs.Lines != 2 || rs.Records[1].Values[0] != int64(2) || rs.Records[1].Values[1] != "active" {
+		t.Fatalf("Wrong generated values: %v", rs.Records)
+	}
+}
+
+func TestSqliteFilter(t *testing.T) {
+	conn := connect(t)
+	defer func() {
+		_ = conn.Close()
+	}()
+	config := database.DataRef{
+		Namespace: "sample",
+	}
+	err := conn.Create(config, []database.Field{
+		{Name: "a", Type: "integer"},
+		{Name: "b", Type: "integer"},
+		{Name: "c", Type: "varchar", Size: 10},
+	})
+	if err != nil {
+		t.Fatalf("Database table creation error occured: %v", err)
+	}
+	fields := []database.Field{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	rows := [][]interface{}{
+		{1, 3, "x"},
+		{1, 1, "y"},
+		{2, 5, nil},
+		{2, 1, "z"},
+	}
+	for _, row := range rows {
+		err = conn.Insert(config, fields, []database.Value{{Value: row[0]}, {Value: row[1]}, {Value: row[2]}})
+		if err != nil {
+			t.Fatalf("Database table insert error occured: %v", err)
+		}
+	}
+	// (a = 1 AND b > 2) OR c IS NULL
+	filter := database.Or(
+		database.And(
+			database.Leaf(database.Condition{Field: "a", Operation: database.Equals, Value: database.Value{Value: 1}}),
+			database.Leaf(database.Condition{Field: "b", Operation: database.GraterThan, Value: database.Value{Value: 2}}),
+		),
+		database.Leaf(database.Condition{Field: "c", Operation: database.Null}),
+	)
+	rs, err := conn.QueryFilter(context.Background(), config, []string{"c"}, filter)
+	if err != nil {
+		t.Fatalf("Database table querying error occured: %v", err)
+	}
+	if rs.Lines != 2 || rs.Records[0].Values[0] != "x" || rs.Records[1].Values[0] != nil {
+		t.Fatalf("Wrong results for nested filter: %v", rs.Records)
+	}
+	count, err := conn.UpdateFilter(context.Background(), config, database.Negate(filter), []database.Field{{Name: "c"}}, []database.Value{{Value: "w"}})
+	if err != nil {
+		t.Fatalf("Database table update error occured: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Wrong number of updated records %v", count)
+	}
+	if count, err = conn.Count(context.Background(), config, filter); err != nil || count != 2 {
+		t.Fatalf("Wrong number of counted records: %v %v", count, err)
+	}
+	empty := database.Leaf(database.Condition{Field: "a", Operation: database.In, Value: database.Value{Value: []int{}}})
+	if count, err = conn.Count(context.Background(), config, empty); err != nil || count != 0 {
+		t.Fatalf("Wrong number of records in the empty list: %v %v", count, err)
+	}
+	if count, err = conn.Count(context.Background(), config, database.Negate(empty)); err != nil || count != 4 {
+		t.Fatalf("Wrong number of records not in the empty list: %v %v", count, err)
+	}
+	count, err = conn.Count(context.Background(), database.DataRef{SQL: "SELECT a FROM sample WHERE a = 2"}, database.Filter{})
+	if err != nil || count != 2 {
+		t.Fatalf("Wrong number of counted query records: %v %v", count, err)
+	}
+	count, err = conn.DeleteFilter(context.Background(), config, filter)
+	if err != nil {
+		t.Fatalf("Database table delete error occured: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Wrong number of deleted records %v", count)
+	}
+}
+
+func TestSqliteQueryPage(t *testing.T) {
+	conn := connect(t)
+	defer func() {
+		_ = conn.Close()
+	}()
+	config := database.DataRef{
+		Namespace: "sample",
+	}
+	err := conn.Create(config, []database.Field{
+		{Name: "code", Type: "integer"},
+		{Name: "name", Type: "varchar", Size: 10},
+	})
+	if err != nil {
+		t.Fatalf("Database table creation error occured: %v", err)
+	}
+	for i, name := range []string{"e", "b", "d", "a", "c"} {
+		err = conn.Insert(config, []database.Field{{Name: "code"}, {Name: "name"}}, []database.Value{{Value: i + 1}, {Value: name}})
+		if err != nil {
+			t.Fatalf("Database table insert error occured: %v", err)
+		}
+	}
+	options := database.QueryOptions{
+		OrderBy: []database.OrderBy{{Field: "name", Direction: database.Descending}},
+		Limit:   2,
+		Offset:  1,
+	}
+	rs, err := conn.QueryPage(context.Background(), config, []string{"name"}, database.And(), options)
+	if err != nil {
+		t.Fatalf("Database table querying error occured: %v", err)
+	}
+	if rs.Lines != 2 || rs.Records[0].Values[0] != "d" || rs.Records[1].Values[0] != "c" {
+		t.Fatalf("Wrong page records: %v", rs.Records)
+	}
+	options.Offset = 0
+	options.After, err = database.NextCursor(rs, options)
+	if err != nil {
+		t.Fatalf("Next cursor error occured: %v", err)
+	}
+	rs, err = conn.QueryPage(context.Background(), config, []string{"name"}, database.And(), options)
+	if err != nil {
+		t.Fatalf("Database table querying error occured: %v", err)
+	}
+	if rs.Lines != 2 || rs.Records[0].Values[0] != "b" || rs.Records[1].Values[0] != "a" {
+		t.Fatalf("Wrong keyset page records: %v", rs.Records)
+	}
+}
+
+func TestSqliteStream(t *testing.T) {
+	conn := connect(t)
+	defer func() {
+		_ = conn.Close()
+	}()
+	config := database.DataRef{
+		Namespace: "sample",
+	}
+	err := conn.Create(config, []database.Field{
+		{Name: "code", Type: "integer"},
+		{Name: "name", Type: "varchar", Size: 10},
+		{Name: "data", Type: "blob"},
+	})
+	if err != nil {
+		t.Fatalf("Database table creation error occured: %v", err)
+	}
+	for i := 1; i <= 100; i++ {
+		err = conn.Insert(config, []database.Field{{Name: "code"}, {Name: "name"}, {Name: "data"}},
+			[]database.Value{{Value: i}, {Value: fmt.Sprintf("n%v", i)}, {Value: []byte{byte(i)}}})
+		if err != nil {
+			t.Fatalf("Database table insert error occured: %v", err)
+		}
+	}
+	rows, err := conn.Stream(context.Background(), config, []string{}, database.And(), database.QueryOptions{
+		OrderBy: []database.OrderBy{{Field: "code"}},
+	})
+	if err != nil {
+		t.Fatalf("Database table streaming error occured: %v", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	if len(rows.MetaData().Columns) != 3 {
+		t.Fatalf("Wrong rows metadata: %v", rows.MetaData().Columns)
+	}
+	var count, sum int
+	for rows.Next() {
+		var code int
+		var name string
+		var data []byte
+		if err = rows.Scan(&code, &name, &data); err != nil {
+			t.Fatalf("Rows scan error occured: %v", err)
+		}
+		current := rows.Current()
+		if current.Values[1] != name || current.Values[0] != int64(code) || len(current.Values[2].([]byte)) != 1 {
+			t.Fatalf("Wrong current record: %v", current.Values)
+		}
+		count++
+		sum += code
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatalf("Rows iteration error occured: %v", err)
+	}
+	if count != 100 || sum != 5050 {
+		t.Fatalf("Wrong streamed records: %v %v", count, sum)
+	}
+}
+
+func TestSqliteTransaction(t *testing.T) {
+	conn := connect(t)
+	defer func() {
+		_ = conn.Close()
+	}()
+	config := database.DataRef{
+		Namespace: "sample",
+	}
+	err := conn.Create(config, []database.Field{{Name: "code", Type: "integer"}})
+	if err != nil {
+		t.Fatalf("Database table creation error occured: %v", err)
+	}
+	insert := func(tx database.Tx, code int) error {
+		return tx.InsertContext(context.Background(), config, []database.Field{{Name: "code"}}, []database.Value{{Value: code}})
+	}
+	count := func() int64 {
+		rs, err := conn.Query(config, []string{}, []database.Condition{}, true)
+		if err != nil {
+			t.Fatalf("Database table querying error occured: %v", err)
+		}
+		return rs.Lines
+	}
+	err = database.RunInTxContext(context.Background(), conn, database.TxOptions{}, func(tx database.Tx) error {
+		if err := insert(tx, 1); err != nil {
+			return err
+		}
+		return insert(tx, 2)
+	})
+	if err != nil {
+		t.Fatalf("Transaction error occured: %v", err)
+	}
+	if count() != 2 {
+		t.Fatalf("Wrong number of committed records: %v", count())
+	}
+	err = database.RunInTxContext(context.Background(), conn, database.TxOptions{}, func(tx database.Tx) error {
+		if err := insert(tx, 3); err != nil {
+			return err
+		}
+		return errors.New("failure")
+	})
+	if err == nil || err.Error() != "failure" {
+		t.Fatalf("Transaction function error expected: %v", err)
+	}
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("Transaction function panic should be propagated")
+			}
+		}()
+		_ = database.RunInTxContext(context.Background(), conn, database.TxOptions{}, func(tx database.Tx) error {
+			_ = insert(tx, 4)
+			panic("failure")
+		})
+	}()
+	if count() != 2 {
+		t.Fatalf("Wrong number of records after rollback: %v", count())
+	}
+	tx, err := conn.Begin(context.Background(), database.TxOptions{})
+	if err != nil {
+		t.Fatalf("Transaction begin error occured: %v", err)
+	}
+	if _, ok := tx.(database.Connection); ok {
+		t.Fatal("Transaction should not expose the connection methods")
+	}
+	if _, err = tx.DeleteFilter(context.Background(), config, database.And()); err != nil {
+		t.Fatalf("Transaction delete error occured: %v", err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("Transaction commit error occured: %v", err)
+	}
+	if count() != 0 {
+		t.Fatalf("Wrong number of records after commit: %v", count())
+	}
+	if err = tx.Rollback(); err == nil {
+		t.Fatal("Rollback after commit should fail")
+	}
+}
+
+func TestSqliteStructMapping(t *testing.T) {
+	type user struct {
+		ID     int64  `db:"id,omitempty"`
+		Email  string `db:"email"`
+		Active bool   `db:"active"`
+	}
+	conn := connect(t)
+	defer func() {
+		_ = conn.Close()
+	}()
+	config := database.DataRef{
+		Namespace: "users",
+	}
+	err := conn.Create(config, []database.Field{
+		{Name: "id", Type: "INTEGER", PrimaryKey: true, AutoIncrement: true},
+		{Name: "email", Type: "VARCHAR", Size: 255},
+		{Name: "active", Type: "BOOLEAN"},
+	})
+	if err != nil {
+		t.Fatalf("Database table creation error occured: %v", err)
+	}
+	for _, u := range []user{{Email: "a@b.c", Active: true}, {Email: "d@e.f"}} {
+		fields, values, err := database.FieldValues(u)
+		if err != nil {
+			t.Fatalf("Field values error occured: %v", err)
+		}
+		if err = conn.Insert(config, fields, values); err != nil {
+			t.Fatalf("Database table insert error occured: %v", err)
+		}
+	}
+	rs, err := conn.Query(config, []string{}, []database.Condition{}, true)
+	if err != nil {
+		t.Fatalf("Database table querying error occured: %v", err)
+	}
+	var users []user
+	if err = database.ScanInto(rs, &users); err != nil {
+		t.Fatalf("Result set scan error occured: %v", err)
+	}
+	if len(users) != 2 || users[0] != (user{ID: 1, Email: "a@b.c", Active: true}) || users[1] != (user{ID: 2, Email: "d@e.f"}) {
+		t.Fatalf("Wrong scanned records: %v", users)
+	}
+}
+
+func TestSqliteInsertBatch(t *testing.T) {
+	conn := connect(t)
+	defer func() {
+		_ = conn.Close()
+	}()
+	config := database.DataRef{
+		Namespace: "sample",
+	}
+	err := conn.Create(config, []database.Field{
+		{Name: "code", Type: "varchar", Size: 36, PrimaryKey: true},
+		{Name: "name", Type: "varchar", Size: 50},
+	})
+	if err != nil {
+		t.Fatalf("Database table creation error occured: %v", err)
+	}
+	fields := []database.Field{{Name: "code"}, {Name: "name"}}
+	rows := [][]database.Value{
+		{{Value: "1"}, {Value: "Mario"}},
+		{{Value: "2"}, {Value: "Luigi"}},
+		{{Value: "3"}, {Value: "Peach"}},
+	}
+	results, err := conn.InsertBatch(config, fields, rows, database.BatchOptions{BatchSize: 2})
+	if err != nil {
+		t.Fatalf("Database batch insert error occured: %v", err)
+	}
+	if len(results) != 2 || results[0].Inserted != 2 || results[1].Inserted != 1 {
+		t.Fatalf("Wrong batch results: %v", results)
+	}
+	rows = [][]database.Value{
+		{{Value: "2"}, {Value: "Luigi Bros"}},
+		{{Value: "4"}, {Value: "Toad"}},
+	}
+	results, err = conn.InsertBatch(config, fields, rows, database.BatchOptions{Upsert: true, KeyFields: []string{"code"}})
+	if err != nil {
+		t.Fatalf("Database batch upsert error occured: %v", err)
+	}
+	if len(results) != 1 || results[0].Inserted != 1 || results[0].Updated != 1 {
+		t.Fatalf("Wrong batch results: %v", results)
+	}
+	rs, _ := conn.Query(config, []string{"name"}, []database.Condition{{Field: "code", Value: database.Value{Value: "2"}}}, true)
+	if rs.Lines != 1 || rs.Records[0].Values[0] != "Luigi Bros" {
+		t.Fatalf("Record should be updated: %v", rs.Records)
+	}
+	if _, err = conn.InsertBatch(config, fields, rows, database.BatchOptions{}); err == nil {
+		t.Fatal("Expected duplicate key error")
+	}
+}
+
+func TestSqlitePoolStats(t *testing.T) {
+	conn, err := GetSqliteDriver().Connect(database.DbConfig{
+		Url:  filepath.Join(t.TempDir(), "pool.db"),
+		Pool: database.PoolConfig{MaxOpen: 3, MaxIdle: 2},
+	})
+	if err != nil {
+		t.Fatalf("Connection error occured: %v", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	if _, err = conn.Query(database.DataRef{Namespace: "sqlite_master"}, []string{}, []database.Condition{}, true); err != nil {
+		t.Fatalf("Database query error occured: %v", err)
+	}
+	stats := conn.Stats()
+	if stats.MaxOpen != 3 || stats.Open != 1 || stats.Idle != 1 || stats.InUse != 0 {
+		t.Fatalf("Wrong pool statistics: %+v", stats)
+	}
+}
+
+func TestSqliteMemoryPool(t *testing.T) {
+	conn, err := GetSqliteDriver().Connect(database.DbConfig{
+		Url:  MemoryDatabase,
+		Pool: database.PoolConfig{MaxOpen: 3, MaxIdle: 0, IdleTimeout: time.Millisecond, MaxLifetime: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("Connection error occured: %v", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	ref := database.DataRef{Namespace: "kept"}
+	if err = conn.Create(ref, []database.Field{{Name: "id", Type: "integer", PrimaryKey: true}}); err != nil {
+		t.Fatalf("Table creation error occured: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err = conn.Query(ref, []string{}, []database.Condition{}, true); err != nil {
+		t.Fatalf("Expected the in memory table to survive the pool timeouts: %v", err)
+	}
+	if stats := conn.Stats(); stats.MaxOpen != 1 || stats.Open != 1 {
+		t.Fatalf("Wrong pool statistics: %+v", stats)
+	}
+}
+
+func TestSqliteHealthCheck(t *testing.T) {
+	conn := connect(t)
+	check := database.CheckHealth(context.Background(), conn)
+	if !check.Healthy() || check.Version == "" {
+		t.Fatalf("Wrong health check: %+v", check)
+	}
+	_ = conn.Close()
+	if check = database.CheckHealth(context.Background(), conn); check.Healthy() {
+		t.Fatal("Closed connection should not be healthy")
+	}
+}
+
+func TestSqliteErrors(t *testing.T) {
+	conn := connect(t)
+	ref := database.DataRef{Namespace: "accounts"}
+	err := conn.Create(ref, []database.Field{
+		{Name: "id", Type: "integer", PrimaryKey: true},
+		{Name: "email", Type: "varchar", Size: 50, NotNull: true},
+	})
+	if err != nil {
+		t.Fatalf("Database table creation error occured: %v", err)
+	}
+	fields := []database.Field{{Name: "id"}, {Name: "email"}}
+	if err = conn.Insert(ref, fields, []database.Value{{Value: 1}, {Value: "a@b.c"}}); err != nil {
+		t.Fatalf("Database table insert error occured: %v", err)
+	}
+	err = conn.Insert(ref, fields, []database.Value{{Value: 1}, {Value: "d@e.f"}})
+	var opErr *database.OpError
+	if !errors.Is(err, database.ErrDuplicateKey) || !errors.As(err, &opErr) || opErr.Op != "Insert" || opErr.Driver != "sqlite" {
+		t.Fatalf("Expected duplicate key error: %v", err)
+	}
+	err = conn.Insert(ref, fields, []database.Value{{Value: 2}, {Value: nil}})
+	if !errors.Is(err, database.ErrConstraint) || errors.Is(err, database.ErrDuplicateKey) {
+		t.Fatalf("Expected constraint error: %v", err)
+	}
+	if _, err = conn.Query(database.DataRef{Namespace: "missing"}, []string{}, []database.Condition{}, true); !errors.Is(err, database.ErrNotFound) {
+		t.Fatalf("Expected not found error: %v", err)
+	}
+	_ = conn.Close()
+	if _, err = conn.Query(ref, []string{}, []database.Condition{}, true); !errors.Is(err, database.ErrClosed) {
+		t.Fatalf("Expected closed connection error: %v", err)
+	}
+	if err = conn.Close(); !errors.Is(err, database.ErrClosed) {
+		t.Fatalf("Expected closed connection error: %v", err)
+	}
+}
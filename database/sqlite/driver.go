@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+	_ "github.com/mattn/go-sqlite3"
+	"strings"
+)
+
+// In memory database file name, the in memory databases use a single connection: the open Stream rows and the running
+// transactions hold it, so other operations of the connection wait for it until their context ends
+const MemoryDatabase = ":memory:"
+
+func init() {
+	if err := database.RegisterType("sqlite", database.SQLiteDriver, []string{"sqlite3"}, GetSqliteDriver); err != nil {
+		panic(err)
+	}
+}
+
+type sqliteDriver struct {
+}
+
+func isMemory(path string) bool {
+	return path == MemoryDatabase || strings.Contains(path, "mode=memory")
+}
+
+// Connects resolving the configuration secrets, with the credentials masked in the returned errors. The connection
+// keeps the configuration with the secret references, resolved again by each new connection.
+func (d *sqliteDriver) Connect(config database.DbConfig) (database.Connection, error) {
+	resolved, err := database.ResolveSecrets(context.Background(), config)
+	if err != nil {
+		return nil, err
+	}
+	return d.ConnectResolved(resolved, config)
+}
+
+// Connects with the configuration secrets already resolved, with the credentials masked in the returned errors
+func (d *sqliteDriver) ConnectResolved(resolved database.DbConfig, config database.DbConfig) (database.Connection, error) {
+	conn, err := d.connect(resolved, config)
+	return conn, resolved.RedactError(err)
+}
+
+// Connects with the resolved configuration, keeping the unresolved one as the connection configuration
+func (d *sqliteDriver) connect(config database.DbConfig, unresolved database.DbConfig) (database.Connection, error) {
+	path := config.Url
+	if path == "" {
+		return nil, errors.New(fmt.Sprint("Please provide the database file path or :memory: in the configuration Url field"))
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	if isMemory(path) {
+		// Any new connection would open a new empty in memory database, so the single connection is never closed.
+		// The shared cache would allow a pool, but fails the writes of tables read by the open rows and sees the
+		// attached databases on one connection only.
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+	} else {
+		config.Pool.Apply(db)
+	}
+	if err = db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &sqliteConnection{
+		Configuration: unresolved,
+		DB:            db,
+		path:          path,
+	}, nil
+}
+
+// Retrieves the SQLite database driver
+func GetSqliteDriver() database.Driver {
+	return &sqliteDriver{}
+}
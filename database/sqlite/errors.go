@@ -0,0 +1,42 @@
+package sqlite
+
+import (
+	"errors"
+	"github.com/hellgate75/go-services/database"
+	"github.com/mattn/go-sqlite3"
+	"strings"
+)
+
+// Classifies the SQLite constraint errors, unique and primary key violations are ErrDuplicateKey, and the missing
+// tables as ErrNotFound
+func classify(err error) error {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+	if sqliteErr.Code == sqlite3.ErrError && strings.HasPrefix(sqliteErr.Error(), "no such table") {
+		return database.WrapError(database.ErrNotFound, err)
+	}
+	if sqliteErr.Code != sqlite3.ErrConstraint {
+		return err
+	}
+	switch sqliteErr.ExtendedCode {
+	case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+		return database.WrapError(database.ErrDuplicateKey, err)
+	}
+	return database.WrapError(database.ErrConstraint, err)
+}
+
+// Wraps the operation error in a database.OpError, classifying the SQLite constraint and missing table errors
+func opError(op string, dbRef database.DataRef, err *error) {
+	*err = database.NewOpError(op, "sqlite", dbRef, classify(*err))
+}
+
+// Verifies the error is a busy or locked database
+func (c *sqliteConnection) IsTransient(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+	return database.IsNetworkError(err)
+}
@@ -0,0 +1,39 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/hellgate75/go-services/database"
+)
+
+// Returns the transaction statements executor, when available, or the database one
+func (c *sqliteConnection) executor() database.SqlExecutor {
+	if c.tx != nil {
+		return c.tx
+	}
+	return c.DB
+}
+
+func (c *sqliteConnection) Begin(ctx context.Context, options database.TxOptions) (_ database.Tx, err error) {
+	defer opError("Begin", database.DataRef{}, &err)
+	if c.DB == nil {
+		return nil, database.ErrClosed
+	}
+	if c.tx != nil {
+		return nil, database.WrapError(database.ErrUnsupported, errors.New(fmt.Sprint("Nested transactions are not supported")))
+	}
+	tx, err := c.DB.BeginTx(ctx, &sql.TxOptions{
+		Isolation: options.Isolation,
+		ReadOnly:  options.ReadOnly,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return database.NewSqlTx(&sqliteConnection{
+		Configuration: c.Configuration,
+		DB:            c.DB,
+		tx:            tx,
+	}, tx, opError), nil
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TLS configuration structure, used by the MySQL, PostgreSQL and MongoDB drivers
+type TLSConfig struct {
+	// Enables TLS with the system CA certificates, implied by the other fields
+	Enabled bool `json:"enabled,omitempty" yaml:"enabled,omitempty" xml:"enabled,omitempty"`
+	// CA certificates bundle PEM file path, verifying the server certificate instead of the system CAs
+	CAFile string `json:"caFile,omitempty" yaml:"caFile,omitempty" xml:"ca-file,omitempty"`
+	// Client certificate PEM file path, DbConfig Certificate when empty
+	CertFile string `json:"certFile,omitempty" yaml:"certFile,omitempty" xml:"cert-file,omitempty"`
+	// Client private key PEM file path, DbConfig PrivateKey when empty, the certificate file when both are empty
+	KeyFile string `json:"keyFile,omitempty" yaml:"keyFile,omitempty" xml:"key-file,omitempty"`
+	// Server name verified against the server certificate, the connected host name when empty
+	ServerName string `json:"serverName,omitempty" yaml:"serverName,omitempty" xml:"server-name,omitempty"`
+	// Minimum TLS version: 1.0, 1.1, 1.2 or 1.3, 1.2 when empty
+	MinVersion string `json:"minVersion,omitempty" yaml:"minVersion,omitempty" xml:"min-version,omitempty"`
+	// Skips the server certificate verification, for development only
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty" yaml:"insecureSkipVerify,omitempty" xml:"insecure-skip-verify,omitempty"`
+	// Authenticates with the client certificate, MongoDB MONGODB-X509 mechanism
+	X509Auth bool `json:"x509Auth,omitempty" yaml:"x509Auth,omitempty" xml:"x509-auth,omitempty"`
+}
+
+// TLS versions by name
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Returns the TLS configuration with the client certificate and key defaulting to the Certificate and PrivateKey
+// fields, TLS is enabled when any of them is set
+func (c DbConfig) TLSSettings() TLSConfig {
+	settings := c.TLS
+	if settings.CertFile == "" {
+		settings.CertFile = c.Certificate
+	}
+	if settings.KeyFile == "" {
+		settings.KeyFile = c.PrivateKey
+	}
+	settings.Enabled = settings.Enabled || settings.CAFile != "" || settings.CertFile != "" || settings.KeyFile != "" ||
+		settings.ServerName != "" || settings.MinVersion != "" || settings.InsecureSkipVerify || settings.X509Auth
+	return settings
+}
+
+// Builds the client tls.Config, nil when TLS is not enabled
+func (c DbConfig) ClientTLS() (*tls.Config, error) {
+	settings := c.TLSSettings()
+	if !settings.Enabled {
+		return nil, nil
+	}
+	config := &tls.Config{
+		ServerName:         settings.ServerName,
+		InsecureSkipVerify: settings.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if settings.MinVersion != "" {
+		version, ok := tlsVersions[settings.MinVersion]
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("Unknown TLS version: %s, expected 1.0, 1.1, 1.2 or 1.3", settings.MinVersion))
+		}
+		config.MinVersion = version
+	}
+	if settings.CAFile != "" {
+		data, err := os.ReadFile(settings.CAFile)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Unable to read CA file: %v", err))
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(data) {
+			return nil, errors.New(fmt.Sprintf("No PEM certificates found in CA file: %s", settings.CAFile))
+		}
+	}
+	if settings.CertFile != "" {
+		keyFile := settings.KeyFile
+		if keyFile == "" {
+			keyFile = settings.CertFile
+		}
+		certificate, err := tls.LoadX509KeyPair(settings.CertFile, keyFile)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Unable to load client certificate: %v", err))
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	return config, nil
+}
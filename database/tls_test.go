@@ -0,0 +1,141 @@
+package database
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Generates a certificate signed by the parent one, self signed when parent is nil, writing the certificate and
+// key PEM files in the directory
+func generateCertificate(t *testing.T, dir string, name string, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Key generation error occured: %v", err)
+	}
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("Certificate generation error occured: %v", err)
+	}
+	keyDer, _ := x509.MarshalECPrivateKey(key)
+	_ = os.WriteFile(filepath.Join(dir, name+".pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	_ = os.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	certificate, _ := x509.ParseCertificate(der)
+	return certificate, key
+}
+
+func TestClientTLS(t *testing.T) {
+	if config, err := (DbConfig{}).ClientTLS(); config != nil || err != nil {
+		t.Fatalf("TLS should be disabled: %v %v", config, err)
+	}
+	dir := t.TempDir()
+	ca, caKey := generateCertificate(t, dir, "ca", &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+	generateCertificate(t, dir, "server", &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "localhost"},
+		DNSNames:    []string{"localhost"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+	}, ca, caKey)
+	generateCertificate(t, dir, "client", &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "app"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+	}, ca, caKey)
+	serverCertificate, err := tls.LoadX509KeyPair(filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key"))
+	if err != nil {
+		t.Fatalf("Server certificate error occured: %v", err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverCertificate},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	})
+	if err != nil {
+		t.Fatalf("Listen error occured: %v", err)
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			_ = conn.Close()
+		}
+	}()
+	handshake := func(config DbConfig) error {
+		tlsConfig, err := config.ClientTLS()
+		if err != nil {
+			return err
+		}
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 5 * time.Second}, "tcp", listener.Addr().String(), tlsConfig)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+		// The server verifies the client certificate after the client handshake completes
+		_, err = conn.Read(make([]byte, 1))
+		if err != nil && err.Error() == "EOF" {
+			return nil
+		}
+		return err
+	}
+	config := DbConfig{
+		Certificate: filepath.Join(dir, "client.pem"),
+		PrivateKey:  filepath.Join(dir, "client.key"),
+		TLS: TLSConfig{
+			CAFile:     filepath.Join(dir, "ca.pem"),
+			ServerName: "localhost",
+			MinVersion: "1.3",
+		},
+	}
+	if err = handshake(config); err != nil {
+		t.Fatalf("TLS handshake error occured: %v", err)
+	}
+	wrongName := config
+	wrongName.TLS.ServerName = "db.example.com"
+	if err = handshake(wrongName); err == nil {
+		t.Fatal("Expected server name verification error")
+	}
+	noClientCertificate := config
+	noClientCertificate.Certificate, noClientCertificate.PrivateKey = "", ""
+	if err = handshake(noClientCertificate); err == nil {
+		t.Fatal("Expected client certificate required error")
+	}
+	insecure := DbConfig{TLS: TLSConfig{InsecureSkipVerify: true, CertFile: config.Certificate, KeyFile: config.PrivateKey}}
+	if err = handshake(insecure); err != nil {
+		t.Fatalf("Insecure TLS handshake error occured: %v", err)
+	}
+	config.TLS.MinVersion = "2.0"
+	if _, err = config.ClientTLS(); err == nil {
+		t.Fatal("Expected unknown TLS version error")
+	}
+}
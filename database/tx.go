@@ -0,0 +1,130 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Transaction options descriptor structure
+type TxOptions struct {
+	// Transaction isolation level, the driver default when not provided
+	Isolation sql.IsolationLevel
+	// Read only transaction
+	ReadOnly bool
+}
+
+// Transaction records operations interface, the Connection records operations executed within the transaction.
+// Drivers embed it in their Tx, so the transaction doesn't expose the other Connection methods.
+type TxOperations interface {
+	// Execute Query on the database instance within the transaction
+	QueryContext(ctx context.Context, dbRef DataRef, fields []string, conditions []Condition, withAnd bool) (ResultSet, error)
+	// Execute Query on the database instance within the transaction, selecting records matching the Filter expression
+	QueryFilter(ctx context.Context, dbRef DataRef, fields []string, filter Filter) (ResultSet, error)
+	// Execute Query on the database instance within the transaction, selecting records matching the Filter expression,
+	// sorted and paged following the QueryOptions
+	QueryPage(ctx context.Context, dbRef DataRef, fields []string, filter Filter, options QueryOptions) (ResultSet, error)
+	// Execute Query on the database instance within the transaction, returning an iterator over the matching records
+	Stream(ctx context.Context, dbRef DataRef, fields []string, filter Filter, options QueryOptions) (Rows, error)
+	// Insert record on the database instance within the transaction
+	InsertContext(ctx context.Context, dbRef DataRef, fields []Field, values []Value) error
+	// Insert the rows on the database instance in batches within the transaction
+	InsertBatchContext(ctx context.Context, dbRef DataRef, fields []Field, rows [][]Value, options BatchOptions) ([]BatchResult, error)
+	// Update one or more records on the database instance within the transaction
+	UpdateContext(ctx context.Context, dbRef DataRef, conditions []Condition, fields []Field, values []Value, withAnd bool) (int64, error)
+	// Update records matching the Filter expression on the database instance within the transaction
+	UpdateFilter(ctx context.Context, dbRef DataRef, filter Filter, fields []Field, values []Value) (int64, error)
+	// Delete one or more records on the database instance within the transaction
+	DeleteContext(ctx context.Context, dbRef DataRef, conditions []Condition, withAnd bool) (int64, error)
+	// Delete records matching the Filter expression on the database instance within the transaction
+	DeleteFilter(ctx context.Context, dbRef DataRef, filter Filter) (int64, error)
+	// Count the records matching the Filter expression on the database instance within the transaction
+	Count(ctx context.Context, dbRef DataRef, filter Filter) (int64, error)
+}
+
+// Transaction interface, exposing the Connection records operations executed within the transaction
+type Tx interface {
+	TxOperations
+	// Commits the transaction
+	Commit() error
+	// Rolls back the transaction
+	Rollback() error
+}
+
+// Transactions Connection interface
+type TxConnection interface {
+	// Starts a transaction on the database instance
+	Begin(ctx context.Context, options TxOptions) (Tx, error)
+}
+
+// Connection running managed transactions interface, implemented by drivers with their own commit and
+// retry logic, used by RunInTxContext in place of Begin. The function Tx must not be committed or rolled back.
+type TxRunner interface {
+	RunInTx(ctx context.Context, options TxOptions, fn func(tx Tx) error) error
+}
+
+// Runs the function in a transaction with the default options, committing it when the function succeeds and rolling
+// it back when the function returns an error or panics
+func RunInTx(conn Connection, fn func(tx Tx) error) error {
+	return RunInTxContext(context.Background(), conn, TxOptions{}, fn)
+}
+
+// Runs the function in a transaction with the given options within the given context, committing it when the
+// function succeeds and rolling it back when the function returns an error or panics
+func RunInTxContext(ctx context.Context, conn Connection, options TxOptions, fn func(tx Tx) error) (err error) {
+	if runner, ok := conn.(TxRunner); ok {
+		return runner.RunInTx(ctx, options, fn)
+	}
+	tx, err := conn.Begin(ctx, options)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+			panic(r)
+		}
+	}()
+	if err = fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback error: %v)", err, rbErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// Database/sql statements executor, implemented by sql.DB and sql.Tx
+type SqlExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Database/sql transaction, exposing the records operations of the driver connection bound to the transaction
+type sqlTx struct {
+	TxOperations
+	tx      *sql.Tx
+	opError func(op string, dbRef DataRef, err *error)
+}
+
+func (t *sqlTx) Commit() (err error) {
+	defer t.opError("Commit", DataRef{}, &err)
+	return t.tx.Commit()
+}
+
+func (t *sqlTx) Rollback() (err error) {
+	defer t.opError("Rollback", DataRef{}, &err)
+	return t.tx.Rollback()
+}
+
+// Creates the Tx committing and rolling back the database/sql transaction, the operations are the driver connection
+// bound to the transaction and opError wraps the Commit and Rollback errors in the driver OpError
+func NewSqlTx(operations TxOperations, tx *sql.Tx, opError func(op string, dbRef DataRef, err *error)) Tx {
+	return &sqlTx{
+		TxOperations: operations,
+		tx:           tx,
+		opError:      opError,
+	}
+}
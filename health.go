@@ -0,0 +1,136 @@
+package go_services
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/hellgate75/go-services/database"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Default time allowed to each connection health check
+const DefaultHealthTimeout = 5 * time.Second
+
+// Single connection health report structure
+type ConnectionHealth struct {
+	// Connection status: UP or DOWN
+	Status string `json:"status"`
+	// Ping round trip time in milliseconds
+	LatencyMs float64 `json:"latencyMs"`
+	// Database server version
+	Version string `json:"version,omitempty"`
+	// Health check error message, with the credentials masked
+	Error string `json:"error,omitempty"`
+}
+
+// Aggregated connections health report structure
+type HealthReport struct {
+	// Overall status: UP when all the connections are healthy, DOWN otherwise
+	Status string `json:"status"`
+	// Connections health by name
+	Connections map[string]ConnectionHealth `json:"connections"`
+}
+
+// Health HTTP handler, aggregating the health of the named connections for liveness and readiness probes. It
+// answers 200 with the HealthReport JSON when all the connections are healthy and 503 otherwise.
+type HealthHandler struct {
+	sync.RWMutex
+	// Time allowed to each connection health check, DefaultHealthTimeout when not positive
+	Timeout     time.Duration
+	connections map[string]database.HealthConnection
+}
+
+// Creates an health HTTP handler without connections
+func NewHealthHandler() *HealthHandler {
+	return &HealthHandler{
+		connections: make(map[string]database.HealthConnection),
+	}
+}
+
+// Adds or replaces the named connection in the health checks
+func (h *HealthHandler) Add(name string, conn database.HealthConnection) {
+	h.Lock()
+	defer h.Unlock()
+	h.connections[name] = conn
+}
+
+// Removes the named connection from the health checks
+func (h *HealthHandler) Remove(name string) {
+	h.Lock()
+	defer h.Unlock()
+	delete(h.connections, name)
+}
+
+// Lists the names of the checked connections
+func (h *HealthHandler) Names() []string {
+	h.RLock()
+	defer h.RUnlock()
+	var names = make([]string, 0)
+	for name := range h.connections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Checks all the connections concurrently, each one within the handler timeout
+func (h *HealthHandler) Check(ctx context.Context) HealthReport {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHealthTimeout
+	}
+	h.RLock()
+	var connections = make(map[string]database.HealthConnection)
+	for name, conn := range h.connections {
+		connections[name] = conn
+	}
+	h.RUnlock()
+	report := HealthReport{
+		Status:      "UP",
+		Connections: make(map[string]ConnectionHealth),
+	}
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for name, conn := range connections {
+		wg.Add(1)
+		go func(name string, conn database.HealthConnection) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			check := database.CheckHealth(checkCtx, conn)
+			health := ConnectionHealth{
+				Status:    "UP",
+				LatencyMs: float64(check.Latency) / float64(time.Millisecond),
+				Version:   check.Version,
+			}
+			if !check.Healthy() {
+				health.Status = "DOWN"
+				health.Error = database.Redact(check.Error).Error()
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			report.Connections[name] = health
+			if !check.Healthy() {
+				report.Status = "DOWN"
+			}
+		}(name, conn)
+	}
+	wg.Wait()
+	return report
+}
+
+func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := h.Check(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status != "UP" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	if r.Method != http.MethodHead {
+		_ = json.NewEncoder(w).Encode(report)
+	}
+}
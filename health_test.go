@@ -0,0 +1,77 @@
+package go_services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/hellgate75/go-services/database"
+	_ "github.com/hellgate75/go-services/database/memory"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type unreachableConnection struct{}
+
+func (c unreachableConnection) Ping(ctx context.Context) error {
+	return errors.New("dial postgres://app:s3cret@db:5432/app: connection refused")
+}
+
+func (c unreachableConnection) ServerVersion(ctx context.Context) (string, error) {
+	return "", nil
+}
+
+func TestHealthHandlerRedaction(t *testing.T) {
+	handler := NewHealthHandler()
+	handler.Add("primary", unreachableConnection{})
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if strings.Contains(recorder.Body.String(), "s3cret") || !strings.Contains(recorder.Body.String(), "connection refused") {
+		t.Fatalf("Health report should mask the credentials: %s", recorder.Body.String())
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	driver, err := GetDatabaseDriver(database.MemoryDriver)
+	if err != nil {
+		t.Fatalf("Driver error occured: %v", err)
+	}
+	primary, err := driver.Connect(database.DbConfig{})
+	if err != nil {
+		t.Fatalf("Connection error occured: %v", err)
+	}
+	secondary, err := driver.Connect(database.DbConfig{})
+	if err != nil {
+		t.Fatalf("Connection error occured: %v", err)
+	}
+	handler := NewHealthHandler()
+	handler.Add("primary", primary)
+	handler.Add("secondary", secondary)
+	check := func(expectedCode int) HealthReport {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+		if recorder.Code != expectedCode {
+			t.Fatalf("Wrong status code, expected: %v but was: %v", expectedCode, recorder.Code)
+		}
+		var report HealthReport
+		if err := json.Unmarshal(recorder.Body.Bytes(), &report); err != nil {
+			t.Fatalf("Health report decoding error occured: %v", err)
+		}
+		return report
+	}
+	report := check(http.StatusOK)
+	if report.Status != "UP" || len(report.Connections) != 2 || report.Connections["primary"].Version != "memory" {
+		t.Fatalf("Wrong health report: %+v", report)
+	}
+	_ = secondary.Close()
+	report = check(http.StatusServiceUnavailable)
+	if report.Status != "DOWN" || report.Connections["secondary"].Status != "DOWN" || report.Connections["secondary"].Error == "" {
+		t.Fatalf("Wrong health report: %+v", report)
+	}
+	handler.Remove("secondary")
+	if names := handler.Names(); len(names) != 1 || names[0] != "primary" {
+		t.Fatalf("Wrong connection names: %v", names)
+	}
+	check(http.StatusOK)
+}
@@ -4,9 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"github.com/hellgate75/go-services/database"
-	"github.com/hellgate75/go-services/database/mongodb"
-	"github.com/hellgate75/go-services/database/mysql"
-	"strings"
 )
 
 func GetDatabaseRef(dType database.DriverType, databaseName string, databaseEntity string) (database.DataRef, error) {
@@ -23,27 +20,21 @@ func GetDatabaseRef(dType database.DriverType, databaseName string, databaseEnti
 }
 
 func GetDatabaseDriver(dType database.DriverType) (database.Driver, error) {
-	switch dType {
-	case database.MongoDbDriver:
-		return mongodb.GetMongoDriver(), nil
-	case database.MySQLDriver:
-		return mysql.GetMySqlDriver(), nil
-	default:
+	var name = database.TypeToDriver(dType)
+	if name == "" {
 		return nil, errors.New(fmt.Sprintf("Unknown Database Driver type: %v", dType))
 	}
+	return database.GetDriver(name)
 }
 
-// You can request database using the driver name: mysql, mongodb
+// You can request database using the driver name or alias of any registered driver: mysql, mongodb, ...
 func GetDatabaseDriverByName(driverName string) (database.Driver, error) {
-	var dType = database.DriverToType(driverName)
-	switch dType {
-	case database.MongoDbDriver:
-		return mongodb.GetMongoDriver(), nil
-	case database.MySQLDriver:
-		return mysql.GetMySqlDriver(), nil
-	default:
-		return nil, errors.New(fmt.Sprintf("Unknown Database Driver type: %v", dType))
-	}
+	return database.GetDriver(driverName)
+}
+
+// Lists the names of all registered database drivers
+func GetDatabaseDrivers() []string {
+	return database.Drivers()
 }
 
 type ServiceType byte
@@ -61,13 +52,10 @@ type ServiceDiscovery interface {
 type _serviceDiscovery struct{}
 
 func (sd *_serviceDiscovery) GetServiceByName(name string) (ServiceType, error) {
-	var nmLwr = strings.ToLower(name)
-	switch nmLwr  {
-	case "mongodb":
+	if _, ok := database.DriverName(name); ok {
 		return DatabaseService, nil
-	default:
-		return UnknownService, errors.New(fmt.Sprintf("Unable to discover service type: %s", name))
 	}
+	return UnknownService, errors.New(fmt.Sprintf("Unable to discover service type: %s", name))
 }
 
 func (sd *_serviceDiscovery) GetDatabaseDriver(dType database.DriverType) (database.Driver, error) {